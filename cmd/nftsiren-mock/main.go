@@ -0,0 +1,51 @@
+// nftsiren-mock serves the marketplace and gas endpoints NFTsiren uses
+// with values scripted by a scenario file, so alerts can be tested
+// without waiting for the real market
+//
+// Usage:
+//
+//	nftsiren-mock -scenario scenarios/floor-drop.json -addr localhost:8765 -speed 10
+//
+// Then set the mock server url in NFTsiren's network settings to http://localhost:8765
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"nftsiren/pkg/log"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8765", "listen address")
+	scenarioPath := flag.String("scenario", "", "path to the scenario file (required)")
+	speed := flag.Float64("speed", 1, "scenario time multiplier, 10 plays 10 minutes in one")
+	flag.Parse()
+
+	if *scenarioPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	scenario, err := LoadScenario(*scenarioPath)
+	if err != nil {
+		log.Error().Println("Failed to load scenario:", err)
+		os.Exit(1)
+	}
+
+	server := NewServer(scenario, *speed)
+	log.Info().Printf("Serving scenario %q with %d collections on http://%s", scenario.Name, len(scenario.Collections), *addr)
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	err = httpServer.ListenAndServe()
+	if err != nil {
+		log.Error().Println("Server stopped:", err)
+		os.Exit(1)
+	}
+}
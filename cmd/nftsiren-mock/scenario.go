@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"nftsiren/pkg/nft"
+)
+
+// Duration accepts go duration strings like "10m" or "1h30m" in json
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %s", data)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+type Keyframe struct {
+	At    Duration `json:"at"`
+	Value float64  `json:"value"`
+}
+
+// Series is a list of keyframes, values between keyframes are interpolated linearly
+// A plain number in json means a constant series
+type Series []Keyframe
+
+func (series *Series) UnmarshalJSON(data []byte) error {
+	var constant float64
+	if err := json.Unmarshal(data, &constant); err == nil {
+		*series = Series{{Value: constant}}
+		return nil
+	}
+	var frames []Keyframe
+	if err := json.Unmarshal(data, &frames); err != nil {
+		return err
+	}
+	sort.SliceStable(frames, func(i, j int) bool { return frames[i].At < frames[j].At })
+	*series = frames
+	return nil
+}
+
+// Value reports the value at elapsed time since the scenario started
+// Returns false when the series is empty
+func (series Series) Value(elapsed time.Duration) (float64, bool) {
+	if len(series) == 0 {
+		return 0, false
+	}
+	if elapsed <= time.Duration(series[0].At) {
+		return series[0].Value, true
+	}
+	for i := 1; i < len(series); i++ {
+		prev, next := series[i-1], series[i]
+		if elapsed < time.Duration(next.At) {
+			span := float64(next.At - prev.At)
+			t := float64(elapsed-time.Duration(prev.At)) / span
+			return prev.Value + (next.Value-prev.Value)*t, true
+		}
+	}
+	return series[len(series)-1].Value, true
+}
+
+// Requests inside an outage fail with the given status code
+type Outage struct {
+	From   Duration `json:"from"`
+	To     Duration `json:"to"`
+	Status int      `json:"status"`
+}
+
+type Outages []Outage
+
+// Returns the status code if elapsed is inside an outage
+func (outages Outages) Active(elapsed time.Duration) (int, bool) {
+	for _, o := range outages {
+		if elapsed >= time.Duration(o.From) && elapsed < time.Duration(o.To) {
+			status := o.Status
+			if status == 0 {
+				status = 500
+			}
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+type ScenarioCollection struct {
+	Market      nft.Marketplace `json:"market"`
+	Symbol      string          `json:"symbol"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	ImageURL    string          `json:"imageUrl"`
+	Floor       Series          `json:"floor"`     // ETH or SOL
+	DaySales    Series          `json:"daySales"`  //
+	DayVolume   Series          `json:"dayVolume"` // ETH or SOL
+	TotalSales  Series          `json:"totalSales"`
+	TotalVolume Series          `json:"totalVolume"`
+	Owners      Series          `json:"owners"`
+	Supply      Series          `json:"supply"`
+	Listed      Series          `json:"listed"`
+	Outages     Outages         `json:"outages"`
+}
+
+type Scenario struct {
+	Name        string               `json:"name"`
+	Loop        Duration             `json:"loop"` // Restarts the scenario after this duration, zero means never
+	Eth         Series               `json:"eth"`  // USD
+	SafeGas     Series               `json:"safeGas"`
+	Gas         Series               `json:"gas"` // Propose gas price
+	FastGas     Series               `json:"fastGas"`
+	BaseFee     Series               `json:"baseFee"`
+	Outages     Outages              `json:"outages"` // Applied to etherscan
+	Collections []ScenarioCollection `json:"collections"`
+}
+
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	scenario := &Scenario{}
+	err = json.Unmarshal(data, scenario)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return scenario, scenario.Validate()
+}
+
+func (scenario *Scenario) Validate() error {
+	seen := make(map[string]bool)
+	for _, c := range scenario.Collections {
+		if c.Symbol == "" {
+			return errors.New("collection symbol is required")
+		}
+		if len(c.Floor) == 0 {
+			return fmt.Errorf("collection %s has no floor", c.Symbol)
+		}
+		key := c.Market.String() + "/" + c.Symbol
+		if seen[key] {
+			return fmt.Errorf("collection %s is defined twice", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+func (scenario *Scenario) Collection(market nft.Marketplace, symbol string) (*ScenarioCollection, bool) {
+	for i := range scenario.Collections {
+		c := &scenario.Collections[i]
+		if c.Market == market && c.Symbol == symbol {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// Maps wall clock time into scenario time
+func (scenario *Scenario) Elapsed(start time.Time, now time.Time, speed float64) time.Duration {
+	elapsed := time.Duration(float64(now.Sub(start)) * speed)
+	if scenario.Loop > 0 {
+		elapsed %= time.Duration(scenario.Loop)
+	}
+	return elapsed
+}
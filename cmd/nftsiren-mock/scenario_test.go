@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesValue(t *testing.T) {
+	var series Series
+	require.NoError(t, json.Unmarshal([]byte(`[{"at":"10m","value":1.4},{"at":"0s","value":2.0}]`), &series))
+	at := func(d time.Duration) float64 {
+		v, ok := series.Value(d)
+		require.True(t, ok)
+		return v
+	}
+	assert.InDelta(t, 2.0, at(0), 1e-9)
+	assert.InDelta(t, 1.7, at(5*time.Minute), 1e-9)
+	assert.InDelta(t, 1.4, at(10*time.Minute), 1e-9)
+	assert.InDelta(t, 1.4, at(time.Hour), 1e-9)
+
+	var constant Series
+	require.NoError(t, json.Unmarshal([]byte(`42`), &constant))
+	v, ok := constant.Value(time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, 42.0, v)
+
+	_, ok = Series(nil).Value(0)
+	assert.False(t, ok)
+}
+
+func TestOutages(t *testing.T) {
+	outages := Outages{{From: Duration(time.Minute), To: Duration(2 * time.Minute)}}
+	_, down := outages.Active(30 * time.Second)
+	assert.False(t, down)
+	status, down := outages.Active(90 * time.Second)
+	assert.True(t, down)
+	assert.Equal(t, 500, status)
+}
+
+func TestLoadScenario(t *testing.T) {
+	scenario, err := LoadScenario("scenarios/floor-drop.json")
+	require.NoError(t, err)
+	assert.Len(t, scenario.Collections, 3)
+	start := time.Now()
+	// Loops every 30 minutes and speed multiplies wall clock
+	assert.Equal(t, 5*time.Minute, scenario.Elapsed(start, start.Add(35*time.Minute), 1))
+	assert.Equal(t, 10*time.Minute, scenario.Elapsed(start, start.Add(time.Minute), 10))
+}
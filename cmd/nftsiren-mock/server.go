@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"nftsiren/pkg/apis"
+	"nftsiren/pkg/log"
+	"nftsiren/pkg/nft"
+)
+
+// Server serves the marketplace and etherscan endpoints NFTsiren uses
+// Responses only contain the fields our api clients decode
+type Server struct {
+	scenario *Scenario
+	start    time.Time
+	speed    float64
+	now      func() time.Time
+	mux      *http.ServeMux
+}
+
+func NewServer(scenario *Scenario, speed float64) *Server {
+	if speed <= 0 {
+		speed = 1
+	}
+	server := &Server{
+		scenario: scenario,
+		start:    time.Now(),
+		speed:    speed,
+		now:      time.Now,
+		mux:      http.NewServeMux(),
+	}
+	server.mux.HandleFunc(apis.MockOpenseaPath+"/", server.opensea)
+	server.mux.HandleFunc(apis.MockLooksrarePath+"/", server.looksrare)
+	server.mux.HandleFunc(apis.MockMagicedenPath+"/", server.magiceden)
+	server.mux.HandleFunc(apis.MockEtherscanPath, server.etherscan)
+	return server
+}
+
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Debug().Println(r.Method, r.URL)
+	server.mux.ServeHTTP(w, r)
+}
+
+func (server *Server) elapsed() time.Duration {
+	return server.scenario.Elapsed(server.start, server.now(), server.speed)
+}
+
+func writeJson(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Splits the path after the provider prefix
+func pathElements(r *http.Request, prefix string) []string {
+	p := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// Returns nil for empty series, so the field is encoded as null
+func value(series Series, elapsed time.Duration) any {
+	v, ok := series.Value(elapsed)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+// Big integer string in given decimals, used for wei and lamports
+func scaled(series Series, elapsed time.Duration, decimals int) any {
+	v, ok := series.Value(elapsed)
+	if !ok {
+		return nil
+	}
+	f := new(big.Float).SetFloat64(v)
+	f.Mul(f, new(big.Float).SetFloat64(math.Pow10(decimals)))
+	i, _ := f.Int(nil)
+	return i.String()
+}
+
+// Returns nil when the collection is not in the scenario
+// Reports true when an outage response is already written
+func (server *Server) lookup(w http.ResponseWriter, market nft.Marketplace, symbol string) (*ScenarioCollection, time.Duration, bool) {
+	elapsed := server.elapsed()
+	c, ok := server.scenario.Collection(market, symbol)
+	if !ok {
+		return nil, elapsed, false
+	}
+	if status, down := c.Outages.Active(elapsed); down {
+		http.Error(w, http.StatusText(status), status)
+		return nil, elapsed, true
+	}
+	return c, elapsed, false
+}
+
+// GET /collection/{slug} and /collection/{slug}/stats
+func (server *Server) opensea(w http.ResponseWriter, r *http.Request) {
+	elements := pathElements(r, apis.MockOpenseaPath)
+	if len(elements) < 2 || elements[0] != "collection" {
+		writeJson(w, http.StatusNotFound, map[string]any{"success": false})
+		return
+	}
+	c, elapsed, down := server.lookup(w, nft.Opensea, elements[1])
+	if down {
+		return
+	}
+	if c == nil {
+		writeJson(w, http.StatusNotFound, map[string]any{"success": false})
+		return
+	}
+	stats := map[string]any{
+		"floor_price":    value(c.Floor, elapsed),
+		"one_day_sales":  value(c.DaySales, elapsed),
+		"one_day_volume": value(c.DayVolume, elapsed),
+		"total_sales":    value(c.TotalSales, elapsed),
+		"total_volume":   value(c.TotalVolume, elapsed),
+		"num_owners":     value(c.Owners, elapsed),
+		"count":          value(c.Supply, elapsed),
+	}
+	if len(elements) > 2 && elements[2] == "stats" {
+		writeJson(w, http.StatusOK, map[string]any{"stats": stats})
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]any{
+		"collection": map[string]any{
+			"name":        c.Name,
+			"description": c.Description,
+			"image_url":   c.ImageURL,
+			"slug":        c.Symbol,
+			"stats":       stats,
+		},
+	})
+}
+
+// GET /collections?address= and /collections/stats?address=
+func (server *Server) looksrare(w http.ResponseWriter, r *http.Request) {
+	elements := pathElements(r, apis.MockLooksrarePath)
+	address := r.URL.Query().Get("address")
+	notFound := map[string]any{"success": false, "message": "Collection not found", "data": nil}
+	if len(elements) < 1 || elements[0] != "collections" {
+		writeJson(w, http.StatusNotFound, notFound)
+		return
+	}
+	c, elapsed, down := server.lookup(w, nft.Looksrare, address)
+	if down {
+		return
+	}
+	if c == nil {
+		writeJson(w, http.StatusNotFound, notFound)
+		return
+	}
+	if len(elements) > 1 && elements[1] == "stats" {
+		writeJson(w, http.StatusOK, map[string]any{
+			"success": true,
+			"data": map[string]any{
+				"address":     c.Symbol,
+				"floorPrice":  scaled(c.Floor, elapsed, 18),
+				"count24h":    value(c.DaySales, elapsed),
+				"volume24h":   scaled(c.DayVolume, elapsed, 18),
+				"countAll":    value(c.TotalSales, elapsed),
+				"volumeAll":   scaled(c.TotalVolume, elapsed, 18),
+				"countOwners": value(c.Owners, elapsed),
+				"totalSupply": value(c.Supply, elapsed),
+			},
+		})
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"address":     c.Symbol,
+			"name":        c.Name,
+			"description": c.Description,
+			"logoURI":     c.ImageURL,
+		},
+	})
+}
+
+// GET /collections/{symbol} and /collections/{symbol}/stats
+func (server *Server) magiceden(w http.ResponseWriter, r *http.Request) {
+	elements := pathElements(r, apis.MockMagicedenPath)
+	notFound := map[string]any{"statusCode": 404, "error": "Not Found", "message": "collection not found"}
+	if len(elements) < 2 || elements[0] != "collections" {
+		writeJson(w, http.StatusNotFound, notFound)
+		return
+	}
+	c, elapsed, down := server.lookup(w, nft.Magiceden, elements[1])
+	if down {
+		return
+	}
+	if c == nil {
+		writeJson(w, http.StatusNotFound, notFound)
+		return
+	}
+	resp := map[string]any{
+		"symbol":      c.Symbol,
+		"floorPrice":  scaled(c.Floor, elapsed, 9),
+		"listedCount": value(c.Listed, elapsed),
+		"volumeAll":   scaled(c.TotalVolume, elapsed, 9),
+	}
+	if len(elements) < 3 || elements[2] != "stats" {
+		resp["name"] = c.Name
+		resp["description"] = c.Description
+		resp["image"] = c.ImageURL
+	}
+	writeJson(w, http.StatusOK, resp)
+}
+
+// GET ?module=stats&action=ethprice and ?module=gastracker&action=gasoracle
+func (server *Server) etherscan(w http.ResponseWriter, r *http.Request) {
+	elapsed := server.elapsed()
+	if status, down := server.scenario.Outages.Active(elapsed); down {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	str := func(series Series) string {
+		v, _ := series.Value(elapsed)
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	q := r.URL.Query()
+	switch q.Get("module") + "/" + q.Get("action") {
+	case "stats/ethprice":
+		writeJson(w, http.StatusOK, map[string]any{
+			"status":  "1",
+			"message": "OK",
+			"result": map[string]any{
+				"ethusd":           str(server.scenario.Eth),
+				"ethusd_timestamp": strconv.FormatInt(server.now().Unix(), 10),
+			},
+		})
+	case "gastracker/gasoracle":
+		writeJson(w, http.StatusOK, map[string]any{
+			"status":  "1",
+			"message": "OK",
+			"result": map[string]any{
+				"LastBlock":       strconv.FormatInt(int64(elapsed/(12*time.Second)), 10),
+				"SafeGasPrice":    str(server.scenario.SafeGas),
+				"ProposeGasPrice": str(server.scenario.Gas),
+				"FastGasPrice":    str(server.scenario.FastGas),
+				"suggestBaseFee":  str(server.scenario.BaseFee),
+			},
+		})
+	default:
+		writeJson(w, http.StatusOK, map[string]any{
+			"status":  "0",
+			"message": "NOTOK",
+			"result":  "Error! Missing Or invalid Module name",
+		})
+	}
+}
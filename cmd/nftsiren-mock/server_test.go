@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nftsiren/pkg/apis"
+	"nftsiren/pkg/apis/etherscan"
+	"nftsiren/pkg/nft"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Runs the real api clients against the mock server
+func TestServer(t *testing.T) {
+	scenario, err := LoadScenario("scenarios/floor-drop.json")
+	require.NoError(t, err)
+	server := NewServer(scenario, 1)
+	elapsed := 5 * time.Minute
+	server.now = func() time.Time { return server.start.Add(elapsed) }
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	require.NoError(t, apis.SetMockServer(httpServer.URL))
+	defer apis.SetMockServer("")
+
+	// Opensea
+	c, err := apis.FetchCollection(nft.Opensea, "mock-apes")
+	require.NoError(t, err)
+	assert.Equal(t, "Mock Apes", c.Name)
+	require.NotNil(t, c.Stats)
+	assert.InDelta(t, 1.7, c.Stats.Floor.Float64(), 1e-9)
+	stats, err := apis.FetchCollectionStats(nft.Opensea, "mock-apes")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.7, stats.Floor.Float64(), 1e-9)
+	assert.Equal(t, "10000", stats.TotalSupply.String())
+	_, err = apis.FetchCollection(nft.Opensea, "unknown")
+	assert.Error(t, err)
+
+	// Looksrare converts from wei
+	stats, err = apis.FetchCollectionStats(nft.Looksrare, "0x0000000000000000000000000000000000000001")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.7, stats.Floor.Float64(), 1e-9)
+	assert.Equal(t, "1200", stats.NumOwners.String())
+
+	// Magiceden converts from lamports
+	stats, err = apis.FetchCollectionStats(nft.Magiceden, "mock_bears")
+	require.NoError(t, err)
+	assert.InDelta(t, 35.5, stats.Floor.Float64(), 1e-9)
+	assert.Equal(t, "410", stats.Listed.String())
+
+	// Etherscan
+	eth, err := etherscan.FetchEthPrice()
+	require.NoError(t, err)
+	assert.Equal(t, "1750", eth.Ethusd.String())
+	gas, err := etherscan.FetchGasPrice()
+	require.NoError(t, err)
+	assert.Equal(t, "14", gas.ProposeGasPrice.String())
+	assert.Equal(t, "17.5", gas.SuggestBaseFee.String())
+
+	// Collection outage
+	elapsed = 13 * time.Minute
+	_, err = apis.FetchCollectionStats(nft.Opensea, "mock-apes")
+	assert.EqualError(t, err, "Internal Server Error")
+
+	// Etherscan outage
+	elapsed = 26 * time.Minute
+	_, err = etherscan.FetchGasPrice()
+	assert.EqualError(t, err, "Bad Gateway")
+}
@@ -3,11 +3,15 @@ package main
 import (
 	"errors"
 	"fmt"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 
+	"nftsiren/cmd/nftsiren/alertengine"
 	"nftsiren/cmd/nftsiren/alerts"
-	"nftsiren/cmd/nftsiren/config"
+	"nftsiren/cmd/nftsiren/escalation"
+	"nftsiren/cmd/nftsiren/history"
+	"nftsiren/cmd/nftsiren/notifier"
 	"nftsiren/cmd/nftsiren/widgets"
 	"nftsiren/pkg/bench"
 	"nftsiren/pkg/log"
@@ -20,80 +24,94 @@ import (
 	"gioui.org/widget"
 	"gioui.org/widget/material"
 	"gioui.org/x/component"
-	"gioui.org/x/notify"
 )
 
-// Alert encapsulates alerts.Alert and also implements it
-type Alert[T alerts.Alert] struct {
-	handle mutex.Value[T]
-	// latest notification time
-	last mutex.Value[time.Time]
-	// checker is mutex locked, do not use any of the alerts methods, use handle directly
-	checker func() bool
-	// will be called when removing this alert
-	onRemove func()
+// Alert adds the gui to alertengine.Alert
+type Alert[T alerts.Editable[T]] struct {
+	*alertengine.Alert[T]
 	// will be called constantly while layouting
-	layouter func(layout.Context, *Theme) layout.Dimensions
+	layouter func(layout.Context, *Theme, *PageStack) layout.Dimensions
 	// for gui
 	remove widget.Clickable
+	edit   widget.Clickable
 }
 
-func (alert *Alert[T]) String() string {
-	return alert.Handle().String()
+func defaultNotification[T alerts.Editable[T]](alert *alertengine.Alert[T]) (string, string) {
+	return NAME, alert.NotificationText()
 }
 
-func (alert *Alert[T]) Description() string {
-	return alert.Handle().Description()
-}
-
-func (alert *Alert[T]) NeedsInterval() bool {
-	return alert.Handle().NeedsInterval()
-}
-
-func (alert *Alert[T]) Interval() int {
-	return alert.Handle().Interval()
-}
-
-func (alert *Alert[T]) Looping() bool {
-	return alert.Handle().Looping()
-}
-
-func (alert *Alert[T]) Handle() T {
-	return alert.handle.Load()
+// Pushes the notification through quiet hours and records it to the history
+// origin gives the kind and collection of the entry
+func (daemon *Daemon) alertFired(origin func() history.Entry) func(alertengine.Event) {
+	return func(event alertengine.Event) {
+		entry := origin()
+		entry.AlertID = event.AlertID
+		entry.Condition = event.Condition
+		entry.Value = event.Value
+		entry.Title = event.Title
+		entry.Text = event.Text
+		entry.Time = event.Time.Unix()
+		entry, err := daemon.History.Add(entry)
+		if err != nil {
+			log.Error().Println("Failed to save alert history:", err)
+		}
+		severity := notifier.SeverityInfo
+		if event.Priority == alerts.PriorityCritical {
+			severity = notifier.SeverityCritical
+		}
+		daemon.QuietHours.Notify(event.Schedule, daemon.withCollection(notifier.Notification{
+			Title:      event.Title,
+			Body:       event.Text,
+			Severity:   severity,
+			Kind:       entry.Kind,
+			AlertID:    event.AlertID,
+			Condition:  event.Condition,
+			Collection: entry.Collection,
+			Name:       entry.Name,
+			URL:        entry.URL(),
+			Value:      event.Value,
+			Time:       event.Time,
+		}))
+		if event.Priority == alerts.PriorityCritical {
+			daemon.Escalations.Add(escalation.Pending{
+				ID:       entry.ID,
+				AlertID:  event.AlertID,
+				Title:    event.Title,
+				Text:     event.Text,
+				URL:      entry.URL(),
+				Schedule: event.Schedule,
+				Fired:    event.Time.Unix(),
+			})
+		}
+	}
 }
 
-// Check will send notification if required and returns ok on notification
-// number argument is here because of implementing alerts.Alert, it is noop
-func (alert *Alert[T]) Check(number.Number) bool {
-	// Check cooldown
-	notifCooldown := config.LoadFallback[int]("notificationCooldown", 60)
-	if time.Since(alert.last.Load()) < time.Duration(notifCooldown)*time.Second {
-		// Do not notify continuously
-		log.Info().Printf("Passed check for %s because cooldown", alert.handle.String())
-		return false
+// Adds image, floor and marketplace page of the watched collection for rich channels
+func (daemon *Daemon) withCollection(notification notifier.Notification) notifier.Notification {
+	if notification.Collection == nil || notification.Collection.IsEth() {
+		return notification
 	}
-	// Check
-	ok := alert.checker()
-	// Set last notification time
-	if ok {
-		alert.last.Store(time.Now())
-		// Remove this alert if it's not looping
-		if !alert.Looping() {
-			alert.onRemove()
+	collection := daemon.Collection(*notification.Collection)
+	if collection == nil {
+		return notification
+	}
+	if info := collection.Info(); info != nil {
+		notification.ImageURL = info.ImageURL
+		notification.Currency = info.Currency.String()
+		if info.Marketpage != "" {
+			notification.URL = info.Marketpage
 		}
 	}
-	return ok
-}
-
-func (alert *Alert[T]) NotificationText() string {
-	return alert.Handle().NotificationText()
+	notification.Floor, _ = collection.Floor()
+	notification.FloorChange, _ = collection.FloorChange()
+	return notification
 }
 
-func (alert *Alert[T]) Layout(gtx layout.Context, theme *Theme) layout.Dimensions {
-	return alert.layouter(gtx, theme)
+func (alert *Alert[T]) Layout(gtx layout.Context, theme *Theme, pages *PageStack) layout.Dimensions {
+	return alert.layouter(gtx, theme, pages)
 }
 
-func (alert *Alert[T]) defaultLayouter(gtx layout.Context, theme *Theme) layout.Dimensions {
+func (alert *Alert[T]) defaultLayouter(gtx layout.Context, theme *Theme, pages *PageStack) layout.Dimensions {
 	return theme.Background(gtx, theme.DarkerBg, func(gtx layout.Context) layout.Dimensions {
 		return theme.SmallInset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 			spacer := layout.Rigid(theme.MediumHSpacer.Layout)
@@ -116,7 +134,11 @@ func (alert *Alert[T]) defaultLayouter(gtx layout.Context, theme *Theme) layout.
 					}.Layout(gtx,
 						// description
 						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-							label := material.Body2(theme.Material(), alert.Description())
+							desc := alert.Description()
+							if summary := alert.Triggering().Summary(); summary != "" {
+								desc += " (" + summary + ")"
+							}
+							label := material.Body2(theme.Material(), desc)
 							label.Alignment = text.Middle
 							label.Color = theme.MediumImpFg
 							return label.Layout(gtx)
@@ -130,13 +152,31 @@ func (alert *Alert[T]) defaultLayouter(gtx layout.Context, theme *Theme) layout.
 							}
 							return theme.LoopIcon.Layout(gtx, theme.IconSize, theme.Fg)
 						}),
+						// paused or snoozed
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							trigger := alert.Triggering()
+							if trigger.Paused {
+								return theme.PauseIcon.Layout(gtx, theme.IconSize, theme.Fg)
+							}
+							if trigger.SnoozedAt(time.Now()) {
+								return theme.SnoozeIcon.Layout(gtx, theme.IconSize, theme.Fg)
+							}
+							return layout.Dimensions{}
+						}),
 					)
 				}),
+				// edit button
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if alert.edit.Clicked() {
+						pages.Push(NewAlertEditPage(alert))
+					}
+					return theme.IconButton(theme.EditIcon, &alert.edit).Layout(gtx)
+				}),
 				// remove button
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 					if alert.remove.Clicked() {
 						TODO("Show are you sure modal")
-						alert.onRemove()
+						alert.Remove()
 					}
 					return theme.IconButton(theme.DeleteIcon, &alert.remove).Layout(gtx)
 				}),
@@ -148,89 +188,189 @@ func (alert *Alert[T]) defaultLayouter(gtx layout.Context, theme *Theme) layout.
 
 func NewEthAlert(handle alerts.EthereumAlert, daemon *Daemon) *Alert[alerts.EthereumAlert] {
 	alert := &Alert[alerts.EthereumAlert]{}
-	alert.handle.Store(handle)
-	alert.checker = func() bool {
-		if !daemon.GasTracker.EthStillValid() {
-			return false
-		}
-		if alert.Handle().Check(daemon.GasTracker.GetEth()) {
-			notify.Push(NAME, alert.NotificationText())
-			return true
-		}
-		return false
-	}
-	alert.onRemove = func() {
-		go daemon.RemoveEthAlert(alert)
-	}
+	alert.Alert = alertengine.New(handle, alertengine.Settings[alerts.EthereumAlert]{
+		Checker: func(alert *alertengine.Alert[alerts.EthereumAlert]) (bool, number.Number) {
+			return alertengine.CheckEth(alert.Handle(), livePrice{daemon: daemon, gas: false})
+		},
+		Notification: defaultNotification[alerts.EthereumAlert],
+		OnFire: daemon.alertFired(func() history.Entry {
+			return history.Entry{Kind: history.KindEthereum}
+		}),
+		OnRemove: func() {
+			go daemon.RemoveEthAlert(alert)
+		},
+	})
 	alert.layouter = alert.defaultLayouter
 	return alert
 }
 
 func NewGasAlert(handle alerts.GasAlert, daemon *Daemon) *Alert[alerts.GasAlert] {
 	alert := &Alert[alerts.GasAlert]{}
-	alert.handle.Store(handle)
-	alert.checker = func() bool {
-		if !daemon.GasTracker.GasStillValid() {
-			return false
-		}
-		if alert.Handle().Check(daemon.GasTracker.GetGas()) {
-			notify.Push(NAME, alert.NotificationText())
-			return true
-		}
-		return false
-	}
-	alert.onRemove = func() {
-		go daemon.RemoveGasAlert(alert)
-	}
+	alert.Alert = alertengine.New(handle, alertengine.Settings[alerts.GasAlert]{
+		Checker: func(alert *alertengine.Alert[alerts.GasAlert]) (bool, number.Number) {
+			return alertengine.CheckGas(alert.Handle(), livePrice{daemon: daemon, gas: true, tier: alert.Handle().Tier})
+		},
+		Notification: defaultNotification[alerts.GasAlert],
+		OnFire: daemon.alertFired(func() history.Entry {
+			return history.Entry{Kind: history.KindGas}
+		}),
+		OnRemove: func() {
+			go daemon.RemoveGasAlert(alert)
+		},
+	})
 	alert.layouter = alert.defaultLayouter
 	return alert
 }
 
 func NewCollectionAlert(handle alerts.CollectionAlert, collection *Collection) *Alert[alerts.CollectionAlert] {
 	alert := &Alert[alerts.CollectionAlert]{}
-	alert.handle.Store(handle)
-	alert.checker = func() bool {
-		checkresult := false
-		switch alert.Handle().Type {
-		case alerts.CollectionAlertTypeFloorLessThan, alerts.CollectionAlertTypeFloorGreaterThan:
-			// Direct check against floor
-			floor, ok := collection.Floor()
-			if ok {
-				checkresult = alert.Handle().Check(floor)
-			}
-		case alerts.CollectionAlertTypeSalesGreaterThan:
-			// Compare current TotalSales with previous TotalSales
-			// First check interval
-			// TODO
-		}
-		if checkresult {
+	alert.Alert = alertengine.New(handle, alertengine.Settings[alerts.CollectionAlert]{
+		Checker: func(alert *alertengine.Alert[alerts.CollectionAlert]) (bool, number.Number) {
+			return alertengine.CheckCollection(alert, collection)
+		},
+		Notification: func(alert *alertengine.Alert[alerts.CollectionAlert]) (string, string) {
 			name, _ := collection.Name()
 			title := fmt.Sprintf("%s | %s", collection.Market.Load(), name)
-			txt := alert.NotificationText()
-			notify.Push(title, txt)
-			return true
-		}
-		return false
-	}
-	alert.onRemove = func() {
-		go collection.RemoveAlert(alert)
-	}
+			return title, alert.NotificationText()
+		},
+		OnFire: collection.Daemon.alertFired(func() history.Entry {
+			asset := collection.Asset()
+			name, _ := collection.Name()
+			return history.Entry{Kind: history.KindCollection, Collection: &asset, Name: name}
+		}),
+		OnRemove: func() {
+			go collection.RemoveAlert(alert)
+		},
+	})
 	alert.layouter = alert.defaultLayouter
 	return alert
 }
 
+func NewPairAlert(handle alerts.PairAlert, daemon *Daemon) *Alert[alerts.PairAlert] {
+	alert := &Alert[alerts.PairAlert]{}
+	alert.Alert = alertengine.New(handle, alertengine.Settings[alerts.PairAlert]{
+		Checker: func(alert *alertengine.Alert[alerts.PairAlert]) (bool, number.Number) {
+			a, ok := daemon.AssetValue(alert.Handle().A)
+			if !ok {
+				return false, number.Number{}
+			}
+			b, ok := daemon.AssetValue(alert.Handle().B)
+			if !ok {
+				return false, number.Number{}
+			}
+			value, ok := alert.Handle().Value(a, b)
+			if !ok {
+				return false, number.Number{}
+			}
+			return alert.Handle().Check(value), value
+		},
+		Notification: defaultNotification[alerts.PairAlert],
+		OnFire: daemon.alertFired(func() history.Entry {
+			// Entries hold one collection, first side is preferred
+			entry := history.Entry{Kind: history.KindPair}
+			for _, asset := range []alerts.Asset{alert.Handle().A, alert.Handle().B} {
+				if !asset.IsEth() {
+					entry.Collection = &asset
+					break
+				}
+			}
+			return entry
+		}),
+		OnRemove: func() {
+			go daemon.RemovePairAlert(alert)
+		},
+	})
+	alert.layouter = alert.defaultLayouter
+	return alert
+}
+
+func NewCompoundAlert(handle alerts.CompoundAlert, daemon *Daemon) *Alert[alerts.CompoundAlert] {
+	alert := &Alert[alerts.CompoundAlert]{}
+	alert.Alert = alertengine.New(handle, alertengine.Settings[alerts.CompoundAlert]{
+		Checker: func(alert *alertengine.Alert[alerts.CompoundAlert]) (bool, number.Number) {
+			return alert.Handle().Match(func(clause alerts.Clause) bool {
+				return alertengine.ClauseHolds(clause, liveSources{daemon})
+			}), number.Number{}
+		},
+		Notification: defaultNotification[alerts.CompoundAlert],
+		OnFire: daemon.alertFired(func() history.Entry {
+			// Entries hold one collection, first one is preferred
+			entry := history.Entry{Kind: history.KindCompound}
+			for _, clause := range alert.Handle().Clauses {
+				if clause.Collection != nil {
+					asset := clause.Asset
+					entry.Collection = &asset
+					break
+				}
+			}
+			return entry
+		}),
+		OnRemove: func() {
+			go daemon.RemoveCompoundAlert(alert)
+		},
+	})
+	alert.layouter = alert.defaultLayouter
+	return alert
+}
+
+// AlertInput is what user entered in AlertCreationPage
+type AlertInput struct {
+	Type     alerts.Condition
+	Value    number.Number
+	High     number.Number // Only set for range conditions
+	Interval int           // Seconds, only set when type needs an interval
+	Loop     bool
+	A, B     alerts.Asset    // Only set in pair mode, B is not set when only one side is asked
+	Clauses  []alerts.Clause // Only set in compound mode
+	Tier     alerts.GasTier  // Only set when tiers are enabled
+	Trigger  alerts.Trigger
+}
+
 type AlertCreationPage struct {
 	title    string
-	onCreate func(t alerts.Condition, n number.Number, loop bool)
+	onCreate func(input AlertInput)
 	List     widget.List
 	Type     widgets.TypedEnum[alerts.Condition]
 	Value    component.TextField
-	Loop     widget.Bool
-	Error    error
-	Ok       widget.Clickable
+	High     component.TextField // Only shown for range conditions
+	Interval component.TextField // Only shown when selected type needs an interval
+	// trigger settings, empty ones use the defaults
+	Cooldown   component.TextField
+	Debounce   component.TextField
+	Hysteresis component.TextField // Not shown in advanced mode
+	QuietHours component.TextField // Own quiet ranges of the alert
+	Critical   widget.Bool         // Repeats until acknowledged
+	Loop       widget.Bool
+	Error      error
+	Ok         widget.Clickable
+	// advanced mode, only available when expressions are enabled
+	exprFields   []string
+	onCreateExpr func(expr string, input AlertInput)
+	Advanced     widget.Bool
+	Expression   component.TextField
+	validated    string // Last validated expression text
+	// pair mode, sides are selected from the assets when entering
+	pairAssets func() []alerts.Asset
+	SideA      widgets.TypedEnum[alerts.Asset]
+	SideB      widgets.TypedEnum[alerts.Asset]
+	singleSide bool // Only A is asked, for collection conditions
+	// compound mode, conditions are added from the clause pages
+	clausePages  []*AlertCreationPage
+	AddClause    []widget.Clickable // For clausePages
+	clauses      []alerts.Clause
+	RemoveClause []widget.Clickable // For clauses
+	// clause mode, conditions of compound alerts have no trigger settings of their own
+	clauseOnly bool
+	// gas tier selection, proposed price is selected by default
+	Tier widgets.TypedEnum[alerts.GasTier]
+	// preview on the recorded history, only available when backtest is enabled
+	backtest func(expr string, input AlertInput) alertengine.Backtest
+	Preview  widget.Clickable
+	result   mutex.Value[*alertengine.Backtest] // Nil while replaying
+	replayed bool                               // Whether preview is clicked
 }
 
-func NewAlertCreationPage(title string, onCreate func(t alerts.Condition, n number.Number, loop bool), types ...alerts.Condition) *AlertCreationPage {
+func NewAlertCreationPage(title string, onCreate func(input AlertInput), types ...alerts.Condition) *AlertCreationPage {
 	page := &AlertCreationPage{
 		title:    title,
 		onCreate: onCreate,
@@ -241,23 +381,147 @@ func NewAlertCreationPage(title string, onCreate func(t alerts.Condition, n numb
 	page.Value.Submit = true // TODO: check submit
 	page.Value.InputHint = key.HintNumeric
 	page.Value.Filter = "0123456789."
+	page.High.SingleLine = true
+	page.High.InputHint = key.HintNumeric
+	page.High.Filter = "0123456789."
+	page.Interval.SingleLine = true
+	page.Interval.Filter = "0123456789.hms"
+	page.Cooldown.SingleLine = true
+	page.Cooldown.Filter = "0123456789.hms"
+	page.Debounce.SingleLine = true
+	page.Debounce.InputHint = key.HintNumeric
+	page.Debounce.Filter = "0123456789"
+	page.Hysteresis.SingleLine = true
+	page.Hysteresis.InputHint = key.HintNumeric
+	page.Hysteresis.Filter = "0123456789."
+	page.QuietHours.SingleLine = true
+	page.QuietHours.Filter = "0123456789:-, "
 	return page
 }
 
+// Enables advanced mode which creates alerts from expressions over the given fields
+func (page *AlertCreationPage) EnableExpressions(fields []string, onCreate func(expr string, input AlertInput)) {
+	page.exprFields = fields
+	page.onCreateExpr = onCreate
+	page.Expression.SingleLine = true
+}
+
+// Enables pair mode which asks for two assets to compare before the type
+func (page *AlertCreationPage) EnablePairs(assets func() []alerts.Asset) {
+	page.pairAssets = assets
+}
+
+// Enables single side pair mode, the selected asset is set as A
+func (page *AlertCreationPage) EnableAsset(assets func() []alerts.Asset) {
+	page.pairAssets = assets
+	page.singleSide = true
+}
+
+// Enables selecting which gas price the alert follows
+func (page *AlertCreationPage) EnableTiers() {
+	page.Tier.SetKeys(alerts.GasTiers...)
+}
+
+// Enables compound mode which combines conditions of collections, eth and gas
+// Types of the page are compound alert types, collections are the assets of the collection conditions
+func (page *AlertCreationPage) EnableClauses(collections func() []alerts.Asset) {
+	add := func(clause alerts.Clause) {
+		page.clauses = append(page.clauses, clause)
+	}
+	collectionPage := NewAlertCreationPage("New Collection Condition",
+		func(input AlertInput) {
+			params := collectionAlertParams("", input)
+			add(alerts.Clause{Asset: input.A, Collection: &params})
+		},
+		[]alerts.Condition{
+			alerts.CollectionAlertTypeFloorLessThan,
+			alerts.CollectionAlertTypeFloorGreaterThan,
+			alerts.CollectionAlertTypeFloorDroppedBy,
+			alerts.CollectionAlertTypeFloorRoseBy,
+			alerts.CollectionAlertTypeVolumeSpike,
+			alerts.CollectionAlertTypeSalesSpike,
+			alerts.CollectionAlertTypeListedGreaterThan,
+			alerts.CollectionAlertTypeListedRoseBy,
+			alerts.CollectionAlertTypeOwnersDroppedBy,
+		}...,
+	)
+	collectionPage.EnableAsset(collections)
+	ethereumPage := NewAlertCreationPage("New Ethereum Condition",
+		func(input AlertInput) {
+			params := ethereumAlertParams("", input)
+			add(alerts.Clause{Ethereum: &params})
+		},
+		[]alerts.Condition{
+			alerts.EthereumAlertTypeLessThan,
+			alerts.EthereumAlertTypeGreaterThan,
+			alerts.EthereumAlertTypeDroppedBy,
+			alerts.EthereumAlertTypeRoseBy,
+		}...,
+	)
+	gasPage := NewAlertCreationPage("New Gas Condition",
+		func(input AlertInput) {
+			params := gasAlertParams("", input)
+			add(alerts.Clause{Gas: &params})
+		},
+		[]alerts.Condition{
+			alerts.GasAlertTypeLessThan,
+			alerts.GasAlertTypeGreaterThan,
+			alerts.GasAlertTypeDroppedBy,
+			alerts.GasAlertTypeRoseBy,
+		}...,
+	)
+	gasPage.EnableTiers()
+	page.clausePages = []*AlertCreationPage{collectionPage, ethereumPage, gasPage}
+	page.AddClause = make([]widget.Clickable, len(page.clausePages))
+	for _, clausePage := range page.clausePages {
+		clausePage.clauseOnly = true
+	}
+}
+
+// Previewing how many times the alert would have fired, expr is empty when not in advanced mode
+func (page *AlertCreationPage) EnableBacktest(backtest func(expr string, input AlertInput) alertengine.Backtest) {
+	page.backtest = backtest
+}
+
 func (page *AlertCreationPage) Title() string {
 	return page.title
 }
 
 func (page *AlertCreationPage) Entering() {
 	log.Debug().Println("Entering", page.title)
+	if page.pairAssets != nil {
+		assets := page.pairAssets()
+		page.SideA.SetKeys(assets...)
+		page.SideB.SetKeys(assets...)
+	}
+	if len(page.Tier.Keys) > 0 && page.Tier.State.Value == "" {
+		page.Tier.State.Value = alerts.GasTierPropose.String()
+	}
 }
 
 func (page *AlertCreationPage) Leaving() {
 	// reset everything
 	page.Type.State.Value = ""
+	page.SideA.State.Value = ""
+	page.SideB.State.Value = ""
+	page.clauses = nil
+	page.Tier.State.Value = ""
 	page.Value.SetText("")
+	page.High.SetText("")
+	page.Interval.SetText("")
+	page.Cooldown.SetText("")
+	page.Debounce.SetText("")
+	page.Hysteresis.SetText("")
+	page.QuietHours.SetText("")
+	page.Critical.Value = false
 	page.Loop.Value = false
 	page.Error = nil
+	page.Advanced.Value = false
+	page.Expression.SetText("")
+	page.Expression.ClearError()
+	page.validated = ""
+	page.result.Store(nil)
+	page.replayed = false
 }
 
 func (page *AlertCreationPage) Layout(gtx layout.Context, theme *Theme, pages *PageStack) layout.Dimensions {
@@ -269,7 +533,15 @@ func (page *AlertCreationPage) Layout(gtx layout.Context, theme *Theme, pages *P
 			pages.Pop()
 		}
 	}
+	if page.Advanced.Value {
+		return page.layoutAdvanced(gtx, theme)
+	}
 	return theme.LayoutForm(gtx, &page.List, &page.Ok,
+		// Advanced mode
+		page.layoutAdvancedCheckBox(theme),
+		// Pair sides
+		page.layoutSide(theme, page.sideTitle(), &page.SideA),
+		page.layoutSide(theme, "B", &page.SideB),
 		// Type label
 		func(gtx layout.Context) layout.Dimensions {
 			return material.Body1(theme.Material(), "Type").Layout(gtx)
@@ -278,8 +550,25 @@ func (page *AlertCreationPage) Layout(gtx layout.Context, theme *Theme, pages *P
 		func(gtx layout.Context) layout.Dimensions {
 			return page.Type.Layout(gtx, theme.Material())
 		},
+		// Conditions of compound alerts
+		page.layoutClauses(theme, pages),
+		// Gas tier
+		func(gtx layout.Context) layout.Dimensions {
+			if len(page.Tier.Keys) == 0 {
+				return layout.Dimensions{}
+			}
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(material.Body1(theme.Material(), "Gas price").Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return page.Tier.Layout(gtx, theme.Material())
+				}),
+			)
+		},
 		// Value entry
 		func(gtx layout.Context) layout.Dimensions {
+			if page.clausePages != nil {
+				return layout.Dimensions{}
+			}
 			hint := "Select type first"
 			t, ok := page.Type.SelectedType()
 			if ok {
@@ -290,92 +579,578 @@ func (page *AlertCreationPage) Layout(gtx layout.Context, theme *Theme, pages *P
 			}
 			return page.Value.Layout(gtx, theme.Material(), hint)
 		},
-		// Loop
+		// Upper bound entry
 		func(gtx layout.Context) layout.Dimensions {
-			return material.CheckBox(theme.Material(), &page.Loop, "Loop").Layout(gtx)
+			t, ok := page.Type.SelectedType()
+			if !ok || upperLabel(t) == "" {
+				return layout.Dimensions{}
+			}
+			return page.High.Layout(gtx, theme.Material(), upperLabel(t))
 		},
-		// Error
+		// Interval entry
 		func(gtx layout.Context) layout.Dimensions {
-			if page.Error == nil {
+			t, ok := page.Type.SelectedType()
+			if !ok || !t.NeedsInterval() {
 				return layout.Dimensions{}
 			}
-			label := material.Caption(theme.Material(), page.Error.Error())
-			label.Color = theme.Error
-			label.Alignment = text.Middle
+			hint := "Within, like 30m or 4h"
+			if maxInterval(t) == baselineLength {
+				hint = "Average of, like 24h or 168h"
+			}
+			return page.Interval.Layout(gtx, theme.Material(), hint)
+		},
+		// Trigger settings
+		page.layoutCooldown(theme),
+		page.layoutDebounce(theme),
+		func(gtx layout.Context) layout.Dimensions {
+			// Compound alerts have no single value to cross back
+			if page.clauseOnly || page.clausePages != nil {
+				return layout.Dimensions{}
+			}
+			return page.Hysteresis.Layout(gtx, theme.Material(), "Re-arm after crossing back by (optional)")
+		},
+		page.layoutQuietHours(theme),
+		page.layoutCritical(theme),
+		// Loop
+		page.layoutLoop(theme),
+		// Preview
+		page.layoutBacktest(theme),
+		// Error
+		page.layoutError(theme),
+	)
+}
+
+func (page *AlertCreationPage) sideTitle() string {
+	if page.singleSide {
+		return "Collection"
+	}
+	return "A"
+}
+
+func (page *AlertCreationPage) layoutClauses(theme *Theme, pages *PageStack) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		if page.clausePages == nil {
+			return layout.Dimensions{}
+		}
+		for i := range page.AddClause {
+			if page.AddClause[i].Clicked() {
+				pages.Push(page.clausePages[i])
+			}
+		}
+		if len(page.RemoveClause) < len(page.clauses) {
+			page.RemoveClause = make([]widget.Clickable, len(page.clauses))
+		}
+		for i := range page.clauses {
+			if page.RemoveClause[i].Clicked() {
+				page.clauses = append(page.clauses[:i], page.clauses[i+1:]...)
+				break
+			}
+		}
+		children := []layout.FlexChild{
+			layout.Rigid(material.Body1(theme.Material(), "Conditions").Layout),
+		}
+		if len(page.clauses) == 0 {
+			children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				label := material.Caption(theme.Material(), "Add at least two conditions")
+				label.Color = theme.MediumImpFg
+				return label.Layout(gtx)
+			}))
+		}
+		for i, clause := range page.clauses {
+			i, clause := i, clause
+			children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Flexed(1, material.Body2(theme.Material(), clause.String()).Layout),
+					layout.Rigid(theme.IconButton(theme.DeleteIcon, &page.RemoveClause[i]).Layout),
+				)
+			}))
+		}
+		titles := []string{"Add collection condition", "Add ethereum condition", "Add gas condition"}
+		for i := range page.AddClause {
+			children = append(children, layout.Rigid(theme.Hyperlink(titles[i], &page.AddClause[i])))
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	}
+}
+
+func (page *AlertCreationPage) layoutLoop(theme *Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		if page.clauseOnly {
+			return layout.Dimensions{}
+		}
+		return material.CheckBox(theme.Material(), &page.Loop, "Loop").Layout(gtx)
+	}
+}
+
+func (page *AlertCreationPage) layoutAdvancedCheckBox(theme *Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		if page.exprFields == nil {
+			return layout.Dimensions{}
+		}
+		return material.CheckBox(theme.Material(), &page.Advanced, "Advanced").Layout(gtx)
+	}
+}
+
+func (page *AlertCreationPage) layoutSide(theme *Theme, title string, side *widgets.TypedEnum[alerts.Asset]) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		if page.pairAssets == nil {
+			return layout.Dimensions{}
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(material.Body1(theme.Material(), title).Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return side.Layout(gtx, theme.Material())
+			}),
+		)
+	}
+}
+
+func (page *AlertCreationPage) layoutCooldown(theme *Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		if page.clauseOnly {
+			return layout.Dimensions{}
+		}
+		hint := fmt.Sprintf("Cooldown, like 30s or 5m (default %s)", alerts.FormatInterval(alerts.DefaultCooldown))
+		return page.Cooldown.Layout(gtx, theme.Material(), hint)
+	}
+}
+
+func (page *AlertCreationPage) layoutDebounce(theme *Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		if page.clauseOnly {
+			return layout.Dimensions{}
+		}
+		return page.Debounce.Layout(gtx, theme.Material(), "Consecutive checks required (default 1)")
+	}
+}
+
+func (page *AlertCreationPage) layoutQuietHours(theme *Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		if page.clauseOnly {
+			return layout.Dimensions{}
+		}
+		return page.QuietHours.Layout(gtx, theme.Material(), "Quiet hours, like 23:00-07:00 (optional)")
+	}
+}
+
+func (page *AlertCreationPage) layoutCritical(theme *Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		if page.clauseOnly {
+			return layout.Dimensions{}
+		}
+		return material.CheckBox(theme.Material(), &page.Critical, "Critical, repeat until acknowledged").Layout(gtx)
+	}
+}
+
+func (page *AlertCreationPage) layoutError(theme *Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		if page.Error == nil {
+			return layout.Dimensions{}
+		}
+		label := material.Caption(theme.Material(), page.Error.Error())
+		label.Color = theme.Error
+		label.Alignment = text.Middle
+		return label.Layout(gtx)
+	}
+}
+
+// At most this many firing times are listed in the preview
+const backtestListed = 10
+
+func (page *AlertCreationPage) layoutBacktest(theme *Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		if page.backtest == nil {
+			return layout.Dimensions{}
+		}
+		if page.Preview.Clicked() {
+			expr, input, err := page.parseInput()
+			page.Error = err
+			page.replayed = err == nil
+			page.result.Store(nil)
+			if err == nil {
+				// Replaying a day of samples takes a while
+				go func() {
+					result := page.backtest(expr, input)
+					page.result.Store(&result)
+					RefreshWindowChan <- struct{}{}
+				}()
+			}
+		}
+		children := []layout.FlexChild{
+			layout.Rigid(theme.Hyperlink("Preview on recorded history", &page.Preview)),
+		}
+		if page.replayed {
+			summary := "Replaying recorded history..."
+			if result := page.result.Load(); result != nil {
+				summary = backtestSummary(*result)
+			}
+			children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				label := material.Caption(theme.Material(), summary)
+				label.Color = theme.MediumImpFg
+				return label.Layout(gtx)
+			}))
+		}
+		return layout.Flex{Axis: layout.Vertical, Alignment: layout.End}.Layout(gtx, children...)
+	}
+}
+
+func backtestSummary(result alertengine.Backtest) string {
+	if result.Samples == 0 {
+		return "Nothing recorded yet, values are recorded while the app is running"
+	}
+	period := alerts.FormatInterval(int(result.Period() / time.Second))
+	switch len(result.Fired) {
+	case 0:
+		return fmt.Sprintf("Would not have fired in the last %s", period)
+	case 1:
+		return fmt.Sprintf("Would have fired once in the last %s, at %s", period, result.Fired[0].Format("Jan 2 15:04"))
+	}
+	times := make([]string, 0, backtestListed+1)
+	for i, t := range result.Fired {
+		if i == backtestListed {
+			times = append(times, fmt.Sprintf("and %d more", len(result.Fired)-i))
+			break
+		}
+		times = append(times, t.Format("Jan 2 15:04"))
+	}
+	return fmt.Sprintf("Would have fired %d times in the last %s, at %s", len(result.Fired), period, strings.Join(times, ", "))
+}
+
+func (page *AlertCreationPage) layoutAdvanced(gtx layout.Context, theme *Theme) layout.Dimensions {
+	// Validate as user types
+	if txt := page.Expression.Text(); txt != page.validated {
+		page.validated = txt
+		page.Error = nil
+		_, err := alerts.ParseExpr(txt, page.exprFields...)
+		if err != nil && txt != "" {
+			page.Expression.SetError(err.Error())
+		} else {
+			page.Expression.ClearError()
+		}
+	}
+	return theme.LayoutForm(gtx, &page.List, &page.Ok,
+		// Advanced mode
+		page.layoutAdvancedCheckBox(theme),
+		// Expression entry
+		func(gtx layout.Context) layout.Dimensions {
+			return page.Expression.Layout(gtx, theme.Material(), "Expression, like "+page.exprExample())
+		},
+		// Available fields
+		func(gtx layout.Context) layout.Dimensions {
+			label := material.Caption(theme.Material(), "Fields: "+strings.Join(page.exprFields, ", "))
+			label.Color = theme.MediumImpFg
 			return label.Layout(gtx)
 		},
+		// Trigger settings
+		page.layoutCooldown(theme),
+		page.layoutDebounce(theme),
+		page.layoutQuietHours(theme),
+		page.layoutCritical(theme),
+		// Loop
+		page.layoutLoop(theme),
+		// Preview
+		page.layoutBacktest(theme),
+		// Error
+		page.layoutError(theme),
 	)
 }
 
-func (page *AlertCreationPage) AddAlert() error {
+func (page *AlertCreationPage) exprExample() string {
+	for _, field := range page.exprFields {
+		if field == alerts.FieldFloor {
+			return "floor < 1.2 && listed > 400 && gas < 25"
+		}
+	}
+	return "eth < 1500 && gas < 20"
+}
+
+// Returns empty when condition is not a range
+func upperLabel(t alerts.Condition) string {
+	if rc, ok := t.(alerts.RangeCondition); ok {
+		return rc.UpperLabel()
+	}
+	return ""
+}
+
+// Longest interval the history of this alert type can cover
+func maxInterval(t alerts.Condition) time.Duration {
+	switch t {
+	case alerts.CollectionAlertTypeVolumeSpike, alerts.CollectionAlertTypeSalesSpike:
+		return baselineLength
+	}
+	return historyLength
+}
+
+// Parses trigger settings, empty fields are left as zero
+func (page *AlertCreationPage) parseTrigger() (alerts.Trigger, error) {
+	var trigger alerts.Trigger
+	if txt := page.Cooldown.Text(); txt != "" {
+		d, err := time.ParseDuration(txt)
+		if err != nil || d < time.Second {
+			return trigger, errors.New("invalid cooldown, use a duration like 30s or 5m")
+		}
+		trigger.Cooldown = int(d / time.Second)
+	}
+	if txt := page.Debounce.Text(); txt != "" {
+		n, err := strconv.Atoi(txt)
+		if err != nil || n < 1 {
+			return trigger, errors.New("consecutive checks must be at least 1")
+		}
+		trigger.Debounce = n
+	}
+	if txt := page.Hysteresis.Text(); txt != "" && !page.Advanced.Value {
+		n, ok := number.NewFromString(txt)
+		if !ok {
+			return trigger, errors.New("invalid re-arm distance")
+		}
+		trigger.Hysteresis = n
+	}
+	ranges, err := alerts.ParseTimeRanges(page.QuietHours.Text())
+	if err != nil {
+		return trigger, err
+	}
+	if len(ranges) > 0 {
+		trigger.Schedule = &alerts.Schedule{Quiet: true, Ranges: ranges}
+	}
+	if page.Critical.Value {
+		trigger.Priority = alerts.PriorityCritical
+	}
+	return trigger, nil
+}
+
+// Validates the form, expr is empty when not in advanced mode
+func (page *AlertCreationPage) parseInput() (string, AlertInput, error) {
+	trigger, err := page.parseTrigger()
+	if err != nil {
+		return "", AlertInput{}, err
+	}
+	if page.Advanced.Value {
+		expr, err := alerts.ParseExpr(page.Expression.Text(), page.exprFields...)
+		if err != nil {
+			return "", AlertInput{}, err
+		}
+		return expr.String(), AlertInput{Loop: page.Loop.Value, Trigger: trigger}, nil
+	}
+	// Validate sides
+	var a, b alerts.Asset
+	if page.pairAssets != nil && page.singleSide {
+		var ok bool
+		a, ok = page.SideA.SelectedType()
+		if !ok {
+			return "", AlertInput{}, errors.New("select collection")
+		}
+	} else if page.pairAssets != nil {
+		var okA, okB bool
+		a, okA = page.SideA.SelectedType()
+		b, okB = page.SideB.SelectedType()
+		if !okA || !okB {
+			return "", AlertInput{}, errors.New("select both sides")
+		}
+		if a == b {
+			return "", AlertInput{}, errors.New("sides must be different")
+		}
+	}
 	// Validate type
 	t, ok := page.Type.SelectedType()
 	if !ok {
-		return errors.New("select type")
+		return "", AlertInput{}, errors.New("select type")
+	}
+	// Validate conditions, compound alerts have no value of their own
+	if page.clausePages != nil {
+		clauses := append([]alerts.Clause(nil), page.clauses...)
+		err := alerts.CompoundAlert{Clauses: clauses}.Validate()
+		if err != nil {
+			return "", AlertInput{}, err
+		}
+		return "", AlertInput{Type: t, Loop: page.Loop.Value, Clauses: clauses, Trigger: trigger}, nil
 	}
 	// Validate number
 	n, ok := number.NewFromString(page.Value.Text())
 	if !ok {
-		return errors.New("invalid number")
+		return "", AlertInput{}, errors.New("invalid number")
 	}
-	// Create alert
-	page.onCreate(t, n, page.Loop.Value)
-	return nil
+	input := AlertInput{Type: t, Value: n, Loop: page.Loop.Value, A: a, B: b, Trigger: trigger}
+	if len(page.Tier.Keys) > 0 {
+		input.Tier, ok = page.Tier.SelectedType()
+		if !ok {
+			return "", AlertInput{}, errors.New("select gas price")
+		}
+	}
+	// Validate upper bound
+	if upperLabel(t) != "" {
+		high, ok := number.NewFromString(page.High.Text())
+		if !ok {
+			return "", AlertInput{}, errors.New("invalid upper bound")
+		}
+		if !high.GreaterThan(n) {
+			return "", AlertInput{}, errors.New("upper bound must be greater than the lower")
+		}
+		input.High = high
+	}
+	// Validate interval
+	if t.NeedsInterval() {
+		d, err := time.ParseDuration(page.Interval.Text())
+		if err != nil {
+			return "", AlertInput{}, errors.New("invalid interval, use a duration like 30m or 4h")
+		}
+		if limit := maxInterval(t); d < time.Minute || d > limit {
+			return "", AlertInput{}, fmt.Errorf("interval must be between 1m and %s", alerts.FormatInterval(int(limit/time.Second)))
+		}
+		input.Interval = int(d / time.Second)
+	}
+	return "", input, nil
 }
 
-// can be initialized by new(AlertList)
-type AlertList struct {
-	mutex sync.Mutex
-	slice []alerts.Alert
+func (page *AlertCreationPage) AddAlert() error {
+	expr, input, err := page.parseInput()
+	if err != nil {
+		return err
+	}
+	if page.Advanced.Value {
+		page.onCreateExpr(expr, input)
+	} else {
+		page.onCreate(input)
+	}
+	return nil
 }
 
-func (alertList *AlertList) Len() int {
-	alertList.mutex.Lock()
-	defer alertList.mutex.Unlock()
-	return len(alertList.slice)
+// AlertEditPage edits the threshold and loop flag of an alert in place, also pauses and snoozes it
+type AlertEditPage[T alerts.Editable[T]] struct {
+	alert    *Alert[T]
+	List     widget.List
+	Value    component.TextField // Only shown when alert has an editable threshold
+	Loop     widget.Bool
+	Paused   widget.Bool
+	Critical widget.Bool
+	Snooze   [3]widget.Clickable // For snoozeDurations
+	Unsnooze widget.Clickable
+	Error    error
+	Ok       widget.Clickable
 }
 
-// Has reports whether this alert is in the list
-func (alertList *AlertList) Has(alert alerts.Alert) bool {
-	alertList.mutex.Lock()
-	defer alertList.mutex.Unlock()
-	for _, a := range alertList.slice {
-		if a.String() == alert.String() {
-			return true
-		}
+var snoozeDurations = [3]time.Duration{time.Hour, time.Hour * 8, time.Hour * 24}
+
+func NewAlertEditPage[T alerts.Editable[T]](alert *Alert[T]) *AlertEditPage[T] {
+	page := &AlertEditPage[T]{
+		alert: alert,
 	}
-	return false
+	page.List.Axis = layout.Vertical
+	page.Value.SingleLine = true
+	page.Value.InputHint = key.HintNumeric
+	page.Value.Filter = "0123456789."
+	return page
+}
+
+func (page *AlertEditPage[T]) Title() string {
+	return "Edit Alert"
 }
 
-func (alertList *AlertList) Add(alert alerts.Alert) bool {
-	if alertList.Has(alert) {
-		return false
+func (page *AlertEditPage[T]) Entering() {
+	handle := page.alert.Handle()
+	if base, ok := handle.Threshold(); ok {
+		page.Value.SetText(base.String())
 	}
-	// Append and return true
-	alertList.mutex.Lock()
-	defer alertList.mutex.Unlock()
-	alertList.slice = append(alertList.slice, alert)
-	return true
+	page.Loop.Value = handle.Looping()
+	page.Paused.Value = handle.Triggering().Paused
+	page.Critical.Value = handle.Triggering().Priority == alerts.PriorityCritical
+	page.Error = nil
 }
 
-func (alertList *AlertList) Remove(alert alerts.Alert) bool {
-	alertList.mutex.Lock()
-	defer alertList.mutex.Unlock()
-	for i, a := range alertList.slice {
-		if a.String() == alert.String() {
-			alertList.slice = append(alertList.slice[:i], alertList.slice[i+1:]...)
-			return true
+func (page *AlertEditPage[T]) Leaving() {}
+
+func (page *AlertEditPage[T]) Layout(gtx layout.Context, theme *Theme, pages *PageStack) layout.Dimensions {
+	if page.Ok.Clicked() {
+		err := page.Save()
+		if err != nil {
+			page.Error = err
+		} else {
+			pages.Pop()
 		}
 	}
-	return false
+	for i := range page.Snooze {
+		if page.Snooze[i].Clicked() {
+			page.alert.Snooze(snoozeDurations[i])
+			pages.Pop()
+		}
+	}
+	if page.Unsnooze.Clicked() {
+		page.alert.Snooze(0)
+	}
+	handle := page.alert.Handle()
+	return theme.LayoutForm(gtx, &page.List, &page.Ok,
+		// Description
+		func(gtx layout.Context) layout.Dimensions {
+			return material.Body1(theme.Material(), handle.Description()).Layout(gtx)
+		},
+		// Threshold
+		func(gtx layout.Context) layout.Dimensions {
+			if _, ok := handle.Threshold(); !ok {
+				return layout.Dimensions{}
+			}
+			return page.Value.Layout(gtx, theme.Material(), handle.Condition().Label())
+		},
+		// Loop
+		func(gtx layout.Context) layout.Dimensions {
+			return material.CheckBox(theme.Material(), &page.Loop, "Loop").Layout(gtx)
+		},
+		// Pause
+		func(gtx layout.Context) layout.Dimensions {
+			return material.CheckBox(theme.Material(), &page.Paused, "Paused").Layout(gtx)
+		},
+		// Priority
+		func(gtx layout.Context) layout.Dimensions {
+			return material.CheckBox(theme.Material(), &page.Critical, "Critical, repeat until acknowledged").Layout(gtx)
+		},
+		// Snooze
+		func(gtx layout.Context) layout.Dimensions {
+			children := []layout.FlexChild{
+				layout.Rigid(material.Body1(theme.Material(), "Snooze").Layout),
+			}
+			for i := range page.Snooze {
+				label := alerts.FormatInterval(int(snoozeDurations[i] / time.Second))
+				children = append(children,
+					layout.Rigid(theme.MediumHSpacer.Layout),
+					layout.Rigid(theme.Button(label, &page.Snooze[i], RegularButton).Layout),
+				)
+			}
+			if handle.Triggering().SnoozedAt(time.Now()) {
+				children = append(children,
+					layout.Rigid(theme.MediumHSpacer.Layout),
+					layout.Rigid(theme.Button("Unsnooze", &page.Unsnooze, RegularButton).Layout),
+				)
+			}
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx, children...)
+		},
+		// Error
+		func(gtx layout.Context) layout.Dimensions {
+			if page.Error == nil {
+				return layout.Dimensions{}
+			}
+			label := material.Caption(theme.Material(), page.Error.Error())
+			label.Color = theme.Error
+			label.Alignment = text.Middle
+			return label.Layout(gtx)
+		},
+	)
 }
 
-func (alertList *AlertList) ForEach(fn func(index int, alert alerts.Alert)) {
-	alertList.mutex.Lock()
-	for i, alert := range alertList.slice {
-		fn(i, alert)
+func (page *AlertEditPage[T]) Save() error {
+	handle := page.alert.Handle()
+	if _, ok := handle.Threshold(); ok {
+		base, ok := number.NewFromString(page.Value.Text())
+		if !ok {
+			return errors.New("invalid number")
+		}
+		handle = handle.WithBase(base)
 	}
-	alertList.mutex.Unlock()
+	trigger := handle.Triggering()
+	trigger.Paused = page.Paused.Value
+	trigger.Priority = alerts.PriorityNormal
+	if page.Critical.Value {
+		trigger.Priority = alerts.PriorityCritical
+	}
+	page.alert.Edit(handle.WithLoop(page.Loop.Value).WithTrigger(trigger))
+	return nil
 }
 
 type AlertListState struct {
@@ -385,7 +1160,7 @@ type AlertListState struct {
 	AlertCreationPage *AlertCreationPage
 }
 
-func (state *AlertListState) Layout(gtx layout.Context, theme *Theme, pages *PageStack, alertList *AlertList) layout.Dimensions {
+func (state *AlertListState) Layout(gtx layout.Context, theme *Theme, pages *PageStack, alertList *alertengine.List) layout.Dimensions {
 	defer bench.Begin()()
 
 	if state.AddNewAlert.Clicked() {
@@ -425,12 +1200,12 @@ func (state *AlertListState) Layout(gtx layout.Context, theme *Theme, pages *Pag
 	}
 
 	type hasLayout interface {
-		Layout(layout.Context, *Theme) layout.Dimensions
+		Layout(layout.Context, *Theme, *PageStack) layout.Dimensions
 	}
 
 	alertList.ForEach(func(index int, alert alerts.Alert) {
 		widgets[index+1] = layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-			return alert.(hasLayout).Layout(gtx, theme)
+			return alert.(hasLayout).Layout(gtx, theme, pages)
 		})
 	})
 
@@ -0,0 +1,189 @@
+package alertengine
+
+import (
+	"sync"
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/log"
+	"nftsiren/pkg/mutex"
+	"nftsiren/pkg/number"
+)
+
+// Event is a fired alert
+type Event struct {
+	AlertID   string
+	Condition string
+	Value     number.Number // Compared with the base, nil when there is nothing to compare
+	Title     string
+	Text      string
+	Schedule  *alerts.Schedule // Own schedule of the alert
+	Priority  alerts.Priority
+	Time      time.Time
+}
+
+// Settings decides where the values come from and what happens on firing, only Checker is required
+type Settings[T alerts.Editable[T]] struct {
+	// Reports whether the condition holds and the value compared with the base, value may be nil
+	Checker func(alert *Alert[T]) (bool, number.Number)
+	// Title and text of the notification, default is an empty title and the notification text
+	Notification func(alert *Alert[T]) (title, text string)
+	// Called on every firing
+	OnFire func(event Event)
+	// Called after one time alerts fired or when removing from the gui
+	OnRemove func()
+	// Clock of the trigger settings, default is time.Now
+	Now func() time.Time
+}
+
+// Alert encapsulates alerts.Alert and also implements it
+// Handle is immutable but it can be replaced with an edited one
+type Alert[T alerts.Editable[T]] struct {
+	handle mutex.Value[T]
+	// serializes checks, they may come from different workers
+	checkMutex sync.Mutex
+	// cooldown, hysteresis and debounce state
+	trigger mutex.Value[alerts.TriggerState]
+	// state of stateful alerts, saved with the alert
+	state    mutex.Value[alerts.State]
+	settings Settings[T]
+}
+
+// New gives the handle an identity if it doesn't have one
+func New[T alerts.Editable[T]](handle T, settings Settings[T]) *Alert[T] {
+	if handle.Identity().ID == "" {
+		handle = handle.WithMeta(alerts.NewMeta())
+	}
+	if settings.Now == nil {
+		settings.Now = time.Now
+	}
+	alert := &Alert[T]{settings: settings}
+	alert.handle.Store(handle)
+	return alert
+}
+
+func (alert *Alert[T]) String() string {
+	return alert.Handle().String()
+}
+
+func (alert *Alert[T]) Description() string {
+	return alert.Handle().Description()
+}
+
+func (alert *Alert[T]) NeedsInterval() bool {
+	return alert.Handle().NeedsInterval()
+}
+
+func (alert *Alert[T]) Interval() int {
+	return alert.Handle().Interval()
+}
+
+func (alert *Alert[T]) Looping() bool {
+	return alert.Handle().Looping()
+}
+
+func (alert *Alert[T]) Identity() alerts.Meta {
+	return alert.Handle().Identity()
+}
+
+func (alert *Alert[T]) ID() string {
+	return alert.Identity().ID
+}
+
+func (alert *Alert[T]) Triggering() alerts.Trigger {
+	return alert.Handle().Triggering()
+}
+
+func (alert *Alert[T]) Distance(num number.Number) number.Number {
+	return alert.Handle().Distance(num)
+}
+
+func (alert *Alert[T]) NotificationText() string {
+	return alert.Handle().NotificationText()
+}
+
+func (alert *Alert[T]) Handle() T {
+	return alert.handle.Load()
+}
+
+// Edit replaces the alert with the edited one, identity is kept
+// Trigger state is reset when the condition changes
+func (alert *Alert[T]) Edit(handle T) {
+	changed := handle.String() != alert.String()
+	alert.handle.Store(handle.WithMeta(alert.Identity().Touched()))
+	if changed {
+		alert.trigger.Store(alerts.TriggerState{})
+	}
+}
+
+// Snooze silences the alert for d, zero d cancels snoozing
+func (alert *Alert[T]) Snooze(d time.Duration) {
+	trigger := alert.Triggering()
+	trigger.Snooze = 0
+	if d > 0 {
+		trigger.Snooze = alert.settings.Now().Add(d).Unix()
+	}
+	alert.Edit(alert.Handle().WithTrigger(trigger))
+}
+
+func (alert *Alert[T]) State() alerts.State {
+	return alert.state.Load()
+}
+
+func (alert *Alert[T]) SetState(state alerts.State) {
+	alert.state.Store(state)
+}
+
+// Remove calls OnRemove, owner of the alert decides how to remove it
+func (alert *Alert[T]) Remove() {
+	if alert.settings.OnRemove != nil {
+		alert.settings.OnRemove()
+	}
+}
+
+// Check fires the alert if required and returns ok on firing
+// number argument is here because of implementing alerts.Alert, it is noop
+func (alert *Alert[T]) Check(number.Number) bool {
+	alert.checkMutex.Lock()
+	defer alert.checkMutex.Unlock()
+	if alert.Triggering().Paused {
+		return false
+	}
+	passed, value := alert.settings.Checker(alert)
+	// Cooldown, hysteresis and debounce
+	handle := alert.Handle()
+	now := alert.settings.Now()
+	state, ok := handle.Triggering().Step(alert.trigger.Load(), now, passed, handle.Distance(value))
+	alert.trigger.Store(state)
+	if !ok {
+		if passed {
+			log.Info().Printf("Passed check for %s because of trigger settings", handle)
+		}
+		return false
+	}
+	if alert.settings.OnFire != nil {
+		title, text := alert.notification()
+		alert.settings.OnFire(Event{
+			AlertID:   handle.Identity().ID,
+			Condition: handle.Condition().String(),
+			Value:     value,
+			Title:     title,
+			Text:      text,
+			Schedule:  handle.Triggering().Schedule,
+			Priority:  handle.Triggering().Priority,
+			Time:      now,
+		})
+	}
+	// Remove this alert if it's not looping
+	if !handle.Looping() {
+		alert.Remove()
+	}
+	return true
+}
+
+func (alert *Alert[T]) notification() (string, string) {
+	if alert.settings.Notification != nil {
+		return alert.settings.Notification(alert)
+	}
+	return "", alert.NotificationText()
+}
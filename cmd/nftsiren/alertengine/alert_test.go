@@ -0,0 +1,373 @@
+package alertengine
+
+import (
+	"testing"
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/apis/etherscan"
+	"nftsiren/pkg/nft"
+	"nftsiren/pkg/number"
+	"nftsiren/pkg/window"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// feed keeps synthetic samples in windows like the collections and the gas tracker do
+type feed struct {
+	now      time.Time
+	history  *window.Window[nft.CollectionStats]
+	baseline *window.Window[nft.CollectionStats]
+	eth      *window.Window[number.Number]
+	gas      *window.Window[etherscan.GasPrice]
+}
+
+func newFeed() *feed {
+	return &feed{
+		now:      time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		history:  window.New[nft.CollectionStats](24*time.Hour, 0),
+		baseline: window.New[nft.CollectionStats](7*24*time.Hour, 0),
+		eth:      window.New[number.Number](24*time.Hour, 0),
+		gas:      window.New[etherscan.GasPrice](24*time.Hour, 0),
+	}
+}
+
+func (f *feed) Floor() (number.Number, bool) {
+	stats := f.Stats()
+	if stats == nil || stats.Floor.IsNil() {
+		return number.Number{}, false
+	}
+	return stats.Floor, true
+}
+
+func (f *feed) Stats() *nft.CollectionStats {
+	latest, ok := f.history.Latest()
+	if !ok {
+		return nil
+	}
+	return &latest.Value
+}
+
+func (f *feed) StatChange(d time.Duration, stat func(nft.CollectionStats) number.Number) (drop, rise number.Number, ok bool) {
+	return window.PercentChange(f.history, f.now.Add(-d), stat)
+}
+
+func (f *feed) VolumeSpike(d time.Duration) (number.Number, bool) {
+	return BaselineRatio(f.baseline.Since(f.now.Add(-d)), d, DayVolumeStat)
+}
+
+func (f *feed) SalesSpike(d time.Duration) (number.Number, bool) {
+	return BaselineRatio(f.baseline.Since(f.now.Add(-d)), d, DaySalesStat)
+}
+
+func (f *feed) Vars() alerts.Vars {
+	return alerts.NewVars(f.Stats(), number.Number{}, number.Number{})
+}
+
+// Eth or gas prices of the feed
+type feedPrice struct {
+	feed *feed
+	gas  bool
+	tier alerts.GasTier
+}
+
+func (price feedPrice) Latest() (number.Number, bool) {
+	if price.gas {
+		latest, ok := price.feed.gas.Latest()
+		value := GasTierValue(latest.Value, price.tier)
+		return value, ok && !value.IsNil()
+	}
+	latest, ok := price.feed.eth.Latest()
+	return latest.Value, ok && !latest.Value.IsNil()
+}
+
+func (price feedPrice) Change(d time.Duration) (drop, rise number.Number, ok bool) {
+	since := price.feed.now.Add(-d)
+	if price.gas {
+		return window.PercentChange(price.feed.gas, since, func(gas etherscan.GasPrice) number.Number {
+			return GasTierValue(gas, price.tier)
+		})
+	}
+	return window.PercentChange(price.feed.eth, since, Identity)
+}
+
+func (price feedPrice) Vars() alerts.Vars {
+	return alerts.NewVars(nil, number.Number{}, number.Number{})
+}
+
+func checkEth(f *feed, alert *Alert[alerts.EthereumAlert]) (bool, number.Number) {
+	return CheckEth(alert.Handle(), feedPrice{feed: f})
+}
+
+func checkGas(f *feed, alert *Alert[alerts.GasAlert]) (bool, number.Number) {
+	return CheckGas(alert.Handle(), feedPrice{feed: f, gas: true, tier: alert.Handle().Tier})
+}
+
+func checkCollection(f *feed, alert *Alert[alerts.CollectionAlert]) (bool, number.Number) {
+	return CheckCollection(alert, f)
+}
+
+// stream checks an alert with the real checkers after every sample of its feed
+type stream[T alerts.Editable[T]] struct {
+	*feed
+	alert   *Alert[T]
+	step    time.Duration // Time between samples
+	events  []Event
+	removed int
+}
+
+func newStream[T alerts.Editable[T]](handle T, checker func(f *feed, alert *Alert[T]) (bool, number.Number)) *stream[T] {
+	s := &stream[T]{feed: newFeed(), step: time.Second}
+	s.alert = New(handle, Settings[T]{
+		Checker: func(alert *Alert[T]) (bool, number.Number) {
+			return checker(s.feed, alert)
+		},
+		Notification: func(alert *Alert[T]) (string, string) {
+			return "test", alert.NotificationText()
+		},
+		OnFire: func(event Event) {
+			s.events = append(s.events, event)
+		},
+		OnRemove: func() {
+			s.removed++
+		},
+		Now: func() time.Time {
+			return s.now
+		},
+	})
+	return s
+}
+
+// Adds n samples and returns the indexes of the firing ones
+func (s *stream[T]) run(n int, add func(i int)) []int {
+	var fired []int
+	for i := 0; i < n; i++ {
+		add(i)
+		if s.alert.Check(number.Number{}) {
+			fired = append(fired, i)
+		}
+		s.now = s.now.Add(s.step)
+	}
+	return fired
+}
+
+// -1 is a missing value
+func num(v int64) number.Number {
+	if v < 0 {
+		return number.Number{}
+	}
+	return number.NewFromInt(v)
+}
+
+// Feeds propose gas prices
+func (s *stream[T]) feedGas(values ...int64) []int {
+	return s.run(len(values), func(i int) {
+		s.gas.Add(s.now, etherscan.GasPrice{ProposeGasPrice: num(values[i])})
+	})
+}
+
+func (s *stream[T]) feedGasPrices(prices ...etherscan.GasPrice) []int {
+	return s.run(len(prices), func(i int) {
+		s.gas.Add(s.now, prices[i])
+	})
+}
+
+func (s *stream[T]) feedEth(values ...int64) []int {
+	return s.run(len(values), func(i int) {
+		s.eth.Add(s.now, num(values[i]))
+	})
+}
+
+func (s *stream[T]) feedStats(stats ...nft.CollectionStats) []int {
+	return s.run(len(stats), func(i int) {
+		stats[i].Time = s.now
+		s.history.Add(s.now, stats[i])
+		s.baseline.Add(s.now, stats[i])
+	})
+}
+
+func floors(values ...float64) []nft.CollectionStats {
+	stats := make([]nft.CollectionStats, len(values))
+	for i, v := range values {
+		stats[i].Floor = number.NewFromFloat(v)
+	}
+	return stats
+}
+
+func TestEngineOneTime(t *testing.T) {
+	s := newStream(alerts.GasAlert{Type: alerts.GasAlertTypeLessThan, Base: number.NewFromInt(20)}, checkGas)
+	require.NotEmpty(t, s.alert.ID())
+	assert.Equal(t, []int{3}, s.feedGas(30, 25, -1, 15))
+	require.Len(t, s.events, 1)
+	event := s.events[0]
+	assert.Equal(t, s.alert.ID(), event.AlertID)
+	assert.Equal(t, "15", event.Value.String())
+	assert.Equal(t, "test", event.Title)
+	assert.Equal(t, s.alert.NotificationText(), event.Text)
+	assert.Equal(t, alerts.GasAlertTypeLessThan.String(), event.Condition)
+	assert.Equal(t, 1, s.removed)
+}
+
+func TestEngineLoopingCooldown(t *testing.T) {
+	handle := alerts.GasAlert{Type: alerts.GasAlertTypeGreaterThan, Base: number.NewFromInt(50), Loop: true}
+	handle.Cooldown = 5
+	s := newStream(handle, checkGas)
+	assert.Equal(t, []int{0, 5, 10}, s.feedGas(60, 60, 60, 60, 60, 60, 60, 60, 60, 60, 60))
+	assert.Zero(t, s.removed)
+	// Event times come from the clock of the engine
+	assert.Equal(t, 5*time.Second, s.events[1].Time.Sub(s.events[0].Time))
+}
+
+func TestEnginePauseSnooze(t *testing.T) {
+	handle := alerts.EthereumAlert{Type: alerts.EthereumAlertTypeLessThan, Base: number.NewFromInt(1500), Loop: true}
+	handle.Cooldown = 1
+	s := newStream(handle, checkEth)
+	id := s.alert.ID()
+
+	paused := s.alert.Triggering()
+	paused.Paused = true
+	s.alert.Edit(s.alert.Handle().WithTrigger(paused))
+	assert.Empty(t, s.feedEth(1000, 1000))
+	assert.Equal(t, id, s.alert.ID())
+
+	s.alert.Edit(s.alert.Handle().WithTrigger(alerts.Trigger{Cooldown: 1}))
+	s.alert.Snooze(3 * time.Second)
+	assert.Equal(t, []int{3, 4}, s.feedEth(1000, 1000, 1000, 1000, 1000))
+	s.alert.Snooze(time.Hour)
+	s.alert.Snooze(0)
+	assert.Equal(t, []int{0}, s.feedEth(1000))
+}
+
+func TestEngineEditResetsTrigger(t *testing.T) {
+	handle := alerts.GasAlert{Type: alerts.GasAlertTypeLessThan, Base: number.NewFromInt(20), Loop: true}
+	s := newStream(handle, checkGas)
+	assert.Equal(t, []int{0}, s.feedGas(10, 10, 10))
+	// Default cooldown is still running for the same condition
+	s.alert.Edit(s.alert.Handle().WithLoop(true))
+	assert.Empty(t, s.feedGas(10))
+	// New condition starts over
+	s.alert.Edit(s.alert.Handle().WithBase(number.NewFromInt(15)))
+	assert.Equal(t, []int{0}, s.feedGas(10))
+	assert.NotZero(t, s.alert.Identity().Updated)
+}
+
+func TestEngineStateful(t *testing.T) {
+	handle := alerts.CollectionAlert{Type: alerts.CollectionAlertTypeTrailingStop, Base: number.NewFromInt(10), Loop: true}
+	handle.Cooldown = 1
+	s := newStream(handle, checkCollection)
+	// Peak 120, 10% drop is 108, then it trails from 100
+	assert.Equal(t, []int{3, 6}, s.feedStats(floors(100, 120, 110, 108, 105, 100, 90)...))
+	assert.Equal(t, "90", s.alert.State().Peak.String())
+}
+
+func TestEngineFloorDroppedBy(t *testing.T) {
+	handle := alerts.CollectionAlert{Type: alerts.CollectionAlertTypeFloorDroppedBy, Base: number.NewFromInt(10), Intv: 3600, Loop: true}
+	handle.Cooldown = 1
+	s := newStream(handle, checkCollection)
+	s.step = 20 * time.Minute
+	// Floor falls 11% from 10 to 8.9 but only 7% within the hour, the last hour drops 12% from 9.1
+	assert.Equal(t, []int{6}, s.feedStats(floors(10, 9.6, 9.3, 9.1, 8.9, 8.5, 8)...))
+	require.Len(t, s.events, 1)
+	assert.True(t, s.events[0].Value.GreaterThan(number.NewFromInt(12)))
+}
+
+func TestEngineListedOwners(t *testing.T) {
+	stats := func(stat func(stats *nft.CollectionStats) *number.Number, values ...int64) []nft.CollectionStats {
+		samples := make([]nft.CollectionStats, len(values))
+		for i, v := range values {
+			*stat(&samples[i]) = num(v)
+		}
+		return samples
+	}
+	owners := func(stats *nft.CollectionStats) *number.Number { return &stats.NumOwners }
+	listed := func(stats *nft.CollectionStats) *number.Number { return &stats.Listed }
+
+	handle := alerts.CollectionAlert{Type: alerts.CollectionAlertTypeOwnersDroppedBy, Base: number.NewFromInt(5), Intv: 3600, Loop: true}
+	handle.Cooldown = 1
+	s := newStream(handle, checkCollection)
+	s.step = 10 * time.Minute
+	// Rises don't count, 1100 to 1045 is a 5% drop
+	assert.Equal(t, []int{3}, s.feedStats(stats(owners, 1000, 1060, 1100, 1045)...))
+	assert.Equal(t, "5", s.events[0].Value.String())
+
+	handle = alerts.CollectionAlert{Type: alerts.CollectionAlertTypeListedRoseBy, Base: number.NewFromInt(50), Intv: 3600, Loop: true}
+	handle.Cooldown = 1
+	s = newStream(handle, checkCollection)
+	s.step = 20 * time.Minute
+	// Listed rises 50% from 40 but never within the hour until it jumps from 52
+	assert.Equal(t, []int{6}, s.feedStats(stats(listed, 40, 44, 48, 52, 56, 60, 80)...))
+
+	// Missing listed counts never fire
+	handle = alerts.CollectionAlert{Type: alerts.CollectionAlertTypeListedGreaterThan, Base: number.NewFromInt(10), Loop: true}
+	handle.Cooldown = 1
+	s = newStream(handle, checkCollection)
+	assert.Equal(t, []int{2}, s.feedStats(stats(listed, -1, -1, 20)...))
+	handle.Type = alerts.CollectionAlertTypeListedRoseBy
+	s = newStream(handle, checkCollection)
+	assert.Empty(t, s.feedStats(stats(listed, -1, -1, -1)...))
+}
+
+func TestEngineVolumeSpike(t *testing.T) {
+	handle := alerts.CollectionAlert{Type: alerts.CollectionAlertTypeVolumeSpike, Base: number.NewFromInt(3), Intv: 7 * 24 * 3600, Loop: true}
+	handle.Cooldown = 1
+	s := newStream(handle, checkCollection)
+	s.step = 6 * time.Hour
+	volumes := func(values ...int64) []nft.CollectionStats {
+		stats := make([]nft.CollectionStats, len(values))
+		for i, v := range values {
+			stats[i].DayVolume = number.NewFromInt(v)
+		}
+		return stats
+	}
+	// Two days don't cover half of the week yet
+	assert.Empty(t, s.feedStats(volumes(100, 100, 100, 100, 100, 100, 100, 100, 400)...))
+	quiet := make([]int64, 20)
+	for i := range quiet {
+		quiet[i] = 100
+	}
+	assert.Empty(t, s.feedStats(volumes(quiet...)...))
+	// The week averages 110 with the early spike in it
+	assert.Equal(t, []int{0}, s.feedStats(volumes(400)...))
+	require.Len(t, s.events, 1)
+	assert.True(t, s.events[0].Value.GreaterThan(number.NewFromInt(3)))
+}
+
+func TestEngineGasTier(t *testing.T) {
+	handle := alerts.GasAlert{Type: alerts.GasAlertTypeLessThan, Base: number.NewFromInt(20), Tier: alerts.GasTierBaseFee, Loop: true}
+	handle.Cooldown = 1
+	s := newStream(handle, checkGas)
+	// Propose price doesn't stand in for a missing base fee
+	assert.Equal(t, []int{1}, s.feedGasPrices(
+		etherscan.GasPrice{ProposeGasPrice: number.NewFromInt(10)},
+		etherscan.GasPrice{ProposeGasPrice: number.NewFromInt(30), SuggestBaseFee: number.NewFromInt(15)},
+		etherscan.GasPrice{ProposeGasPrice: number.NewFromInt(10), SuggestBaseFee: number.NewFromInt(25)},
+	))
+	require.Len(t, s.events, 1)
+	assert.Equal(t, "15", s.events[0].Value.String())
+
+	// Priority fee needs both the propose price and the base fee
+	handle = alerts.GasAlert{Type: alerts.GasAlertTypeLessThan, Base: number.NewFromInt(5), Tier: alerts.GasTierPriorityFee}
+	s = newStream(handle, checkGas)
+	assert.Equal(t, []int{1}, s.feedGasPrices(
+		etherscan.GasPrice{SuggestBaseFee: number.NewFromInt(10)},
+		etherscan.GasPrice{ProposeGasPrice: number.NewFromInt(12), SuggestBaseFee: number.NewFromInt(10)},
+	))
+}
+
+func TestList(t *testing.T) {
+	list := new(List)
+	a := newStream(alerts.GasAlert{Type: alerts.GasAlertTypeLessThan, Base: number.NewFromInt(20)}, checkGas)
+	b := newStream(alerts.GasAlert{Type: alerts.GasAlertTypeLessThan, Base: number.NewFromInt(20)}, checkGas)
+	assert.True(t, list.Add(a.alert))
+	// Identical alerts with different ids coexist
+	assert.True(t, list.Add(b.alert))
+	assert.False(t, list.Add(a.alert))
+	a.gas.Add(a.now, etherscan.GasPrice{ProposeGasPrice: number.NewFromInt(10)})
+	list.CheckAll()
+	assert.Len(t, a.events, 1)
+	assert.Empty(t, b.events)
+	assert.True(t, list.Remove(a.alert))
+	assert.False(t, list.Has(a.alert))
+	assert.Equal(t, 1, list.Len())
+}
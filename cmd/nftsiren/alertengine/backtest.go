@@ -0,0 +1,196 @@
+package alertengine
+
+import (
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/apis/etherscan"
+	"nftsiren/pkg/nft"
+	"nftsiren/pkg/number"
+	"nftsiren/pkg/window"
+)
+
+// Backtest is the result of replaying an alert over recorded values
+type Backtest struct {
+	Fired   []time.Time
+	From    time.Time // Time of the first replayed sample
+	To      time.Time // Time of the last replayed sample
+	Samples int
+}
+
+func (backtest Backtest) Period() time.Duration {
+	return backtest.To.Sub(backtest.From)
+}
+
+// Replay checks the alert at every sample time as if it was running then
+// check is called with the index of the current sample, later samples must not be looked at
+// Trigger settings apply but pause and snooze are ignored, one time alerts stop at the first firing
+func Replay[T alerts.Editable[T]](handle T, times []time.Time, check func(alert *Alert[T], i int) (bool, number.Number)) Backtest {
+	backtest := Backtest{Samples: len(times)}
+	if len(times) == 0 {
+		return backtest
+	}
+	backtest.From = times[0]
+	backtest.To = times[len(times)-1]
+	trigger := handle.Triggering()
+	trigger.Paused = false
+	trigger.Snooze = 0
+	i := 0
+	removed := false
+	alert := New(handle.WithTrigger(trigger), Settings[T]{
+		Checker: func(alert *Alert[T]) (bool, number.Number) {
+			return check(alert, i)
+		},
+		OnFire: func(event Event) {
+			backtest.Fired = append(backtest.Fired, event.Time)
+		},
+		OnRemove: func() {
+			removed = true
+		},
+		Now: func() time.Time {
+			return times[i]
+		},
+	})
+	for ; i < len(times) && !removed; i++ {
+		alert.Check(number.Number{})
+	}
+	return backtest
+}
+
+// Recorded samples which backtests replay
+type Recorded struct {
+	History  []window.Sample[nft.CollectionStats] // Only used by collection backtests
+	Baseline []window.Sample[nft.CollectionStats] // Longer but coarser history for spike alerts
+	Eth      []window.Sample[number.Number]
+	Gas      []window.Sample[etherscan.GasPrice]
+}
+
+// BacktestCollection replays the alert over the recorded stats of a collection
+// Spike alerts use the baseline
+func BacktestCollection(handle alerts.CollectionAlert, recorded Recorded) Backtest {
+	samples := recorded.History
+	switch handle.Type {
+	case alerts.CollectionAlertTypeVolumeSpike, alerts.CollectionAlertTypeSalesSpike:
+		samples = recorded.Baseline
+	}
+	r := &collectionReplay{newReplay(samples, recorded)}
+	return Replay(handle, r.times(), func(alert *Alert[alerts.CollectionAlert], i int) (bool, number.Number) {
+		r.i = i
+		return CheckCollection(alert, r)
+	})
+}
+
+// BacktestEth replays the alert over the recorded eth prices
+func BacktestEth(handle alerts.EthereumAlert, recorded Recorded) Backtest {
+	r := &priceReplay{newReplay(recorded.Eth, recorded)}
+	return Replay(handle, r.times(), func(alert *Alert[alerts.EthereumAlert], i int) (bool, number.Number) {
+		r.i = i
+		return CheckEth(alert.Handle(), r)
+	})
+}
+
+// BacktestGas replays the alert over the recorded prices of its tier
+func BacktestGas(handle alerts.GasAlert, recorded Recorded) Backtest {
+	r := &priceReplay{newReplay(gasTierSamples(recorded.Gas, handle.Tier), recorded)}
+	return Replay(handle, r.times(), func(alert *Alert[alerts.GasAlert], i int) (bool, number.Number) {
+		r.i = i
+		return CheckGas(alert.Handle(), r)
+	})
+}
+
+// Recorded prices of the tier, samples without it are left out
+func gasTierSamples(samples []window.Sample[etherscan.GasPrice], tier alerts.GasTier) []window.Sample[number.Number] {
+	values := make([]window.Sample[number.Number], 0, len(samples))
+	for _, sample := range samples {
+		value := GasTierValue(sample.Value, tier)
+		if !value.IsNil() {
+			values = append(values, window.Sample[number.Number]{Time: sample.Time, Value: value})
+		}
+	}
+	return values
+}
+
+// Replays recorded values, i is the index of the current sample
+// Eth and gas histories are only used for expression variables
+type replay[T any] struct {
+	samples  []window.Sample[T]
+	i        int
+	eth, gas []window.Sample[number.Number]
+}
+
+func newReplay[T any](samples []window.Sample[T], recorded Recorded) replay[T] {
+	return replay[T]{
+		samples: samples,
+		eth:     recorded.Eth,
+		gas:     gasTierSamples(recorded.Gas, alerts.GasTierPropose),
+	}
+}
+
+func (r *replay[T]) now() time.Time {
+	return r.samples[r.i].Time
+}
+
+// Samples within the last d of the current one
+func (r *replay[T]) within(d time.Duration) []window.Sample[T] {
+	return window.After(r.samples[:r.i+1], r.now().Add(-d))
+}
+
+func (r *replay[T]) vars(stats *nft.CollectionStats) alerts.Vars {
+	eth, _ := window.ValueAt(r.eth, r.now())
+	gas, _ := window.ValueAt(r.gas, r.now())
+	return alerts.NewVars(stats, eth, gas)
+}
+
+func (r *replay[T]) times() []time.Time {
+	times := make([]time.Time, len(r.samples))
+	for i, sample := range r.samples {
+		times[i] = sample.Time
+	}
+	return times
+}
+
+type collectionReplay struct {
+	replay[nft.CollectionStats]
+}
+
+func (r *collectionReplay) Stats() *nft.CollectionStats {
+	return &r.samples[r.i].Value
+}
+
+func (r *collectionReplay) Floor() (number.Number, bool) {
+	floor := r.Stats().Floor
+	return floor, !floor.IsNil()
+}
+
+func (r *collectionReplay) StatChange(d time.Duration, stat func(nft.CollectionStats) number.Number) (drop, rise number.Number, ok bool) {
+	return window.PercentChangeOf(r.within(d), stat)
+}
+
+func (r *collectionReplay) VolumeSpike(d time.Duration) (number.Number, bool) {
+	return BaselineRatio(r.within(d), d, DayVolumeStat)
+}
+
+func (r *collectionReplay) SalesSpike(d time.Duration) (number.Number, bool) {
+	return BaselineRatio(r.within(d), d, DaySalesStat)
+}
+
+func (r *collectionReplay) Vars() alerts.Vars {
+	return r.vars(r.Stats())
+}
+
+type priceReplay struct {
+	replay[number.Number]
+}
+
+func (r *priceReplay) Latest() (number.Number, bool) {
+	value := r.samples[r.i].Value
+	return value, !value.IsNil()
+}
+
+func (r *priceReplay) Change(d time.Duration) (drop, rise number.Number, ok bool) {
+	return window.PercentChangeOf(r.within(d), Identity)
+}
+
+func (r *priceReplay) Vars() alerts.Vars {
+	return r.vars(nil)
+}
@@ -0,0 +1,98 @@
+package alertengine
+
+import (
+	"testing"
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/apis/etherscan"
+	"nftsiren/pkg/nft"
+	"nftsiren/pkg/number"
+	"nftsiren/pkg/window"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	begin := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	values := []int64{30, 18, 17, 25, 15, 15, 15, 40, 10}
+	times := make([]time.Time, len(values))
+	for i := range times {
+		times[i] = begin.Add(time.Duration(i) * time.Minute)
+	}
+	check := func(alert *Alert[alerts.GasAlert], i int) (bool, number.Number) {
+		value := number.NewFromInt(values[i])
+		return alert.Handle().Check(value), value
+	}
+	handle := alerts.GasAlert{Type: alerts.GasAlertTypeLessThan, Base: number.NewFromInt(20), Loop: true}
+	handle.Cooldown = 120
+	handle.Paused = true
+	backtest := Replay(handle, times, check)
+	assert.Equal(t, len(values), backtest.Samples)
+	assert.Equal(t, 8*time.Minute, backtest.Period())
+	// Pause is ignored, cooldown is not
+	assert.Equal(t, []time.Time{times[1], times[4], times[6], times[8]}, backtest.Fired)
+
+	// One time alerts fire once
+	handle.Loop = false
+	assert.Equal(t, []time.Time{times[1]}, Replay(handle, times, check).Fired)
+
+	assert.Empty(t, Replay(handle, nil, check).Fired)
+}
+
+// Records the values every step from the start of the feed
+func record[T any](step time.Duration, values ...T) []window.Sample[T] {
+	begin := newFeed().now
+	samples := make([]window.Sample[T], len(values))
+	for i, v := range values {
+		samples[i] = window.Sample[T]{Time: begin.Add(time.Duration(i) * step), Value: v}
+	}
+	return samples
+}
+
+func TestBacktestCollection(t *testing.T) {
+	history := record(10*time.Minute, floors(10, 10, 9, 9, 8.5, 8, 8, 8, 8, 8, 8, 8, 8)...)
+	handle := alerts.CollectionAlert{Type: alerts.CollectionAlertTypeFloorDroppedBy, Base: number.NewFromInt(10), Intv: 3600, Loop: true}
+	handle.Cooldown = 1800
+	backtest := BacktestCollection(handle, Recorded{History: history})
+	assert.Equal(t, len(history), backtest.Samples)
+	assert.Equal(t, 2*time.Hour, backtest.Period())
+	// Cooldown holds back the firings in between, the drop from 9 leaves the hour at the last one
+	assert.Equal(t, []time.Time{history[2].Time, history[5].Time, history[8].Time}, backtest.Fired)
+
+	// Spike alerts replay the baseline
+	handle = alerts.CollectionAlert{Type: alerts.CollectionAlertTypeVolumeSpike, Base: number.NewFromInt(3), Intv: 24 * 3600}
+	baseline := record(2*time.Hour, make([]nft.CollectionStats, 13)...)
+	for i := range baseline {
+		baseline[i].Value.DayVolume = number.NewFromInt(100)
+	}
+	baseline[12].Value.DayVolume = number.NewFromInt(500)
+	backtest = BacktestCollection(handle, Recorded{History: history, Baseline: baseline})
+	assert.Equal(t, len(baseline), backtest.Samples)
+	assert.Equal(t, []time.Time{baseline[12].Time}, backtest.Fired)
+}
+
+func TestBacktestGas(t *testing.T) {
+	gas := record(time.Minute,
+		etherscan.GasPrice{ProposeGasPrice: number.NewFromInt(10)},
+		etherscan.GasPrice{SuggestBaseFee: number.NewFromInt(25)},
+		etherscan.GasPrice{SuggestBaseFee: number.NewFromInt(15)},
+		etherscan.GasPrice{SuggestBaseFee: number.NewFromInt(15)},
+		etherscan.GasPrice{SuggestBaseFee: number.NewFromInt(30)},
+		etherscan.GasPrice{SuggestBaseFee: number.NewFromInt(18)},
+	)
+	handle := alerts.GasAlert{Type: alerts.GasAlertTypeLessThan, Base: number.NewFromInt(20), Tier: alerts.GasTierBaseFee, Loop: true}
+	handle.Cooldown = 120
+	backtest := BacktestGas(handle, Recorded{Gas: gas})
+	// Sample without a base fee is left out
+	assert.Equal(t, 5, backtest.Samples)
+	assert.Equal(t, gas[1].Time, backtest.From)
+	assert.Equal(t, []time.Time{gas[2].Time, gas[5].Time}, backtest.Fired)
+
+	// Eth backtests don't look at gas
+	eth := record(time.Minute, number.NewFromInt(2000), number.NewFromInt(1800), number.Number{}, number.NewFromInt(1700))
+	ethHandle := alerts.EthereumAlert{Type: alerts.EthereumAlertTypeDroppedBy, Base: number.NewFromInt(10), Intv: 3600}
+	backtest = BacktestEth(ethHandle, Recorded{Eth: eth, Gas: gas})
+	assert.Equal(t, len(eth), backtest.Samples)
+	assert.Equal(t, []time.Time{eth[1].Time}, backtest.Fired)
+}
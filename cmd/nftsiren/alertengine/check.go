@@ -0,0 +1,150 @@
+package alertengine
+
+import (
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/log"
+	"nftsiren/pkg/number"
+)
+
+// CollectionCheck is the collection alert being checked, stateful types keep their state in it
+type CollectionCheck interface {
+	Handle() alerts.CollectionAlert
+	State() alerts.State
+	SetState(state alerts.State)
+}
+
+// Conditions of compound alerts are never stateful, so they have no state to keep
+type clauseCheck struct {
+	handle alerts.CollectionAlert
+}
+
+func (check clauseCheck) Handle() alerts.CollectionAlert { return check.handle }
+func (check clauseCheck) State() alerts.State            { return alerts.State{} }
+func (check clauseCheck) SetState(alerts.State)          {}
+
+// CheckCollection reports whether the collection alert condition holds and the value compared with the base, value may be nil
+func CheckCollection(alert CollectionCheck, values CollectionValues) (bool, number.Number) {
+	handle := alert.Handle()
+	interval := time.Duration(handle.Interval()) * time.Second
+	// value is compared with the alert base, it stays nil when there is nothing to compare
+	var value number.Number
+	ok := false
+	switch handle.Type {
+	case alerts.CollectionAlertTypeFloorLessThan, alerts.CollectionAlertTypeFloorGreaterThan:
+		// Direct check against floor
+		value, ok = values.Floor()
+	case alerts.CollectionAlertTypeSalesGreaterThan:
+		// Compare current TotalSales with previous TotalSales
+		// First check interval
+		// TODO
+	case alerts.CollectionAlertTypeFloorDroppedBy, alerts.CollectionAlertTypeFloorRoseBy:
+		var drop, rise number.Number
+		drop, rise, ok = values.StatChange(interval, FloorStat)
+		value = pickChange(handle.Type == alerts.CollectionAlertTypeFloorDroppedBy, drop, rise)
+	case alerts.CollectionAlertTypeListedGreaterThan:
+		stats := values.Stats()
+		if stats != nil && !stats.Listed.IsNil() {
+			value, ok = stats.Listed, true
+		}
+	case alerts.CollectionAlertTypeListedRoseBy:
+		_, value, ok = values.StatChange(interval, ListedStat)
+	case alerts.CollectionAlertTypeOwnersDroppedBy:
+		value, _, ok = values.StatChange(interval, OwnersStat)
+	case alerts.CollectionAlertTypeVolumeSpike:
+		value, ok = values.VolumeSpike(interval)
+	case alerts.CollectionAlertTypeSalesSpike:
+		value, ok = values.SalesSpike(interval)
+	case alerts.CollectionAlertTypeTrailingStop, alerts.CollectionAlertTypeBand:
+		floor, ok := values.Floor()
+		if !ok {
+			return false, number.Number{}
+		}
+		state, fired := handle.Step(alert.State(), floor)
+		alert.SetState(state)
+		return fired, number.Number{}
+	case alerts.CollectionAlertTypeExpression:
+		return exprMatched(handle.Eval(values.Vars())), number.Number{}
+	}
+	if !ok {
+		return false, number.Number{}
+	}
+	return handle.Check(value), value
+}
+
+// CheckEth reports whether the ethereum alert condition holds and the value compared with the base
+func CheckEth(handle alerts.EthereumAlert, values PriceValues) (bool, number.Number) {
+	if handle.Type == alerts.EthereumAlertTypeExpression {
+		return exprMatched(handle.Eval(values.Vars())), number.Number{}
+	}
+	if handle.NeedsInterval() {
+		// Percent change
+		drop, rise, ok := values.Change(time.Duration(handle.Interval()) * time.Second)
+		if !ok {
+			return false, number.Number{}
+		}
+		change := pickChange(handle.Type == alerts.EthereumAlertTypeDroppedBy, drop, rise)
+		return handle.Check(change), change
+	}
+	eth, ok := values.Latest()
+	if !ok {
+		return false, number.Number{}
+	}
+	return handle.Check(eth), eth
+}
+
+// CheckGas is CheckEth for gas alerts, values are the prices of the tier of the alert
+func CheckGas(handle alerts.GasAlert, values PriceValues) (bool, number.Number) {
+	if handle.Type == alerts.GasAlertTypeExpression {
+		return exprMatched(handle.Eval(values.Vars())), number.Number{}
+	}
+	if handle.NeedsInterval() {
+		// Percent change
+		drop, rise, ok := values.Change(time.Duration(handle.Interval()) * time.Second)
+		if !ok {
+			return false, number.Number{}
+		}
+		change := pickChange(handle.Type == alerts.GasAlertTypeDroppedBy, drop, rise)
+		return handle.Check(change), change
+	}
+	gas, ok := values.Latest()
+	if !ok {
+		return false, number.Number{}
+	}
+	return handle.Check(gas), gas
+}
+
+// ClauseHolds reports whether the condition of a compound alert holds now, missing data never holds
+func ClauseHolds(clause alerts.Clause, sources Sources) bool {
+	var ok bool
+	switch {
+	case clause.Collection != nil:
+		values, found := sources.Collection(clause.Asset)
+		if !found {
+			return false
+		}
+		ok, _ = CheckCollection(clauseCheck{*clause.Collection}, values)
+	case clause.Ethereum != nil:
+		ok, _ = CheckEth(*clause.Ethereum, sources.Eth())
+	case clause.Gas != nil:
+		ok, _ = CheckGas(*clause.Gas, sources.Gas(clause.Gas.Tier))
+	}
+	return ok
+}
+
+func pickChange(dropped bool, drop, rise number.Number) number.Number {
+	if dropped {
+		return drop
+	}
+	return rise
+}
+
+// Missing fields are expected until everything is fetched, so errors are only logged
+func exprMatched(ok bool, err error) bool {
+	if err != nil {
+		log.Debug().Println("Expression not evaluated:", err)
+		return false
+	}
+	return ok
+}
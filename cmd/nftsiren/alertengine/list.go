@@ -0,0 +1,72 @@
+package alertengine
+
+import (
+	"sync"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/number"
+)
+
+// List is a thread safe list of alerts
+// can be initialized by new(List)
+// Alerts in the list are identified by their id, identical alerts may coexist
+type List struct {
+	mutex sync.Mutex
+	slice []alerts.Alert
+}
+
+func (list *List) Len() int {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+	return len(list.slice)
+}
+
+// Has reports whether this alert is in the list
+func (list *List) Has(alert alerts.Alert) bool {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+	for _, a := range list.slice {
+		if a.Identity().ID == alert.Identity().ID {
+			return true
+		}
+	}
+	return false
+}
+
+func (list *List) Add(alert alerts.Alert) bool {
+	if list.Has(alert) {
+		return false
+	}
+	// Append and return true
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+	list.slice = append(list.slice, alert)
+	return true
+}
+
+func (list *List) Remove(alert alerts.Alert) bool {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+	for i, a := range list.slice {
+		if a.Identity().ID == alert.Identity().ID {
+			list.slice = append(list.slice[:i], list.slice[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (list *List) ForEach(fn func(index int, alert alerts.Alert)) {
+	list.mutex.Lock()
+	for i, alert := range list.slice {
+		fn(i, alert)
+	}
+	list.mutex.Unlock()
+}
+
+// CheckAll checks every alert in the list
+func (list *List) CheckAll() {
+	list.ForEach(func(index int, alert alerts.Alert) {
+		alert.Check(number.Number{})
+	})
+}
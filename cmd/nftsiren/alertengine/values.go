@@ -0,0 +1,78 @@
+package alertengine
+
+import (
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/apis/etherscan"
+	"nftsiren/pkg/nft"
+	"nftsiren/pkg/number"
+	"nftsiren/pkg/window"
+)
+
+// CollectionValues are checked by collection alerts, live ones come from the collection and replayed ones from its history
+type CollectionValues interface {
+	Floor() (number.Number, bool)
+	Stats() *nft.CollectionStats // Nil until the stats are known
+	// Percent drop and rise of the stat within the last d
+	StatChange(d time.Duration, stat func(nft.CollectionStats) number.Number) (drop, rise number.Number, ok bool)
+	// How many times the latest 24h volume and sales are of their average within the last d
+	VolumeSpike(d time.Duration) (number.Number, bool)
+	SalesSpike(d time.Duration) (number.Number, bool)
+	Vars() alerts.Vars
+}
+
+// PriceValues are checked by ethereum and gas alerts
+type PriceValues interface {
+	Latest() (number.Number, bool)
+	// Percent drop and rise within the last d
+	Change(d time.Duration) (drop, rise number.Number, ok bool)
+	Vars() alerts.Vars
+}
+
+// Sources gives the live values which the clauses of compound alerts are checked against
+type Sources interface {
+	Collection(asset alerts.Asset) (CollectionValues, bool)
+	Eth() PriceValues
+	Gas(tier alerts.GasTier) PriceValues
+}
+
+func FloorStat(stats nft.CollectionStats) number.Number     { return stats.Floor }
+func ListedStat(stats nft.CollectionStats) number.Number    { return stats.Listed }
+func OwnersStat(stats nft.CollectionStats) number.Number    { return stats.NumOwners }
+func DayVolumeStat(stats nft.CollectionStats) number.Number { return stats.DayVolume }
+func DaySalesStat(stats nft.CollectionStats) number.Number  { return stats.DaySales }
+
+func Identity(n number.Number) number.Number {
+	return n
+}
+
+// Spike alerts compare with the average of the baseline
+const (
+	BaselineMinSamples = 4
+	// Samples must span this part of the interval, otherwise a short warm-up would look like a spike
+	BaselineMinCoverage = 0.5
+)
+
+// BaselineRatio is the ratio of the latest value to the average of the baseline samples within d
+func BaselineRatio(samples []window.Sample[nft.CollectionStats], d time.Duration, stat func(nft.CollectionStats) number.Number) (number.Number, bool) {
+	if len(samples) == 0 || samples[len(samples)-1].Time.Sub(samples[0].Time) < time.Duration(float64(d)*BaselineMinCoverage) {
+		return number.Number{}, false
+	}
+	return window.RatioToAverageOf(samples, BaselineMinSamples, stat)
+}
+
+// GasTierValue is the price of the tier, nil when etherscan doesn't give it
+func GasTierValue(gas etherscan.GasPrice, tier alerts.GasTier) number.Number {
+	switch tier {
+	case alerts.GasTierSafe:
+		return gas.SafeGasPrice
+	case alerts.GasTierFast:
+		return gas.FastGasPrice
+	case alerts.GasTierBaseFee:
+		return gas.SuggestBaseFee
+	case alerts.GasTierPriorityFee:
+		return gas.PriorityFee()
+	}
+	return gas.ProposeGasPrice
+}
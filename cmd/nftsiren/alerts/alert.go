@@ -1,6 +1,11 @@
 package alerts
 
-import "nftsiren/pkg/number"
+import (
+	"strings"
+	"time"
+
+	"nftsiren/pkg/number"
+)
 
 type Condition interface {
 	// This can be shown to user
@@ -11,13 +16,24 @@ type Condition interface {
 	NeedsInterval() bool
 }
 
+// Conditions checking a range implement this, upper bound is entered separately
+type RangeCondition interface {
+	Condition
+	// Label of the upper bound, empty when this condition is not a range
+	UpperLabel() string
+}
+
+var _ RangeCondition = CollectionAlertType(0)
+
 var _ Condition = CollectionAlertType(0)
 var _ Condition = EthereumAlertType(0)
 var _ Condition = GasAlertType(0)
+var _ Condition = PairAlertType(0)
+var _ Condition = CompoundAlertType(0)
 
 // Alert must be immutable
 type Alert interface {
-	// This is for debugging and for checking equality
+	// This is for debugging, use Identity for equality
 	String() string
 	// This will return a description of what this alert does, can be showed to user
 	Description() string
@@ -32,8 +48,58 @@ type Alert interface {
 	Check(num2 number.Number) bool
 	// This text can be shown to user in a notification
 	NotificationText() string
+	// Cooldown, hysteresis and debounce settings of this alert
+	Triggering() Trigger
+	// Unique id and timestamps, stays same across edits
+	Identity() Meta
+	// How far num is from Base on the side where the condition doesn't hold, used for hysteresis
+	// Nil when alert has no single value to compare
+	Distance(num number.Number) number.Number
+}
+
+// Editable alerts return edited copies of themselves, every alert type implements it
+type Editable[T any] interface {
+	Alert
+	// Type of the alert
+	Condition() Condition
+	// Base number and whether it can be edited, expressions and ranges have no single base
+	Threshold() (number.Number, bool)
+	WithBase(base number.Number) T
+	WithLoop(loop bool) T
+	WithTrigger(trigger Trigger) T
+	WithMeta(meta Meta) T
+}
+
+var _ Editable[CollectionAlert] = CollectionAlert{}
+var _ Editable[EthereumAlert] = EthereumAlert{}
+var _ Editable[GasAlert] = GasAlert{}
+var _ Editable[PairAlert] = PairAlert{}
+var _ Editable[CompoundAlert] = CompoundAlert{}
+
+// State is the mutable part of stateful alerts, it is kept next to the immutable alert
+type State struct {
+	Peak     number.Number `json:"peak,omitempty"`     // Highest value since creation or last notification
+	Disarmed bool          `json:"disarmed,omitempty"` // Set after firing until the value comes back
+}
+
+func (state State) IsZero() bool {
+	return state.Peak.IsNil() && !state.Disarmed
 }
 
 var _ Alert = &CollectionAlert{}
 var _ Alert = &EthereumAlert{}
 var _ Alert = &GasAlert{}
+var _ Alert = &PairAlert{}
+var _ Alert = &CompoundAlert{}
+
+// Formats interval seconds like 30m or 1h30m
+func FormatInterval(seconds int) string {
+	str := (time.Duration(seconds) * time.Second).String()
+	if strings.HasSuffix(str, "m0s") {
+		str = str[:len(str)-2]
+	}
+	if strings.HasSuffix(str, "h0m") {
+		str = str[:len(str)-2]
+	}
+	return str
+}
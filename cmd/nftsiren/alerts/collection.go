@@ -12,6 +12,16 @@ const (
 	CollectionAlertTypeFloorLessThan CollectionAlertType = iota
 	CollectionAlertTypeFloorGreaterThan
 	CollectionAlertTypeSalesGreaterThan
+	CollectionAlertTypeExpression // Custom expression over CollectionFields
+	CollectionAlertTypeFloorDroppedBy
+	CollectionAlertTypeFloorRoseBy
+	CollectionAlertTypeVolumeSpike // 24h volume compared to its average in interval
+	CollectionAlertTypeSalesSpike  // 24h sales compared to its average in interval
+	CollectionAlertTypeListedGreaterThan
+	CollectionAlertTypeListedRoseBy
+	CollectionAlertTypeOwnersDroppedBy
+	CollectionAlertTypeTrailingStop // Stateful, see Step
+	CollectionAlertTypeBand         // Stateful, see Step
 )
 
 func (t CollectionAlertType) String() string {
@@ -22,6 +32,26 @@ func (t CollectionAlertType) String() string {
 		return "Floor Greater Than"
 	case CollectionAlertTypeSalesGreaterThan:
 		return "Sales Greater Than"
+	case CollectionAlertTypeExpression:
+		return "Expression"
+	case CollectionAlertTypeFloorDroppedBy:
+		return "Floor Dropped By"
+	case CollectionAlertTypeFloorRoseBy:
+		return "Floor Rose By"
+	case CollectionAlertTypeVolumeSpike:
+		return "Volume Spike"
+	case CollectionAlertTypeSalesSpike:
+		return "Sales Spike"
+	case CollectionAlertTypeListedGreaterThan:
+		return "Listed Greater Than"
+	case CollectionAlertTypeListedRoseBy:
+		return "Listed Rose By"
+	case CollectionAlertTypeOwnersDroppedBy:
+		return "Owners Dropped By"
+	case CollectionAlertTypeTrailingStop:
+		return "Floor Trailing Stop"
+	case CollectionAlertTypeBand:
+		return "Floor Leaves Range"
 	}
 	return "UNKNOWN"
 }
@@ -36,10 +66,36 @@ func (t CollectionAlertType) Label() string {
 		return "Floor Price (ETH)"
 	case CollectionAlertTypeSalesGreaterThan:
 		return "Total Sales In Interval"
+	case CollectionAlertTypeExpression:
+		return "Expression"
+	case CollectionAlertTypeFloorDroppedBy, CollectionAlertTypeFloorRoseBy:
+		return "Change (%)"
+	case CollectionAlertTypeVolumeSpike, CollectionAlertTypeSalesSpike:
+		return "Times The Average"
+	case CollectionAlertTypeListedGreaterThan:
+		return "Listed Items"
+	case CollectionAlertTypeListedRoseBy, CollectionAlertTypeOwnersDroppedBy:
+		return "Change (%)"
+	case CollectionAlertTypeTrailingStop:
+		return "Drop From Peak (%)"
+	case CollectionAlertTypeBand:
+		return "Low Floor (ETH)"
 	}
 	return "UNKNOWN"
 }
 
+func (t CollectionAlertType) UpperLabel() string {
+	if t == CollectionAlertTypeBand {
+		return "High Floor (ETH)"
+	}
+	return ""
+}
+
+// Whether this type keeps a State
+func (t CollectionAlertType) Stateful() bool {
+	return t == CollectionAlertTypeTrailingStop || t == CollectionAlertTypeBand
+}
+
 func (t CollectionAlertType) NeedsInterval() bool {
 	switch t {
 	case CollectionAlertTypeFloorLessThan:
@@ -48,18 +104,38 @@ func (t CollectionAlertType) NeedsInterval() bool {
 		return false
 	case CollectionAlertTypeSalesGreaterThan:
 		return true
+	case CollectionAlertTypeFloorDroppedBy, CollectionAlertTypeFloorRoseBy:
+		return true
+	case CollectionAlertTypeVolumeSpike, CollectionAlertTypeSalesSpike:
+		return true
+	case CollectionAlertTypeListedGreaterThan:
+		return false
+	case CollectionAlertTypeListedRoseBy, CollectionAlertTypeOwnersDroppedBy:
+		return true
 	}
 	return false
 }
 
 type CollectionAlert struct {
-	Type CollectionAlertType `json:"type"     bson:"type"`     // Type of the alert
-	Base number.Number       `json:"base"     bson:"base"`     // A number to check against something specified by type
-	Intv int                 `json:"interval" bson:"interval"` // The time between each update in seconds
-	Loop bool                `json:"loop"     bson:"loop"`     // Is it needs to be checked continiously?
+	Type CollectionAlertType `json:"type"     bson:"type"`                 // Type of the alert
+	Base number.Number       `json:"base"     bson:"base"`                 // A number to check against something specified by type
+	Intv int                 `json:"interval" bson:"interval"`             // The time between each update in seconds
+	Loop bool                `json:"loop"     bson:"loop"`                 // Is it needs to be checked continiously?
+	Expr string              `json:"expr,omitempty" bson:"expr,omitempty"` // Only used by expression type
+	High number.Number       `json:"high,omitempty" bson:"high,omitempty"` // Upper bound of band type, Base is the lower
+
+	// Trigger settings and identity, flattened in json
+	Trigger `bson:",inline"`
+	Meta    `bson:",inline"`
 }
 
 func (alert CollectionAlert) String() string {
+	if alert.Type == CollectionAlertTypeExpression {
+		return fmt.Sprintf("%v:%s:%v", alert.Type, alert.Expr, alert.Loop)
+	}
+	if alert.Type == CollectionAlertTypeBand {
+		return fmt.Sprintf("%v:%v-%v:%v", alert.Type, alert.Base, alert.High, alert.Loop)
+	}
 	return fmt.Sprintf("%v:%v:%d:%v", alert.Type, alert.Base, alert.Intv, alert.Loop)
 }
 
@@ -71,6 +147,26 @@ func (alert CollectionAlert) Description() string {
 		return fmt.Sprintf("Checks for Floor > %v", alert.Base)
 	case CollectionAlertTypeSalesGreaterThan:
 		return fmt.Sprintf("Checks for Sales > %v every %d seconds", alert.Base, alert.Intv)
+	case CollectionAlertTypeExpression:
+		return fmt.Sprintf("Checks for %s", alert.Expr)
+	case CollectionAlertTypeFloorDroppedBy:
+		return fmt.Sprintf("Checks for Floor drop >= %v%% within %s", alert.Base, FormatInterval(alert.Intv))
+	case CollectionAlertTypeFloorRoseBy:
+		return fmt.Sprintf("Checks for Floor rise >= %v%% within %s", alert.Base, FormatInterval(alert.Intv))
+	case CollectionAlertTypeVolumeSpike:
+		return fmt.Sprintf("Checks for 24h Volume >= %vx of %s average", alert.Base, FormatInterval(alert.Intv))
+	case CollectionAlertTypeSalesSpike:
+		return fmt.Sprintf("Checks for 24h Sales >= %vx of %s average", alert.Base, FormatInterval(alert.Intv))
+	case CollectionAlertTypeListedGreaterThan:
+		return fmt.Sprintf("Checks for Listed > %v", alert.Base)
+	case CollectionAlertTypeListedRoseBy:
+		return fmt.Sprintf("Checks for Listed rise >= %v%% within %s", alert.Base, FormatInterval(alert.Intv))
+	case CollectionAlertTypeOwnersDroppedBy:
+		return fmt.Sprintf("Checks for Owners drop >= %v%% within %s", alert.Base, FormatInterval(alert.Intv))
+	case CollectionAlertTypeTrailingStop:
+		return fmt.Sprintf("Checks for Floor drop >= %v%% from its peak", alert.Base)
+	case CollectionAlertTypeBand:
+		return fmt.Sprintf("Checks for Floor outside %v - %v", alert.Base, alert.High)
 	}
 	return "UNKNOWN"
 }
@@ -88,6 +184,7 @@ func (alert CollectionAlert) Looping() bool {
 }
 
 // returns true when check passed
+// Expression alerts always return false, use Eval for them, stateful ones use Step
 func (alert CollectionAlert) Check(num number.Number) bool {
 	switch alert.Type {
 	case CollectionAlertTypeFloorLessThan:
@@ -96,10 +193,29 @@ func (alert CollectionAlert) Check(num number.Number) bool {
 		return num.GreaterThan(alert.Base)
 	case CollectionAlertTypeSalesGreaterThan:
 		return num.LessThan(alert.Base)
+	case CollectionAlertTypeListedGreaterThan:
+		return num.GreaterThan(alert.Base)
+	case CollectionAlertTypeFloorDroppedBy, CollectionAlertTypeFloorRoseBy,
+		CollectionAlertTypeListedRoseBy, CollectionAlertTypeOwnersDroppedBy:
+		// num is the percent change
+		return num.GreaterThanOrEqual(alert.Base)
+	case CollectionAlertTypeVolumeSpike, CollectionAlertTypeSalesSpike:
+		// num is the ratio to the average
+		return num.GreaterThanOrEqual(alert.Base)
 	}
 	return false
 }
 
+func (alert CollectionAlert) Distance(num number.Number) number.Number {
+	switch alert.Type {
+	case CollectionAlertTypeExpression, CollectionAlertTypeTrailingStop, CollectionAlertTypeBand:
+		return number.Number{}
+	case CollectionAlertTypeFloorLessThan, CollectionAlertTypeSalesGreaterThan:
+		return backDistance(num, alert.Base, true)
+	}
+	return backDistance(num, alert.Base, false)
+}
+
 func (alert CollectionAlert) NotificationText() string {
 	switch alert.Type {
 	case CollectionAlertTypeFloorLessThan:
@@ -108,6 +224,88 @@ func (alert CollectionAlert) NotificationText() string {
 		return fmt.Sprintf("Floor is greater than %v", alert.Base)
 	case CollectionAlertTypeSalesGreaterThan:
 		return fmt.Sprintf("Sales passed %v in past %d seconds", alert.Base, alert.Intv)
+	case CollectionAlertTypeExpression:
+		return fmt.Sprintf("%s matched", alert.Expr)
+	case CollectionAlertTypeFloorDroppedBy:
+		return fmt.Sprintf("Floor dropped %v%% within %s", alert.Base, FormatInterval(alert.Intv))
+	case CollectionAlertTypeFloorRoseBy:
+		return fmt.Sprintf("Floor rose %v%% within %s", alert.Base, FormatInterval(alert.Intv))
+	case CollectionAlertTypeVolumeSpike:
+		return fmt.Sprintf("24h volume is %vx of its %s average", alert.Base, FormatInterval(alert.Intv))
+	case CollectionAlertTypeSalesSpike:
+		return fmt.Sprintf("24h sales are %vx of its %s average", alert.Base, FormatInterval(alert.Intv))
+	case CollectionAlertTypeListedGreaterThan:
+		return fmt.Sprintf("Listed items passed %v", alert.Base)
+	case CollectionAlertTypeListedRoseBy:
+		return fmt.Sprintf("Listed items rose %v%% within %s", alert.Base, FormatInterval(alert.Intv))
+	case CollectionAlertTypeOwnersDroppedBy:
+		return fmt.Sprintf("Owners dropped %v%% within %s", alert.Base, FormatInterval(alert.Intv))
+	case CollectionAlertTypeTrailingStop:
+		return fmt.Sprintf("Floor dropped %v%% from its peak", alert.Base)
+	case CollectionAlertTypeBand:
+		return fmt.Sprintf("Floor left %v - %v", alert.Base, alert.High)
 	}
 	return "UNKNOWN"
 }
+
+// Step advances the state of a stateful alert with the current floor and reports whether it fires
+// Trailing stop fires when floor drops Base percent from the peak and restarts from the current floor
+// Band fires once when floor leaves [Base, High] and re-arms when it comes back
+func (alert CollectionAlert) Step(state State, floor number.Number) (State, bool) {
+	switch alert.Type {
+	case CollectionAlertTypeTrailingStop:
+		if state.Peak.IsNil() || floor.GreaterThan(state.Peak) {
+			state.Peak = floor
+		}
+		if state.Peak.IsZero() {
+			return state, false
+		}
+		drop := state.Peak.Sub(floor).Div(state.Peak).MulInt64(100)
+		if drop.GreaterThanOrEqual(alert.Base) {
+			state.Peak = floor
+			return state, true
+		}
+	case CollectionAlertTypeBand:
+		outside := floor.LessThan(alert.Base) || floor.GreaterThan(alert.High)
+		if !outside {
+			state.Disarmed = false
+		} else if !state.Disarmed {
+			state.Disarmed = true
+			return state, true
+		}
+	}
+	return state, false
+}
+
+// Eval evaluates the expression of an expression alert
+func (alert CollectionAlert) Eval(vars Vars) (bool, error) {
+	return EvalExpr(alert.Expr, CollectionFields, vars)
+}
+
+func (alert CollectionAlert) Condition() Condition {
+	return alert.Type
+}
+
+func (alert CollectionAlert) Threshold() (number.Number, bool) {
+	return alert.Base, alert.Type != CollectionAlertTypeExpression && alert.Type != CollectionAlertTypeBand
+}
+
+func (alert CollectionAlert) WithBase(base number.Number) CollectionAlert {
+	alert.Base = base
+	return alert
+}
+
+func (alert CollectionAlert) WithLoop(loop bool) CollectionAlert {
+	alert.Loop = loop
+	return alert
+}
+
+func (alert CollectionAlert) WithTrigger(trigger Trigger) CollectionAlert {
+	alert.Trigger = trigger
+	return alert
+}
+
+func (alert CollectionAlert) WithMeta(meta Meta) CollectionAlert {
+	alert.Meta = meta
+	return alert
+}
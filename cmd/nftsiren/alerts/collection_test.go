@@ -0,0 +1,71 @@
+package alerts
+
+import (
+	"testing"
+
+	"nftsiren/pkg/number"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Feeds floors one by one and returns the indexes of the ones firing
+func stepAll(alert CollectionAlert, state State, floors ...float64) (State, []int) {
+	var fired []int
+	for i, floor := range floors {
+		var ok bool
+		state, ok = alert.Step(state, number.NewFromFloat(floor))
+		if ok {
+			fired = append(fired, i)
+		}
+	}
+	return state, fired
+}
+
+func TestTrailingStop(t *testing.T) {
+	alert := CollectionAlert{Type: CollectionAlertTypeTrailingStop, Base: number.NewFromInt(10)}
+	state, fired := stepAll(alert, State{}, 1, 1.5, 2, 1.9, 1.81)
+	assert.Empty(t, fired)
+	assert.Equal(t, 2.0, state.Peak.Float64())
+
+	// 10% from the peak of 2, then it trails again from 1.79
+	state, fired = stepAll(alert, state, 1.79, 1.7, 1.6)
+	assert.Equal(t, []int{0, 2}, fired)
+	assert.Equal(t, 1.6, state.Peak.Float64())
+}
+
+func TestBand(t *testing.T) {
+	alert := CollectionAlert{Type: CollectionAlertTypeBand, Base: number.NewFromInt(1), High: number.NewFromInt(2)}
+	state, fired := stepAll(alert, State{}, 1.5, 2.5, 3, 1.9, 0.5, 0.4, 1)
+	// Fires once when leaving, re-arms when coming back
+	assert.Equal(t, []int{1, 4}, fired)
+	assert.False(t, state.Disarmed)
+	assert.True(t, state.IsZero())
+
+	// Outside at creation fires immediately
+	state, fired = stepAll(alert, State{}, 5, 5)
+	assert.Equal(t, []int{0}, fired)
+	assert.True(t, state.Disarmed)
+}
+
+func TestListedOwners(t *testing.T) {
+	listed := CollectionAlert{Type: CollectionAlertTypeListedGreaterThan, Base: number.NewFromInt(50)}
+	assert.False(t, listed.NeedsInterval())
+	assert.False(t, listed.Check(number.NewFromInt(50)))
+	assert.True(t, listed.Check(number.NewFromInt(51)))
+	assert.Equal(t, "Checks for Listed > 50", listed.Description())
+	assert.Equal(t, "Listed items passed 50", listed.NotificationText())
+
+	rose := CollectionAlert{Type: CollectionAlertTypeListedRoseBy, Base: number.NewFromInt(20), Intv: 3600}
+	assert.True(t, rose.NeedsInterval())
+	assert.False(t, rose.Check(number.NewFromFloat(19.9)))
+	assert.True(t, rose.Check(number.NewFromInt(20)))
+	assert.Equal(t, "Checks for Listed rise >= 20% within 1h", rose.Description())
+	assert.Equal(t, "Listed items rose 20% within 1h", rose.NotificationText())
+
+	owners := CollectionAlert{Type: CollectionAlertTypeOwnersDroppedBy, Base: number.NewFromInt(5), Intv: 1800}
+	assert.True(t, owners.NeedsInterval())
+	assert.False(t, owners.Check(number.NewFromInt(4)))
+	assert.True(t, owners.Check(number.NewFromInt(5)))
+	assert.Equal(t, "Checks for Owners drop >= 5% within 30m", owners.Description())
+	assert.Equal(t, "Owners dropped 5% within 30m", owners.NotificationText())
+}
@@ -0,0 +1,249 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"nftsiren/pkg/number"
+)
+
+type CompoundAlertType int
+
+const (
+	CompoundAlertTypeAll CompoundAlertType = iota // Every clause holds
+	CompoundAlertTypeAny                          // At least one clause holds
+)
+
+func (t CompoundAlertType) String() string {
+	switch t {
+	case CompoundAlertTypeAll:
+		return "All Of"
+	case CompoundAlertTypeAny:
+		return "Any Of"
+	}
+	return "UNKNOWN"
+}
+
+func (t CompoundAlertType) Label() string {
+	return "Conditions"
+}
+
+func (t CompoundAlertType) NeedsInterval() bool {
+	return false
+}
+
+// Joins the clauses in descriptions
+func (t CompoundAlertType) operator() string {
+	if t == CompoundAlertTypeAny {
+		return "OR"
+	}
+	return "AND"
+}
+
+// Clause is one condition of a compound alert, exactly one of the alerts is set
+// Only the type, base and interval of the alert are used, its trigger settings are ignored
+type Clause struct {
+	Asset      Asset            `json:"asset,omitempty" bson:"asset,omitempty"` // Collection of the collection alert
+	Collection *CollectionAlert `json:"collection,omitempty" bson:"collection,omitempty"`
+	Ethereum   *EthereumAlert   `json:"ethereum,omitempty" bson:"ethereum,omitempty"`
+	Gas        *GasAlert        `json:"gas,omitempty" bson:"gas,omitempty"`
+}
+
+func (clause Clause) alert() Alert {
+	switch {
+	case clause.Collection != nil:
+		return clause.Collection
+	case clause.Ethereum != nil:
+		return clause.Ethereum
+	case clause.Gas != nil:
+		return clause.Gas
+	}
+	return nil
+}
+
+// Like azuki: Floor < 5 or GAS < 20
+func (clause Clause) String() string {
+	alert := clause.alert()
+	if alert == nil {
+		return "UNKNOWN"
+	}
+	desc := strings.TrimPrefix(alert.Description(), "Checks for ")
+	if clause.Collection != nil {
+		return fmt.Sprintf("%s: %s", clause.Asset.Symbol, desc)
+	}
+	return desc
+}
+
+// Involves reports whether the clause reads the asset, EthAsset stands for both eth and gas
+func (clause Clause) Involves(asset Asset) bool {
+	if clause.Collection != nil {
+		return clause.Asset == asset
+	}
+	return asset.IsEth()
+}
+
+func (clause Clause) Validate() error {
+	set := 0
+	for _, alert := range []bool{clause.Collection != nil, clause.Ethereum != nil, clause.Gas != nil} {
+		if alert {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("clause must have exactly one condition")
+	}
+	if clause.Collection != nil {
+		if clause.Asset.IsEth() {
+			return errors.New("collection condition needs a collection")
+		}
+		if !ClauseConditions[clause.Collection.Type] {
+			return fmt.Errorf("%v can't be combined", clause.Collection.Type)
+		}
+	}
+	if clause.Ethereum != nil && !ClauseConditions[clause.Ethereum.Type] {
+		return fmt.Errorf("%v can't be combined", clause.Ethereum.Type)
+	}
+	if clause.Gas != nil && !ClauseConditions[clause.Gas.Type] {
+		return fmt.Errorf("%v can't be combined", clause.Gas.Type)
+	}
+	return nil
+}
+
+// Conditions which can be clauses, stateful ones and expressions are left out
+var ClauseConditions = map[Condition]bool{
+	CollectionAlertTypeFloorLessThan:     true,
+	CollectionAlertTypeFloorGreaterThan:  true,
+	CollectionAlertTypeFloorDroppedBy:    true,
+	CollectionAlertTypeFloorRoseBy:       true,
+	CollectionAlertTypeVolumeSpike:       true,
+	CollectionAlertTypeSalesSpike:        true,
+	CollectionAlertTypeListedGreaterThan: true,
+	CollectionAlertTypeListedRoseBy:      true,
+	CollectionAlertTypeOwnersDroppedBy:   true,
+	EthereumAlertTypeLessThan:            true,
+	EthereumAlertTypeGreaterThan:         true,
+	EthereumAlertTypeDroppedBy:           true,
+	EthereumAlertTypeRoseBy:              true,
+	GasAlertTypeLessThan:                 true,
+	GasAlertTypeGreaterThan:              true,
+	GasAlertTypeDroppedBy:                true,
+	GasAlertTypeRoseBy:                   true,
+}
+
+// CompoundAlert combines conditions of collections, eth and gas
+// like floor of azuki < 5 and gas < 20 and eth < 2000
+type CompoundAlert struct {
+	Type    CompoundAlertType `json:"type" bson:"type"`
+	Clauses []Clause          `json:"clauses" bson:"clauses"`
+	Loop    bool              `json:"loop" bson:"loop"`
+
+	// Trigger settings and identity, flattened in json
+	Trigger `bson:",inline"`
+	Meta    `bson:",inline"`
+}
+
+func (alert CompoundAlert) String() string {
+	return fmt.Sprintf("%v:%s:%v", alert.Type, alert.formula(), alert.Loop)
+}
+
+// Like azuki: Floor < 5 AND GAS < 20
+func (alert CompoundAlert) formula() string {
+	clauses := make([]string, len(alert.Clauses))
+	for i, clause := range alert.Clauses {
+		clauses[i] = clause.String()
+	}
+	return strings.Join(clauses, " "+alert.Type.operator()+" ")
+}
+
+func (alert CompoundAlert) Validate() error {
+	if len(alert.Clauses) < 2 {
+		return errors.New("add at least two conditions")
+	}
+	for _, clause := range alert.Clauses {
+		if err := clause.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match combines the clauses, holds reports whether one of them holds now
+// Clauses without data should not hold
+func (alert CompoundAlert) Match(holds func(clause Clause) bool) bool {
+	if len(alert.Clauses) == 0 {
+		return false
+	}
+	for _, clause := range alert.Clauses {
+		if holds(clause) == (alert.Type == CompoundAlertTypeAny) {
+			return alert.Type == CompoundAlertTypeAny
+		}
+	}
+	return alert.Type == CompoundAlertTypeAll
+}
+
+// Involves reports whether any clause reads the asset, EthAsset stands for both eth and gas
+func (alert CompoundAlert) Involves(asset Asset) bool {
+	for _, clause := range alert.Clauses {
+		if clause.Involves(asset) {
+			return true
+		}
+	}
+	return false
+}
+
+func (alert CompoundAlert) Description() string {
+	return "Checks for " + alert.formula()
+}
+
+func (alert CompoundAlert) NeedsInterval() bool {
+	return false
+}
+
+func (alert CompoundAlert) Interval() int {
+	return 0
+}
+
+func (alert CompoundAlert) Looping() bool {
+	return alert.Loop
+}
+
+// Compound alerts are checked by Match, there is no single value
+func (alert CompoundAlert) Check(num number.Number) bool {
+	return false
+}
+
+func (alert CompoundAlert) Distance(num number.Number) number.Number {
+	return number.Number{}
+}
+
+func (alert CompoundAlert) NotificationText() string {
+	return alert.formula()
+}
+
+func (alert CompoundAlert) Condition() Condition {
+	return alert.Type
+}
+
+func (alert CompoundAlert) Threshold() (number.Number, bool) {
+	return number.Number{}, false
+}
+
+func (alert CompoundAlert) WithBase(base number.Number) CompoundAlert {
+	return alert
+}
+
+func (alert CompoundAlert) WithLoop(loop bool) CompoundAlert {
+	alert.Loop = loop
+	return alert
+}
+
+func (alert CompoundAlert) WithTrigger(trigger Trigger) CompoundAlert {
+	alert.Trigger = trigger
+	return alert
+}
+
+func (alert CompoundAlert) WithMeta(meta Meta) CompoundAlert {
+	alert.Meta = meta
+	return alert
+}
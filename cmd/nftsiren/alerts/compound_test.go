@@ -0,0 +1,59 @@
+package alerts
+
+import (
+	"encoding/json"
+	"testing"
+
+	"nftsiren/pkg/nft"
+	"nftsiren/pkg/number"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompoundAlert(t *testing.T) {
+	azuki := Asset{Market: nft.Opensea, Symbol: "azuki"}
+	floor := Clause{Asset: azuki, Collection: &CollectionAlert{Type: CollectionAlertTypeFloorLessThan, Base: number.NewFromInt(5)}}
+	gas := Clause{Gas: &GasAlert{Type: GasAlertTypeLessThan, Base: number.NewFromInt(20)}}
+	eth := Clause{Ethereum: &EthereumAlert{Type: EthereumAlertTypeLessThan, Base: number.NewFromInt(2000)}}
+	alert := CompoundAlert{Type: CompoundAlertTypeAll, Clauses: []Clause{floor, gas, eth}}
+	require.NoError(t, alert.Validate())
+	assert.Equal(t, "azuki: Floor < 5 AND GAS < 20 AND ETH$ < 2000", alert.NotificationText())
+	assert.True(t, alert.Involves(azuki))
+	assert.True(t, alert.Involves(EthAsset))
+	assert.False(t, alert.Involves(Asset{Market: nft.Opensea, Symbol: "doodles"}))
+
+	holds := func(clause Clause) bool {
+		return clause.Collection != nil
+	}
+	// Floor holds but gas and eth don't
+	assert.False(t, alert.Match(holds))
+	alert.Type = CompoundAlertTypeAny
+	assert.True(t, alert.Match(holds))
+	assert.False(t, alert.Match(func(Clause) bool { return false }))
+	alert.Type = CompoundAlertTypeAll
+	assert.True(t, alert.Match(func(Clause) bool { return true }))
+
+	// Survives a round trip through the config
+	data, err := json.Marshal(alert)
+	require.NoError(t, err)
+	var loaded CompoundAlert
+	require.NoError(t, json.Unmarshal(data, &loaded))
+	assert.Equal(t, alert.NotificationText(), loaded.NotificationText())
+	assert.Equal(t, azuki, loaded.Clauses[0].Asset)
+}
+
+func TestCompoundAlertValidate(t *testing.T) {
+	gas := Clause{Gas: &GasAlert{Type: GasAlertTypeLessThan, Base: number.NewFromInt(20)}}
+	assert.Error(t, CompoundAlert{Clauses: []Clause{gas}}.Validate())
+	// Collection clauses need a collection
+	floor := Clause{Collection: &CollectionAlert{Type: CollectionAlertTypeFloorLessThan}}
+	assert.Error(t, CompoundAlert{Clauses: []Clause{gas, floor}}.Validate())
+	// Stateful conditions can't be combined
+	floor.Asset = Asset{Market: nft.Opensea, Symbol: "azuki"}
+	floor.Collection.Type = CollectionAlertTypeTrailingStop
+	assert.Error(t, CompoundAlert{Clauses: []Clause{gas, floor}}.Validate())
+	// Only one condition per clause
+	gas.Ethereum = &EthereumAlert{}
+	assert.Error(t, CompoundAlert{Clauses: []Clause{gas, gas}}.Validate())
+}
@@ -11,6 +11,9 @@ type EthereumAlertType int
 const (
 	EthereumAlertTypeLessThan EthereumAlertType = iota
 	EthereumAlertTypeGreaterThan
+	EthereumAlertTypeExpression // Custom expression over EthGasFields
+	EthereumAlertTypeDroppedBy
+	EthereumAlertTypeRoseBy
 )
 
 func (t EthereumAlertType) String() string {
@@ -19,25 +22,49 @@ func (t EthereumAlertType) String() string {
 		return "Eth Less Than"
 	case EthereumAlertTypeGreaterThan:
 		return "Eth Greater Than"
+	case EthereumAlertTypeExpression:
+		return "Expression"
+	case EthereumAlertTypeDroppedBy:
+		return "Eth Dropped By"
+	case EthereumAlertTypeRoseBy:
+		return "Eth Rose By"
 	}
 	return "UNKNOWN"
 }
 
 func (t EthereumAlertType) Label() string {
+	switch t {
+	case EthereumAlertTypeExpression:
+		return "Expression"
+	case EthereumAlertTypeDroppedBy, EthereumAlertTypeRoseBy:
+		return "Change (%)"
+	}
 	return "Eth Price (USD)"
 }
 
 func (t EthereumAlertType) NeedsInterval() bool {
-	return false
+	return t == EthereumAlertTypeDroppedBy || t == EthereumAlertTypeRoseBy
 }
 
 type EthereumAlert struct {
 	Type EthereumAlertType `json:"type" bson:"type"`
 	Base number.Number     `json:"base" bson:"base"`
 	Loop bool              `json:"loop" bson:"loop"`
+	Intv int               `json:"interval,omitempty" bson:"interval,omitempty"` // Window of percent change types in seconds
+	Expr string            `json:"expr,omitempty" bson:"expr,omitempty"`         // Only used by expression type
+
+	// Trigger settings and identity, flattened in json
+	Trigger `bson:",inline"`
+	Meta    `bson:",inline"`
 }
 
 func (alert EthereumAlert) String() string {
+	if alert.Type == EthereumAlertTypeExpression {
+		return fmt.Sprintf("%v:%s:%v", alert.Type, alert.Expr, alert.Loop)
+	}
+	if alert.NeedsInterval() {
+		return fmt.Sprintf("%v:%v:%d:%v", alert.Type, alert.Base, alert.Intv, alert.Loop)
+	}
 	return fmt.Sprintf("%v:%v:%v", alert.Type, alert.Base, alert.Loop)
 }
 
@@ -47,16 +74,22 @@ func (alert EthereumAlert) Description() string {
 		return fmt.Sprintf("Checks for ETH$ < %v", alert.Base)
 	case EthereumAlertTypeGreaterThan:
 		return fmt.Sprintf("Checks for ETH$ > %v", alert.Base)
+	case EthereumAlertTypeExpression:
+		return fmt.Sprintf("Checks for %s", alert.Expr)
+	case EthereumAlertTypeDroppedBy:
+		return fmt.Sprintf("Checks for ETH drop >= %v%% within %s", alert.Base, FormatInterval(alert.Intv))
+	case EthereumAlertTypeRoseBy:
+		return fmt.Sprintf("Checks for ETH rise >= %v%% within %s", alert.Base, FormatInterval(alert.Intv))
 	}
 	return "UNKNOWN"
 }
 
 func (alert EthereumAlert) NeedsInterval() bool {
-	return false
+	return alert.Type.NeedsInterval()
 }
 
 func (alert EthereumAlert) Interval() int {
-	return 0
+	return alert.Intv
 }
 
 func (alert EthereumAlert) Looping() bool {
@@ -64,22 +97,75 @@ func (alert EthereumAlert) Looping() bool {
 }
 
 // returns true when check passed
+// Expression alerts always return false, use Eval for them
 func (alert EthereumAlert) Check(num number.Number) bool {
 	switch alert.Type {
 	case EthereumAlertTypeLessThan:
 		return num.LessThan(alert.Base)
 	case EthereumAlertTypeGreaterThan:
 		return num.GreaterThan(alert.Base)
+	case EthereumAlertTypeDroppedBy, EthereumAlertTypeRoseBy:
+		// num is the percent change
+		return num.GreaterThanOrEqual(alert.Base)
 	}
 	return false
 }
 
+func (alert EthereumAlert) Distance(num number.Number) number.Number {
+	switch alert.Type {
+	case EthereumAlertTypeExpression:
+		return number.Number{}
+	case EthereumAlertTypeLessThan:
+		return backDistance(num, alert.Base, true)
+	}
+	return backDistance(num, alert.Base, false)
+}
+
 func (alert EthereumAlert) NotificationText() string {
 	switch alert.Type {
 	case EthereumAlertTypeLessThan:
 		return fmt.Sprintf("ETH less than %v", alert.Base)
 	case EthereumAlertTypeGreaterThan:
 		return fmt.Sprintf("ETH greater than %v", alert.Base)
+	case EthereumAlertTypeExpression:
+		return fmt.Sprintf("%s matched", alert.Expr)
+	case EthereumAlertTypeDroppedBy:
+		return fmt.Sprintf("ETH dropped %v%% within %s", alert.Base, FormatInterval(alert.Intv))
+	case EthereumAlertTypeRoseBy:
+		return fmt.Sprintf("ETH rose %v%% within %s", alert.Base, FormatInterval(alert.Intv))
 	}
 	return "UNKNOWN"
 }
+
+// Eval evaluates the expression of an expression alert
+func (alert EthereumAlert) Eval(vars Vars) (bool, error) {
+	return EvalExpr(alert.Expr, EthGasFields, vars)
+}
+
+func (alert EthereumAlert) Condition() Condition {
+	return alert.Type
+}
+
+func (alert EthereumAlert) Threshold() (number.Number, bool) {
+	return alert.Base, alert.Type != EthereumAlertTypeExpression
+}
+
+func (alert EthereumAlert) WithBase(base number.Number) EthereumAlert {
+	alert.Base = base
+	return alert
+}
+
+func (alert EthereumAlert) WithLoop(loop bool) EthereumAlert {
+	alert.Loop = loop
+	return alert
+}
+
+func (alert EthereumAlert) WithTrigger(trigger Trigger) EthereumAlert {
+	alert.Trigger = trigger
+	return alert
+}
+
+func (alert EthereumAlert) WithMeta(meta Meta) EthereumAlert {
+	alert.Meta = meta
+	return alert
+}
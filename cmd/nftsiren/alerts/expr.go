@@ -0,0 +1,469 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"nftsiren/pkg/nft"
+	"nftsiren/pkg/number"
+)
+
+// Fields can be used in expressions
+const (
+	FieldFloor     = "floor"
+	FieldDaySales  = "sales24h"
+	FieldDayVolume = "volume24h"
+	FieldSales     = "sales"
+	FieldVolume    = "volume"
+	FieldOwners    = "owners"
+	FieldSupply    = "supply"
+	FieldListed    = "listed"
+	FieldEth       = "eth"
+	FieldGas       = "gas"
+)
+
+// Fields available to collection alert expressions
+var CollectionFields = []string{
+	FieldFloor, FieldDaySales, FieldDayVolume, FieldSales, FieldVolume,
+	FieldOwners, FieldSupply, FieldListed, FieldEth, FieldGas,
+}
+
+// Fields available to ethereum and gas alert expressions
+var EthGasFields = []string{FieldEth, FieldGas}
+
+// Vars holds the current value of every field, unavailable fields are missing
+type Vars map[string]number.Number
+
+func (vars Vars) set(name string, value number.Number) {
+	if !value.IsNil() {
+		vars[name] = value
+	}
+}
+
+// Builds variables from stats, eth and gas, any of them may be nil
+func NewVars(stats *nft.CollectionStats, eth, gas number.Number) Vars {
+	vars := make(Vars)
+	if stats != nil {
+		vars.set(FieldFloor, stats.Floor)
+		vars.set(FieldDaySales, stats.DaySales)
+		vars.set(FieldDayVolume, stats.DayVolume)
+		vars.set(FieldSales, stats.TotalSales)
+		vars.set(FieldVolume, stats.TotalVolume)
+		vars.set(FieldOwners, stats.NumOwners)
+		vars.set(FieldSupply, stats.TotalSupply)
+		vars.set(FieldListed, stats.Listed)
+	}
+	vars.set(FieldEth, eth)
+	vars.set(FieldGas, gas)
+	return vars
+}
+
+// ExprError points to the position of the problem in the expression
+type ExprError struct {
+	Pos int // Byte offset
+	Msg string
+}
+
+func (err *ExprError) Error() string {
+	return fmt.Sprintf("%s at column %d", err.Msg, err.Pos+1)
+}
+
+var ErrEmptyExpr = errors.New("expression is empty")
+
+// Expr is a parsed and type checked expression like
+//
+//	floor < 1.2 && listed > 400 && gas < 25
+//
+// Supports numbers, fields, + - * /, comparisons, && || ! and parentheses
+type Expr struct {
+	src    string
+	root   *exprNode
+	fields []string // Used fields
+}
+
+type exprKind int
+
+const (
+	numberKind exprKind = iota
+	boolKind
+)
+
+func (kind exprKind) String() string {
+	if kind == boolKind {
+		return "condition"
+	}
+	return "number"
+}
+
+type exprNode struct {
+	op          string // Operator, "num" or "field"
+	pos         int
+	kind        exprKind
+	num         number.Number
+	field       string
+	left, right *exprNode
+}
+
+// ParseExpr parses and type checks src, only the given fields can be used
+// Result of the expression must be a condition
+func ParseExpr(src string, fields ...string) (*Expr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, ErrEmptyExpr
+	}
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens, fields: fields}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEnd {
+		return nil, &ExprError{tok.pos, fmt.Sprintf("unexpected %v", tok)}
+	}
+	if root.kind != boolKind {
+		return nil, &ExprError{root.pos, "expression must be a condition, like floor < 1"}
+	}
+	return &Expr{src: src, root: root, fields: p.used}, nil
+}
+
+func (expr *Expr) String() string {
+	return expr.src
+}
+
+// Fields returns the fields used in this expression
+func (expr *Expr) Fields() []string {
+	return expr.fields
+}
+
+// Eval returns an error when a used field is missing in vars or on division by zero
+func (expr *Expr) Eval(vars Vars) (bool, error) {
+	v, err := expr.root.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	return v.b, nil
+}
+
+// Parses and evaluates src at once
+func EvalExpr(src string, fields []string, vars Vars) (bool, error) {
+	expr, err := ParseExpr(src, fields...)
+	if err != nil {
+		return false, err
+	}
+	return expr.Eval(vars)
+}
+
+type exprValue struct {
+	n number.Number
+	b bool
+}
+
+func (node *exprNode) eval(vars Vars) (exprValue, error) {
+	switch node.op {
+	case "num":
+		return exprValue{n: node.num}, nil
+	case "field":
+		n, ok := vars[node.field]
+		if !ok {
+			return exprValue{}, fmt.Errorf("%s is not available", node.field)
+		}
+		return exprValue{n: n}, nil
+	case "!":
+		v, err := node.left.eval(vars)
+		return exprValue{b: !v.b}, err
+	case "neg":
+		v, err := node.left.eval(vars)
+		return exprValue{n: number.NewFromInt(0).Sub(v.n)}, err
+	}
+	left, err := node.left.eval(vars)
+	if err != nil {
+		return exprValue{}, err
+	}
+	// Short circuit
+	switch node.op {
+	case "&&":
+		if !left.b {
+			return exprValue{b: false}, nil
+		}
+	case "||":
+		if left.b {
+			return exprValue{b: true}, nil
+		}
+	}
+	right, err := node.right.eval(vars)
+	if err != nil {
+		return exprValue{}, err
+	}
+	switch node.op {
+	case "&&", "||":
+		return exprValue{b: right.b}, nil
+	case "+":
+		return exprValue{n: left.n.Add(right.n)}, nil
+	case "-":
+		return exprValue{n: left.n.Sub(right.n)}, nil
+	case "*":
+		return exprValue{n: left.n.Mul(right.n)}, nil
+	case "/":
+		if right.n.IsZero() {
+			return exprValue{}, errors.New("division by zero")
+		}
+		return exprValue{n: left.n.Div(right.n)}, nil
+	case "<":
+		return exprValue{b: left.n.LessThan(right.n)}, nil
+	case "<=":
+		return exprValue{b: left.n.LessThanOrEqual(right.n)}, nil
+	case ">":
+		return exprValue{b: left.n.GreaterThan(right.n)}, nil
+	case ">=":
+		return exprValue{b: left.n.GreaterThanOrEqual(right.n)}, nil
+	case "==":
+		return exprValue{b: left.n.Equals(right.n)}, nil
+	case "!=":
+		return exprValue{b: !left.n.Equals(right.n)}, nil
+	}
+	panic("unknown operator " + node.op)
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (tok token) String() string {
+	if tok.kind == tokenEnd {
+		return tok.text
+	}
+	return fmt.Sprintf("%q", tok.text)
+}
+
+// Longer operators must come first
+var operators = []string{"&&", "||", "<=", ">=", "==", "!=", "<", ">", "!", "+", "-", "*", "/", "(", ")"}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+next:
+	for i < len(src) {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokenNumber, src[start:i], start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(src) && (unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i])) || src[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, src[start:i], start})
+		default:
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{tokenOp, op, i})
+					i += len(op)
+					continue next
+				}
+			}
+			if c == '=' || c == '&' || c == '|' {
+				return nil, &ExprError{i, fmt.Sprintf("unknown operator %q, did you mean %q", c, strings.Repeat(string(c), 2))}
+			}
+			return nil, &ExprError{i, fmt.Sprintf("unexpected character %q", c)}
+		}
+	}
+	return append(tokens, token{tokenEnd, "end of expression", len(src)}), nil
+}
+
+type exprParser struct {
+	tokens []token
+	index  int
+	fields []string
+	used   []string
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.index]
+}
+
+func (p *exprParser) accept(ops ...string) (token, bool) {
+	tok := p.peek()
+	if tok.kind != tokenOp {
+		return tok, false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.index++
+			return tok, true
+		}
+	}
+	return tok, false
+}
+
+func expectKind(node *exprNode, kind exprKind, op token) error {
+	if node.kind != kind {
+		return &ExprError{node.pos, fmt.Sprintf("%q needs a %v, got a %v", op.text, kind, node.kind)}
+	}
+	return nil
+}
+
+// Parses left associative binary operators
+func (p *exprParser) binary(operand func() (*exprNode, error), in, out exprKind, ops ...string) (*exprNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if err := expectKind(left, in, op); err != nil {
+			return nil, err
+		}
+		if err := expectKind(right, in, op); err != nil {
+			return nil, err
+		}
+		left = &exprNode{op: op.text, pos: op.pos, kind: out, left: left, right: right}
+	}
+}
+
+func (p *exprParser) or() (*exprNode, error) {
+	return p.binary(p.and, boolKind, boolKind, "||")
+}
+
+func (p *exprParser) and() (*exprNode, error) {
+	return p.binary(p.not, boolKind, boolKind, "&&")
+}
+
+func (p *exprParser) not() (*exprNode, error) {
+	if op, ok := p.accept("!"); ok {
+		operand, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		if err := expectKind(operand, boolKind, op); err != nil {
+			return nil, err
+		}
+		return &exprNode{op: "!", pos: op.pos, kind: boolKind, left: operand}, nil
+	}
+	return p.comparison()
+}
+
+// Comparisons can not be chained, a < b < c is an error
+func (p *exprParser) comparison() (*exprNode, error) {
+	left, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("<", "<=", ">", ">=", "==", "!=")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if err := expectKind(left, numberKind, op); err != nil {
+		return nil, err
+	}
+	if err := expectKind(right, numberKind, op); err != nil {
+		return nil, err
+	}
+	return &exprNode{op: op.text, pos: op.pos, kind: boolKind, left: left, right: right}, nil
+}
+
+func (p *exprParser) sum() (*exprNode, error) {
+	return p.binary(p.product, numberKind, numberKind, "+", "-")
+}
+
+func (p *exprParser) product() (*exprNode, error) {
+	return p.binary(p.unary, numberKind, numberKind, "*", "/")
+}
+
+func (p *exprParser) unary() (*exprNode, error) {
+	if op, ok := p.accept("-"); ok {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if err := expectKind(operand, numberKind, op); err != nil {
+			return nil, err
+		}
+		return &exprNode{op: "neg", pos: op.pos, kind: numberKind, left: operand}, nil
+	}
+	return p.primary()
+}
+
+func (p *exprParser) primary() (*exprNode, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokenNumber:
+		p.index++
+		n, ok := number.NewFromString(tok.text)
+		if !ok {
+			return nil, &ExprError{tok.pos, fmt.Sprintf("invalid number %q", tok.text)}
+		}
+		return &exprNode{op: "num", pos: tok.pos, kind: numberKind, num: n}, nil
+	case tokenIdent:
+		p.index++
+		name := strings.ToLower(tok.text)
+		if !p.known(name) {
+			return nil, &ExprError{tok.pos, fmt.Sprintf("unknown field %q, available fields: %s", tok.text, strings.Join(p.fields, ", "))}
+		}
+		if !p.isUsed(name) {
+			p.used = append(p.used, name)
+		}
+		return &exprNode{op: "field", pos: tok.pos, kind: numberKind, field: name}, nil
+	case tokenOp:
+		if _, ok := p.accept("("); ok {
+			inner, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, &ExprError{p.peek().pos, "missing )"}
+			}
+			return inner, nil
+		}
+	}
+	return nil, &ExprError{tok.pos, fmt.Sprintf("expected a number or field, got %v", tok)}
+}
+
+func (p *exprParser) known(name string) bool {
+	for _, field := range p.fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *exprParser) isUsed(name string) bool {
+	for _, field := range p.used {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"nftsiren/pkg/nft"
+	"nftsiren/pkg/number"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testVars() Vars {
+	stats := &nft.CollectionStats{
+		Time:     time.Now(),
+		Floor:    number.NewFromFloat(1.25),
+		DaySales: number.NewFromInt(30),
+		Listed:   number.NewFromInt(450),
+	}
+	return NewVars(stats, number.NewFromInt(1600), number.NewFromInt(20))
+}
+
+func TestExprEval(t *testing.T) {
+	vars := testVars()
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"floor < 1.3 && listed > 400 && gas < 25", true},
+		{"floor < 1.3 && listed > 500", false},
+		{"floor > 2 || gas <= 20", true},
+		{"!(floor < 1.3)", false},
+		{"FLOOR * eth >= 2000", true},
+		{"floor * eth > 2000", false},
+		{"listed / sales24h == 15", true},
+		{"-floor < 0 && 1 - floor < 0", true},
+		{"(floor + 1) * 2 != 4.5", false},
+		{"floor > 1 && floor < 2 || gas > 100", true},
+	}
+	for _, test := range tests {
+		expr, err := ParseExpr(test.src, CollectionFields...)
+		require.NoError(t, err, test.src)
+		got, err := expr.Eval(vars)
+		require.NoError(t, err, test.src)
+		assert.Equal(t, test.want, got, test.src)
+	}
+}
+
+func TestExprErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		{"", "expression is empty"},
+		{"eth", "expression must be a condition, like floor < 1 at column 1"},
+		{"eth < 1 &&", "expected a number or field, got end of expression at column 11"},
+		{"eth < 1 & gas < 2", `unknown operator '&', did you mean "&&" at column 9`},
+		{"eth = 1", `unknown operator '=', did you mean "==" at column 5`},
+		{"floor < 1", `unknown field "floor", available fields: eth, gas at column 1`},
+		{"eth < 1 && 2", `"&&" needs a condition, got a number at column 12`},
+		{"eth + (gas < 1) > 0", `"+" needs a number, got a condition at column 12`},
+		{"eth < 1 < 2", `unexpected "<" at column 9`},
+		{"(eth < 1", "missing ) at column 9"},
+		{"eth < 1.2.3", `invalid number "1.2.3" at column 7`},
+		{"eth < $5", `unexpected character '$' at column 7`},
+	}
+	for _, test := range tests {
+		_, err := ParseExpr(test.src, EthGasFields...)
+		assert.EqualError(t, err, test.err, test.src)
+	}
+}
+
+func TestExprMissingFields(t *testing.T) {
+	expr, err := ParseExpr("listed > 400 || floor < 1 && floor > 0", CollectionFields...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{FieldListed, FieldFloor}, expr.Fields())
+
+	// Listed is not provided by every marketplace
+	vars := testVars()
+	delete(vars, FieldListed)
+	_, err = expr.Eval(vars)
+	assert.EqualError(t, err, "listed is not available")
+
+	// Short circuit skips missing fields
+	ok, err := EvalExpr("gas > 100 && listed > 400", CollectionFields, vars)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = EvalExpr("floor / (gas - 20) > 1", CollectionFields, testVars())
+	assert.EqualError(t, err, "division by zero")
+}
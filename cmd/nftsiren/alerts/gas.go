@@ -11,6 +11,9 @@ type GasAlertType int
 const (
 	GasAlertTypeLessThan GasAlertType = iota
 	GasAlertTypeGreaterThan
+	GasAlertTypeExpression // Custom expression over EthGasFields
+	GasAlertTypeDroppedBy
+	GasAlertTypeRoseBy
 )
 
 func (t GasAlertType) String() string {
@@ -19,44 +22,139 @@ func (t GasAlertType) String() string {
 		return "Gas Less Than"
 	case GasAlertTypeGreaterThan:
 		return "Gas Greater Than"
+	case GasAlertTypeExpression:
+		return "Expression"
+	case GasAlertTypeDroppedBy:
+		return "Gas Dropped By"
+	case GasAlertTypeRoseBy:
+		return "Gas Rose By"
 	}
 	return "UNKNOWN"
 }
 
 func (t GasAlertType) Label() string {
+	switch t {
+	case GasAlertTypeExpression:
+		return "Expression"
+	case GasAlertTypeDroppedBy, GasAlertTypeRoseBy:
+		return "Change (%)"
+	}
 	return "Gas Price (GWEI)"
 }
 
 func (t GasAlertType) NeedsInterval() bool {
-	return false
+	return t == GasAlertTypeDroppedBy || t == GasAlertTypeRoseBy
+}
+
+// GasTier is the gas price a gas alert follows
+// Zero value is the proposed price which was the only one before tiers
+type GasTier int
+
+const (
+	GasTierPropose GasTier = iota
+	GasTierSafe
+	GasTierFast
+	GasTierBaseFee     // EIP-1559 base fee of the next block
+	GasTierPriorityFee // Proposed price above the base fee
+)
+
+var GasTiers = []GasTier{GasTierSafe, GasTierPropose, GasTierFast, GasTierBaseFee, GasTierPriorityFee}
+
+func (tier GasTier) String() string {
+	switch tier {
+	case GasTierPropose:
+		return "Propose"
+	case GasTierSafe:
+		return "Safe"
+	case GasTierFast:
+		return "Fast"
+	case GasTierBaseFee:
+		return "Base Fee"
+	case GasTierPriorityFee:
+		return "Priority Fee"
+	}
+	return "UNKNOWN"
+}
+
+// Name in descriptions, like GAS or BASE FEE
+func (tier GasTier) symbol() string {
+	switch tier {
+	case GasTierSafe:
+		return "GAS(safe)"
+	case GasTierFast:
+		return "GAS(fast)"
+	case GasTierBaseFee:
+		return "BASE FEE"
+	case GasTierPriorityFee:
+		return "PRIORITY FEE"
+	}
+	return "GAS"
+}
+
+// Name in notifications, like Gas or Base fee
+func (tier GasTier) subject() string {
+	switch tier {
+	case GasTierSafe:
+		return "Safe gas"
+	case GasTierFast:
+		return "Fast gas"
+	case GasTierBaseFee:
+		return "Base fee"
+	case GasTierPriorityFee:
+		return "Priority fee"
+	}
+	return "Gas"
 }
 
 type GasAlert struct {
 	Type GasAlertType  `json:"type" bson:"type"`
 	Base number.Number `json:"base" bson:"base"`
 	Loop bool          `json:"loop" bson:"loop"`
+	Intv int           `json:"interval,omitempty" bson:"interval,omitempty"` // Window of percent change types in seconds
+	Expr string        `json:"expr,omitempty" bson:"expr,omitempty"`         // Only used by expression type
+	Tier GasTier       `json:"tier,omitempty" bson:"tier,omitempty"`         // Followed price, expressions use their own fields
+
+	// Trigger settings and identity, flattened in json
+	Trigger `bson:",inline"`
+	Meta    `bson:",inline"`
 }
 
 func (alert GasAlert) String() string {
-	return fmt.Sprintf("%v:%v:%v", alert.Type, alert.Base, alert.Loop)
+	if alert.Type == GasAlertTypeExpression {
+		return fmt.Sprintf("%v:%s:%v", alert.Type, alert.Expr, alert.Loop)
+	}
+	str := fmt.Sprintf("%v:%v:%v", alert.Type, alert.Base, alert.Loop)
+	if alert.NeedsInterval() {
+		str = fmt.Sprintf("%v:%v:%d:%v", alert.Type, alert.Base, alert.Intv, alert.Loop)
+	}
+	if alert.Tier != GasTierPropose {
+		str += ":" + alert.Tier.String()
+	}
+	return str
 }
 
 func (alert GasAlert) Description() string {
 	switch alert.Type {
 	case GasAlertTypeLessThan:
-		return fmt.Sprintf("Checks for GAS < %v", alert.Base)
+		return fmt.Sprintf("Checks for %s < %v", alert.Tier.symbol(), alert.Base)
 	case GasAlertTypeGreaterThan:
-		return fmt.Sprintf("Checks for GAS > %v", alert.Base)
+		return fmt.Sprintf("Checks for %s > %v", alert.Tier.symbol(), alert.Base)
+	case GasAlertTypeExpression:
+		return fmt.Sprintf("Checks for %s", alert.Expr)
+	case GasAlertTypeDroppedBy:
+		return fmt.Sprintf("Checks for %s drop >= %v%% within %s", alert.Tier.symbol(), alert.Base, FormatInterval(alert.Intv))
+	case GasAlertTypeRoseBy:
+		return fmt.Sprintf("Checks for %s rise >= %v%% within %s", alert.Tier.symbol(), alert.Base, FormatInterval(alert.Intv))
 	}
 	return "UNKNOWN"
 }
 
 func (alert GasAlert) NeedsInterval() bool {
-	return false
+	return alert.Type.NeedsInterval()
 }
 
 func (alert GasAlert) Interval() int {
-	return 0
+	return alert.Intv
 }
 
 func (alert GasAlert) Looping() bool {
@@ -64,22 +162,75 @@ func (alert GasAlert) Looping() bool {
 }
 
 // returns true when check passed
+// Expression alerts always return false, use Eval for them
 func (alert GasAlert) Check(num number.Number) bool {
 	switch alert.Type {
 	case GasAlertTypeLessThan:
 		return num.LessThan(alert.Base)
 	case GasAlertTypeGreaterThan:
 		return num.GreaterThan(alert.Base)
+	case GasAlertTypeDroppedBy, GasAlertTypeRoseBy:
+		// num is the percent change
+		return num.GreaterThanOrEqual(alert.Base)
 	}
 	return false
 }
 
+func (alert GasAlert) Distance(num number.Number) number.Number {
+	switch alert.Type {
+	case GasAlertTypeExpression:
+		return number.Number{}
+	case GasAlertTypeLessThan:
+		return backDistance(num, alert.Base, true)
+	}
+	return backDistance(num, alert.Base, false)
+}
+
 func (alert GasAlert) NotificationText() string {
 	switch alert.Type {
 	case GasAlertTypeLessThan:
-		return fmt.Sprintf("Gas less than %v", alert.Base)
+		return fmt.Sprintf("%s less than %v", alert.Tier.subject(), alert.Base)
 	case GasAlertTypeGreaterThan:
-		return fmt.Sprintf("Gas greater than %v", alert.Base)
+		return fmt.Sprintf("%s greater than %v", alert.Tier.subject(), alert.Base)
+	case GasAlertTypeExpression:
+		return fmt.Sprintf("%s matched", alert.Expr)
+	case GasAlertTypeDroppedBy:
+		return fmt.Sprintf("%s dropped %v%% within %s", alert.Tier.subject(), alert.Base, FormatInterval(alert.Intv))
+	case GasAlertTypeRoseBy:
+		return fmt.Sprintf("%s rose %v%% within %s", alert.Tier.subject(), alert.Base, FormatInterval(alert.Intv))
 	}
 	return "UNKNOWN"
 }
+
+// Eval evaluates the expression of an expression alert
+func (alert GasAlert) Eval(vars Vars) (bool, error) {
+	return EvalExpr(alert.Expr, EthGasFields, vars)
+}
+
+func (alert GasAlert) Condition() Condition {
+	return alert.Type
+}
+
+func (alert GasAlert) Threshold() (number.Number, bool) {
+	return alert.Base, alert.Type != GasAlertTypeExpression
+}
+
+func (alert GasAlert) WithBase(base number.Number) GasAlert {
+	alert.Base = base
+	return alert
+}
+
+func (alert GasAlert) WithLoop(loop bool) GasAlert {
+	alert.Loop = loop
+	return alert
+}
+
+func (alert GasAlert) WithTrigger(trigger Trigger) GasAlert {
+	alert.Trigger = trigger
+	return alert
+}
+
+func (alert GasAlert) WithMeta(meta Meta) GasAlert {
+	alert.Meta = meta
+	return alert
+}
@@ -0,0 +1,31 @@
+package alerts
+
+import (
+	"encoding/json"
+	"testing"
+
+	"nftsiren/pkg/number"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasAlertTier(t *testing.T) {
+	alert := GasAlert{Type: GasAlertTypeLessThan, Base: number.NewFromInt(20)}
+	assert.Equal(t, "Checks for GAS < 20", alert.Description())
+	assert.Equal(t, "Gas less than 20", alert.NotificationText())
+
+	alert.Tier = GasTierBaseFee
+	assert.Equal(t, "Checks for BASE FEE < 20", alert.Description())
+	assert.Equal(t, "Base fee less than 20", alert.NotificationText())
+	alert = GasAlert{Type: GasAlertTypeRoseBy, Base: number.NewFromInt(50), Intv: 3600, Tier: GasTierFast}
+	assert.Equal(t, "Fast gas rose 50% within 1h", alert.NotificationText())
+
+	// Alerts saved before tiers follow the proposed price
+	var old GasAlert
+	require.NoError(t, json.Unmarshal([]byte(`{"type":0,"base":"20","loop":true}`), &old))
+	assert.Equal(t, GasTierPropose, old.Tier)
+	data, err := json.Marshal(old)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "tier")
+}
@@ -0,0 +1,42 @@
+package alerts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Meta identifies an alert or a collection across edits and restarts
+// It is embedded in every alert
+type Meta struct {
+	ID      string `json:"id,omitempty" bson:"id,omitempty"`
+	Created int64  `json:"created,omitempty" bson:"created,omitempty"` // Unix time
+	Updated int64  `json:"updated,omitempty" bson:"updated,omitempty"` // Unix time of the last edit
+}
+
+// NewID returns a random 16 character hex id
+func NewID() string {
+	var b [8]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		// This never happens on supported platforms, fall back to time
+		return hex.EncodeToString([]byte(time.Now().Format("0102150405.00")))[:16]
+	}
+	return hex.EncodeToString(b[:])
+}
+
+func NewMeta() Meta {
+	now := time.Now().Unix()
+	return Meta{ID: NewID(), Created: now, Updated: now}
+}
+
+// This is here for reaching the embedded meta from alerts.Alert
+func (meta Meta) Identity() Meta {
+	return meta
+}
+
+// Touched returns a copy with updated time set to now
+func (meta Meta) Touched() Meta {
+	meta.Updated = time.Now().Unix()
+	return meta
+}
@@ -0,0 +1,33 @@
+package alerts
+
+import (
+	"encoding/json"
+	"testing"
+
+	"nftsiren/pkg/number"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeta(t *testing.T) {
+	a, b := NewID(), NewID()
+	assert.Len(t, a, 16)
+	assert.NotEqual(t, a, b)
+
+	meta := NewMeta()
+	assert.NotZero(t, meta.Created)
+	assert.Equal(t, meta.Created, meta.Updated)
+
+	// Identity survives edits and is flattened in json
+	alert := CollectionAlert{Type: CollectionAlertTypeFloorLessThan, Base: number.NewFromInt(1)}.WithMeta(meta)
+	edited := alert.WithBase(number.NewFromInt(2)).WithLoop(true)
+	assert.Equal(t, meta, edited.Identity())
+	data, err := json.Marshal(edited)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"id":"`+meta.ID+`"`)
+
+	var loaded CollectionAlert
+	require.NoError(t, json.Unmarshal(data, &loaded))
+	assert.Equal(t, meta.ID, loaded.ID)
+}
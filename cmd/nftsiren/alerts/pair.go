@@ -0,0 +1,192 @@
+package alerts
+
+import (
+	"fmt"
+
+	"nftsiren/pkg/nft"
+	"nftsiren/pkg/number"
+)
+
+// Asset is one side of a pair alert, a watched collection or ETH/USD when Symbol is empty
+type Asset struct {
+	Market nft.Marketplace `json:"market" bson:"market"`
+	Symbol string          `json:"symbol,omitempty" bson:"symbol,omitempty"`
+}
+
+var EthAsset = Asset{}
+
+func (asset Asset) IsEth() bool {
+	return asset.Symbol == ""
+}
+
+func (asset Asset) String() string {
+	if asset.IsEth() {
+		return "ETH/USD"
+	}
+	return fmt.Sprintf("%s:%s", asset.Market, asset.Symbol)
+}
+
+// Short name of the compared value, like floor(slug)
+func (asset Asset) Label() string {
+	if asset.IsEth() {
+		return "ETH"
+	}
+	return fmt.Sprintf("floor(%s)", asset.Symbol)
+}
+
+type PairAlertType int
+
+const (
+	PairAlertTypeRatioGreaterThan PairAlertType = iota
+	PairAlertTypeRatioLessThan
+	PairAlertTypeDiffGreaterThan
+	PairAlertTypeDiffLessThan
+)
+
+func (t PairAlertType) String() string {
+	switch t {
+	case PairAlertTypeRatioGreaterThan:
+		return "Ratio Greater Than"
+	case PairAlertTypeRatioLessThan:
+		return "Ratio Less Than"
+	case PairAlertTypeDiffGreaterThan:
+		return "Difference Greater Than"
+	case PairAlertTypeDiffLessThan:
+		return "Difference Less Than"
+	}
+	return "UNKNOWN"
+}
+
+func (t PairAlertType) Label() string {
+	switch t {
+	case PairAlertTypeDiffGreaterThan, PairAlertTypeDiffLessThan:
+		return "Difference (A - B)"
+	}
+	return "Ratio (A / B)"
+}
+
+func (t PairAlertType) NeedsInterval() bool {
+	return false
+}
+
+func (t PairAlertType) isRatio() bool {
+	return t == PairAlertTypeRatioGreaterThan || t == PairAlertTypeRatioLessThan
+}
+
+// PairAlert compares two assets, A is divided by or subtracted from B
+// Collections are compared by floor price in their own currency and ETH by its USD price
+type PairAlert struct {
+	Type PairAlertType `json:"type" bson:"type"`
+	A    Asset         `json:"a" bson:"a"`
+	B    Asset         `json:"b" bson:"b"`
+	Base number.Number `json:"base" bson:"base"`
+	Loop bool          `json:"loop" bson:"loop"`
+
+	// Trigger settings and identity, flattened in json
+	Trigger `bson:",inline"`
+	Meta    `bson:",inline"`
+}
+
+func (alert PairAlert) String() string {
+	return fmt.Sprintf("%v:%v:%v:%v:%v", alert.Type, alert.A, alert.B, alert.Base, alert.Loop)
+}
+
+func (alert PairAlert) operator() string {
+	switch alert.Type {
+	case PairAlertTypeRatioGreaterThan, PairAlertTypeDiffGreaterThan:
+		return ">"
+	}
+	return "<"
+}
+
+// Like floor(a) / floor(b)
+func (alert PairAlert) formula() string {
+	op := "-"
+	if alert.Type.isRatio() {
+		op = "/"
+	}
+	return fmt.Sprintf("%s %s %s", alert.A.Label(), op, alert.B.Label())
+}
+
+func (alert PairAlert) Description() string {
+	return fmt.Sprintf("Checks for %s %s %v", alert.formula(), alert.operator(), alert.Base)
+}
+
+func (alert PairAlert) NeedsInterval() bool {
+	return false
+}
+
+func (alert PairAlert) Interval() int {
+	return 0
+}
+
+func (alert PairAlert) Looping() bool {
+	return alert.Loop
+}
+
+// Involves reports whether asset is one of the sides
+func (alert PairAlert) Involves(asset Asset) bool {
+	return alert.A == asset || alert.B == asset
+}
+
+// Value returns the ratio or difference of the sides, ratio is not ok when b is zero
+func (alert PairAlert) Value(a, b number.Number) (number.Number, bool) {
+	if a.IsNil() || b.IsNil() {
+		return number.Number{}, false
+	}
+	if alert.Type.isRatio() {
+		if b.IsZero() {
+			return number.Number{}, false
+		}
+		return a.Div(b), true
+	}
+	return a.Sub(b), true
+}
+
+// num is the value returned from Value
+func (alert PairAlert) Check(num number.Number) bool {
+	switch alert.Type {
+	case PairAlertTypeRatioGreaterThan, PairAlertTypeDiffGreaterThan:
+		return num.GreaterThan(alert.Base)
+	case PairAlertTypeRatioLessThan, PairAlertTypeDiffLessThan:
+		return num.LessThan(alert.Base)
+	}
+	return false
+}
+
+func (alert PairAlert) Distance(num number.Number) number.Number {
+	lessThan := alert.Type == PairAlertTypeRatioLessThan || alert.Type == PairAlertTypeDiffLessThan
+	return backDistance(num, alert.Base, lessThan)
+}
+
+func (alert PairAlert) NotificationText() string {
+	return fmt.Sprintf("%s %s %v", alert.formula(), alert.operator(), alert.Base)
+}
+
+func (alert PairAlert) Condition() Condition {
+	return alert.Type
+}
+
+func (alert PairAlert) Threshold() (number.Number, bool) {
+	return alert.Base, true
+}
+
+func (alert PairAlert) WithBase(base number.Number) PairAlert {
+	alert.Base = base
+	return alert
+}
+
+func (alert PairAlert) WithLoop(loop bool) PairAlert {
+	alert.Loop = loop
+	return alert
+}
+
+func (alert PairAlert) WithTrigger(trigger Trigger) PairAlert {
+	alert.Trigger = trigger
+	return alert
+}
+
+func (alert PairAlert) WithMeta(meta Meta) PairAlert {
+	alert.Meta = meta
+	return alert
+}
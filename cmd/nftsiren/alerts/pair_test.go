@@ -0,0 +1,39 @@
+package alerts
+
+import (
+	"testing"
+
+	"nftsiren/pkg/nft"
+	"nftsiren/pkg/number"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPairAlert(t *testing.T) {
+	a := Asset{Market: nft.Opensea, Symbol: "azuki"}
+	b := Asset{Market: nft.Looksrare, Symbol: "doodles"}
+	alert := PairAlert{Type: PairAlertTypeRatioGreaterThan, A: a, B: b, Base: number.NewFromFloat(1.5)}
+	assert.Equal(t, "floor(azuki) / floor(doodles) > 1.5", alert.NotificationText())
+	assert.True(t, alert.Involves(b))
+	assert.False(t, alert.Involves(EthAsset))
+
+	value, ok := alert.Value(number.NewFromInt(6), number.NewFromInt(4))
+	assert.True(t, ok)
+	assert.Equal(t, 1.5, value.Float64())
+	assert.False(t, alert.Check(value))
+	value, _ = alert.Value(number.NewFromInt(7), number.NewFromInt(4))
+	assert.True(t, alert.Check(value))
+
+	// Ratio against zero or missing values
+	_, ok = alert.Value(number.NewFromInt(1), number.NewFromInt(0))
+	assert.False(t, ok)
+	_, ok = alert.Value(number.Number{}, number.NewFromInt(1))
+	assert.False(t, ok)
+
+	// Difference against eth
+	alert = PairAlert{Type: PairAlertTypeDiffLessThan, A: a, B: EthAsset, Base: number.NewFromInt(0)}
+	assert.Equal(t, "Checks for floor(azuki) - ETH < 0", alert.Description())
+	value, ok = alert.Value(number.NewFromInt(5), number.NewFromInt(1600))
+	assert.True(t, ok)
+	assert.True(t, alert.Check(value))
+}
@@ -0,0 +1,180 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeRange is a range within a day in minutes since midnight, end is exclusive
+// It wraps past midnight when End is before Start, like 23:00-07:00
+type TimeRange struct {
+	Start int `json:"start" bson:"start"`
+	End   int `json:"end" bson:"end"`
+}
+
+const minutesInDay = 24 * 60
+
+func (r TimeRange) wraps() bool {
+	return r.End < r.Start
+}
+
+func (r TimeRange) Contains(minute int) bool {
+	if r.wraps() {
+		return minute >= r.Start || minute < r.End
+	}
+	return minute >= r.Start && minute < r.End
+}
+
+func (r TimeRange) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", r.Start/60, r.Start%60, r.End/60, r.End%60)
+}
+
+// Parses a clock like 7:30 or 23:00 into minutes since midnight
+func parseClock(s string) (int, error) {
+	var hour, minute int
+	n, err := fmt.Sscanf(s, "%d:%d", &hour, &minute)
+	if err != nil || n != 2 || hour < 0 || hour > 24 || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("invalid time %q, use 24 hour clock like 07:30", s)
+	}
+	return hour*60 + minute, nil
+}
+
+// ParseTimeRanges parses comma separated ranges like 23:00-07:00, 12:00-13:00
+func ParseTimeRanges(s string) ([]TimeRange, error) {
+	var ranges []TimeRange
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		start, end, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid range %q, use start-end like 23:00-07:00", part)
+		}
+		var r TimeRange
+		var err error
+		if r.Start, err = parseClock(strings.TrimSpace(start)); err != nil {
+			return nil, err
+		}
+		if r.End, err = parseClock(strings.TrimSpace(end)); err != nil {
+			return nil, err
+		}
+		// 24:00 is only meaningful as an end
+		r.Start %= minutesInDay
+		if r.Start == r.End {
+			return nil, fmt.Errorf("range %q is empty", part)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func FormatTimeRanges(ranges []TimeRange) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Schedule is a weekly set of time ranges in a time zone
+// Quiet schedules suppress notifications within the ranges, others only allow them within the ranges
+// Zero value allows everything
+type Schedule struct {
+	Quiet    bool           `json:"quiet,omitempty" bson:"quiet,omitempty"`
+	TimeZone string         `json:"timezone,omitempty" bson:"timezone,omitempty"` // IANA name like Europe/Istanbul, empty is local
+	Days     []time.Weekday `json:"days,omitempty" bson:"days,omitempty"`         // Empty is every day
+	Ranges   []TimeRange    `json:"ranges,omitempty" bson:"ranges,omitempty"`     // Empty is all day
+}
+
+func (schedule Schedule) location() *time.Location {
+	if schedule.TimeZone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+func (schedule Schedule) Validate() error {
+	if schedule.TimeZone != "" {
+		if _, err := time.LoadLocation(schedule.TimeZone); err != nil {
+			return fmt.Errorf("unknown time zone %q", schedule.TimeZone)
+		}
+	}
+	for _, day := range schedule.Days {
+		if day < time.Sunday || day > time.Saturday {
+			return errors.New("invalid weekday")
+		}
+	}
+	for _, r := range schedule.Ranges {
+		if r.Start < 0 || r.Start >= minutesInDay || r.End < 0 || r.End > minutesInDay || r.Start == r.End {
+			return fmt.Errorf("invalid range %v", r)
+		}
+	}
+	return nil
+}
+
+func (schedule Schedule) hasDay(day time.Weekday) bool {
+	if len(schedule.Days) == 0 {
+		return true
+	}
+	for _, d := range schedule.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether t is within the ranges of the schedule days
+// The part of a range after midnight belongs to the day it started
+func (schedule Schedule) Matches(t time.Time) bool {
+	t = t.In(schedule.location())
+	if len(schedule.Ranges) == 0 {
+		return schedule.hasDay(t.Weekday())
+	}
+	minute := t.Hour()*60 + t.Minute()
+	for _, r := range schedule.Ranges {
+		if !r.Contains(minute) {
+			continue
+		}
+		day := t.Weekday()
+		if r.wraps() && minute < r.End {
+			day = (day + 6) % 7
+		}
+		if schedule.hasDay(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether notifications can be pushed at t
+func (schedule Schedule) Allows(t time.Time) bool {
+	return schedule.Matches(t) != schedule.Quiet
+}
+
+func (schedule Schedule) String() string {
+	var b strings.Builder
+	if schedule.Quiet {
+		b.WriteString("quiet")
+	} else {
+		b.WriteString("active")
+	}
+	if len(schedule.Ranges) > 0 {
+		b.WriteString(" " + FormatTimeRanges(schedule.Ranges))
+	}
+	if len(schedule.Days) > 0 && len(schedule.Days) < 7 {
+		days := make([]string, len(schedule.Days))
+		for i, day := range schedule.Days {
+			days[i] = day.String()[:3]
+		}
+		b.WriteString(" on " + strings.Join(days, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeRanges(t *testing.T) {
+	ranges, err := ParseTimeRanges("23:00-7:30, 12:00 - 13:00,")
+	require.NoError(t, err)
+	assert.Equal(t, []TimeRange{{23 * 60, 7*60 + 30}, {12 * 60, 13 * 60}}, ranges)
+	assert.Equal(t, "23:00-07:30, 12:00-13:00", FormatTimeRanges(ranges))
+
+	ranges, err = ParseTimeRanges("")
+	assert.NoError(t, err)
+	assert.Empty(t, ranges)
+
+	for _, s := range []string{"23:00", "25:00-01:00", "10:00-10:00", "24:00-00:00", "9am-5pm"} {
+		_, err := ParseTimeRanges(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestScheduleQuietHours(t *testing.T) {
+	schedule := Schedule{
+		Quiet:    true,
+		TimeZone: "UTC",
+		Days:     []time.Weekday{time.Friday, time.Saturday},
+		Ranges:   []TimeRange{{23 * 60, 8 * 60}},
+	}
+	require.NoError(t, schedule.Validate())
+	at := func(day, hour int) time.Time {
+		// 2024-03-01 is a friday
+		return time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC)
+	}
+	assert.True(t, schedule.Allows(at(1, 22)))
+	assert.False(t, schedule.Allows(at(1, 23)))
+	// Early saturday belongs to friday night
+	assert.False(t, schedule.Allows(at(2, 7)))
+	assert.True(t, schedule.Allows(at(2, 8)))
+	// Early monday belongs to sunday which is not in the days
+	assert.True(t, schedule.Allows(at(4, 2)))
+	assert.False(t, schedule.Allows(at(3, 2)))
+
+	// Other time zones
+	schedule.TimeZone = "Europe/Istanbul" // UTC+3
+	assert.False(t, schedule.Allows(at(1, 20)))
+	assert.Equal(t, "quiet 23:00-08:00 on Fri, Sat", schedule.String())
+
+	schedule.TimeZone = "Mars/Olympus"
+	assert.Error(t, schedule.Validate())
+}
+
+func TestScheduleActive(t *testing.T) {
+	// Zero value allows everything
+	assert.True(t, Schedule{}.Allows(time.Now()))
+
+	weekdays := Schedule{Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}}
+	assert.True(t, weekdays.Allows(time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)))
+	assert.False(t, weekdays.Allows(time.Date(2024, 3, 2, 12, 0, 0, 0, time.Local)))
+}
@@ -0,0 +1,138 @@
+package alerts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"nftsiren/pkg/number"
+)
+
+// Cooldown of alerts without their own one
+const DefaultCooldown = 60
+
+// Priority decides how hard an alert tries to reach the user
+type Priority int
+
+const (
+	PriorityNormal   Priority = iota
+	PriorityCritical          // Repeats until acknowledged
+)
+
+func (priority Priority) String() string {
+	switch priority {
+	case PriorityNormal:
+		return "Normal"
+	case PriorityCritical:
+		return "Critical"
+	}
+	return "UNKNOWN"
+}
+
+// Trigger decides when a passed check turns into a notification, it is embedded in every alert
+// Zero value notifies on every passed check with the default cooldown
+type Trigger struct {
+	Cooldown   int           `json:"cooldown,omitempty" bson:"cooldown,omitempty"`     // Seconds between two notifications
+	Hysteresis number.Number `json:"hysteresis,omitempty" bson:"hysteresis,omitempty"` // Re-arms only after the value crosses back by this much
+	Debounce   int           `json:"debounce,omitempty" bson:"debounce,omitempty"`     // Consecutive passed checks needed
+	Schedule   *Schedule     `json:"schedule,omitempty" bson:"schedule,omitempty"`     // Own schedule, applied together with the global one
+	Paused     bool          `json:"paused,omitempty" bson:"paused,omitempty"`         // Paused alerts are not checked
+	Snooze     int64         `json:"snooze,omitempty" bson:"snooze,omitempty"`         // Unix time, no notifications until then
+	Priority   Priority      `json:"priority,omitempty" bson:"priority,omitempty"`
+}
+
+// This is here for reaching the embedded trigger from alerts.Alert
+func (trigger Trigger) Triggering() Trigger {
+	return trigger
+}
+
+func (trigger Trigger) CooldownDuration() time.Duration {
+	if trigger.Cooldown <= 0 {
+		return DefaultCooldown * time.Second
+	}
+	return time.Duration(trigger.Cooldown) * time.Second
+}
+
+func (trigger Trigger) SnoozedAt(t time.Time) bool {
+	return trigger.Snooze > t.Unix()
+}
+
+func (trigger Trigger) hasHysteresis() bool {
+	return !trigger.Hysteresis.IsNil() && !trigger.Hysteresis.IsZero()
+}
+
+// Short summary of the non default settings, like every 5m, 3 checks
+func (trigger Trigger) Summary() string {
+	var parts []string
+	if trigger.Priority == PriorityCritical {
+		parts = append(parts, "critical")
+	}
+	if trigger.Paused {
+		parts = append(parts, "paused")
+	} else if trigger.SnoozedAt(time.Now()) {
+		parts = append(parts, "snoozed until "+time.Unix(trigger.Snooze, 0).Format("Jan 2 15:04"))
+	}
+	if trigger.Cooldown > 0 {
+		parts = append(parts, "every "+FormatInterval(trigger.Cooldown))
+	}
+	if trigger.Debounce > 1 {
+		parts = append(parts, fmt.Sprintf("%d checks", trigger.Debounce))
+	}
+	if trigger.hasHysteresis() {
+		parts = append(parts, fmt.Sprintf("re-arm by %v", trigger.Hysteresis))
+	}
+	if trigger.Schedule != nil {
+		parts = append(parts, trigger.Schedule.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// TriggerState is the runtime state of a trigger
+type TriggerState struct {
+	Last     time.Time // Latest notification
+	Streak   int       // Consecutive passed checks
+	Disarmed bool      // Waiting for the value to cross back by hysteresis
+}
+
+// Step takes the result of a check and reports whether to notify
+// distance is how far the value is on the other side of the threshold, see Alert.Distance
+func (trigger Trigger) Step(state TriggerState, now time.Time, passed bool, distance number.Number) (TriggerState, bool) {
+	if trigger.Paused || trigger.SnoozedAt(now) {
+		state.Streak = 0
+		return state, false
+	}
+	if state.Disarmed {
+		// Alerts without a value re-arm as soon as the condition stops holding
+		if passed || (!distance.IsNil() && distance.LessThan(trigger.Hysteresis)) {
+			return state, false
+		}
+		state.Disarmed = false
+	}
+	if !passed {
+		state.Streak = 0
+		return state, false
+	}
+	state.Streak++
+	if state.Streak < trigger.Debounce {
+		return state, false
+	}
+	if !state.Last.IsZero() && now.Sub(state.Last) < trigger.CooldownDuration() {
+		return state, false
+	}
+	state.Last = now
+	state.Streak = 0
+	state.Disarmed = trigger.hasHysteresis()
+	return state, true
+}
+
+// How far num is from base on the side where the condition doesn't hold
+// lessThan is the direction of the condition, nil num returns nil
+func backDistance(num, base number.Number, lessThan bool) number.Number {
+	if num.IsNil() || base.IsNil() {
+		return number.Number{}
+	}
+	if lessThan {
+		return num.Sub(base)
+	}
+	return base.Sub(num)
+}
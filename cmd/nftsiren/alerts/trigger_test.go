@@ -0,0 +1,97 @@
+package alerts
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"nftsiren/pkg/number"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Checks gas values one per second and returns the indexes of the notifying ones
+func triggerAll(alert GasAlert, values ...int64) []int {
+	var state TriggerState
+	var fired []int
+	begin := time.Now()
+	for i, v := range values {
+		num := number.NewFromInt(v)
+		var ok bool
+		state, ok = alert.Triggering().Step(state, begin.Add(time.Duration(i)*time.Second), alert.Check(num), alert.Distance(num))
+		if ok {
+			fired = append(fired, i)
+		}
+	}
+	return fired
+}
+
+func TestTriggerCooldown(t *testing.T) {
+	alert := GasAlert{Type: GasAlertTypeLessThan, Base: number.NewFromInt(20)}
+	alert.Cooldown = 3
+	assert.Equal(t, []int{0, 3, 6}, triggerAll(alert, 10, 10, 10, 10, 10, 10, 10))
+	// Default cooldown
+	alert.Cooldown = 0
+	assert.Equal(t, []int{0}, triggerAll(alert, 10, 10, 10, 10, 10, 10, 10))
+}
+
+func TestTriggerDebounce(t *testing.T) {
+	alert := GasAlert{Type: GasAlertTypeLessThan, Base: number.NewFromInt(20)}
+	alert.Cooldown = 1
+	alert.Debounce = 3
+	// Streak is broken by 25
+	assert.Equal(t, []int{5, 8}, triggerAll(alert, 10, 10, 25, 10, 10, 10, 10, 10, 10))
+}
+
+func TestTriggerHysteresis(t *testing.T) {
+	alert := GasAlert{Type: GasAlertTypeGreaterThan, Base: number.NewFromInt(50)}
+	alert.Cooldown = 1
+	alert.Hysteresis = number.NewFromInt(10)
+	// 45 is not back by 10, 40 re-arms
+	assert.Equal(t, []int{0, 5}, triggerAll(alert, 60, 60, 45, 60, 40, 55))
+
+	// Expressions re-arm when the condition stops holding
+	alert = GasAlert{Type: GasAlertTypeExpression}
+	alert.Hysteresis = number.NewFromInt(10)
+	assert.True(t, alert.Distance(number.NewFromInt(1)).IsNil())
+}
+
+func TestTriggerJSON(t *testing.T) {
+	// Old configs have no trigger fields
+	var alert EthereumAlert
+	require.NoError(t, json.Unmarshal([]byte(`{"type":0,"base":"1500","loop":true}`), &alert))
+	assert.Equal(t, Trigger{}, alert.Trigger)
+
+	alert.Cooldown = 300
+	alert.Debounce = 2
+	data, err := json.Marshal(alert)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"cooldown":300,`)
+	assert.Contains(t, string(data), `"debounce":2`)
+	assert.Equal(t, "every 5m, 2 checks", alert.Summary())
+}
+
+func TestTriggerPauseSnooze(t *testing.T) {
+	alert := GasAlert{Type: GasAlertTypeLessThan, Base: number.NewFromInt(20)}
+	alert.Cooldown = 1
+	paused := alert.WithTrigger(Trigger{Cooldown: 1, Paused: true})
+	assert.Empty(t, triggerAll(paused, 10, 10, 10))
+	assert.Equal(t, "paused, every 1s", paused.Summary())
+	paused.Priority = PriorityCritical
+	assert.Equal(t, "critical, paused, every 1s", paused.Summary())
+
+	// Snoozed for the first two seconds of triggerAll
+	snoozed := alert.WithTrigger(Trigger{Cooldown: 1, Snooze: time.Now().Add(2 * time.Second).Unix()})
+	fired := triggerAll(snoozed, 10, 10, 10, 10)
+	assert.NotEmpty(t, fired)
+	assert.GreaterOrEqual(t, fired[0], 1)
+
+	// Editing keeps the original untouched
+	edited := alert.WithBase(number.NewFromInt(30)).WithLoop(true)
+	assert.Equal(t, "20", alert.Base.String())
+	assert.True(t, edited.Loop)
+	base, ok := edited.Threshold()
+	assert.True(t, ok)
+	assert.Equal(t, "30", base.String())
+}
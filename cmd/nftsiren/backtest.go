@@ -0,0 +1,32 @@
+package main
+
+import (
+	"nftsiren/cmd/nftsiren/alertengine"
+	"nftsiren/cmd/nftsiren/alerts"
+)
+
+// Replays the alert over the recorded stats of the collection
+func (collection *Collection) Backtest(handle alerts.CollectionAlert) alertengine.Backtest {
+	recorded := collection.Daemon.GasTracker.recorded()
+	recorded.History = collection.history.Samples()
+	recorded.Baseline = collection.baseline.Samples()
+	return alertengine.BacktestCollection(handle, recorded)
+}
+
+// Recorded eth and gas prices
+func (tracker *GasTracker) recorded() alertengine.Recorded {
+	return alertengine.Recorded{
+		Eth: tracker.ethHistory.Samples(),
+		Gas: tracker.gasHistory.Samples(),
+	}
+}
+
+// Replays the alert over the recorded eth prices
+func (tracker *GasTracker) BacktestEth(handle alerts.EthereumAlert) alertengine.Backtest {
+	return alertengine.BacktestEth(handle, tracker.recorded())
+}
+
+// Replays the alert over the recorded gas prices
+func (tracker *GasTracker) BacktestGas(handle alerts.GasAlert) alertengine.Backtest {
+	return alertengine.BacktestGas(handle, tracker.recorded())
+}
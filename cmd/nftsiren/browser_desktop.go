@@ -0,0 +1,28 @@
+//go:build !android && !ios
+
+package main
+
+import (
+	"os/exec"
+	"runtime"
+)
+
+// Opens the url in the default browser
+func OpenURL(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	case "darwin":
+		cmd = exec.Command("open", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+	// Don't leave a zombie behind
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build android || ios
+
+package main
+
+import "errors"
+
+func OpenURL(url string) error {
+	return errors.New("opening links is not supported on mobile yet")
+}
@@ -7,15 +7,18 @@ import (
 	"net/url"
 	"time"
 
+	"nftsiren/cmd/nftsiren/alertengine"
 	"nftsiren/cmd/nftsiren/alerts"
 	"nftsiren/cmd/nftsiren/cache"
 	"nftsiren/cmd/nftsiren/widgets"
 	"nftsiren/pkg/apis"
+	"nftsiren/pkg/apis/opensea"
 	"nftsiren/pkg/images"
 	"nftsiren/pkg/log"
 	"nftsiren/pkg/mutex"
 	"nftsiren/pkg/nft"
 	"nftsiren/pkg/number"
+	"nftsiren/pkg/window"
 	"nftsiren/pkg/worker"
 
 	"gioui.org/layout"
@@ -29,14 +32,25 @@ type Collection struct {
 	Daemon *Daemon
 	Market mutex.Value[nft.Marketplace] // Which marketplace this collection will be fetched from
 	Symbol mutex.Value[string]          // Unique id of this collection, maybe contract address
+	meta   mutex.Value[alerts.Meta]     // Our own id and timestamps, persisted
 	// worker runs the given func constantly in given period
 	worker *worker.Worker
 	// alerts of this collection
-	alerts *AlertList
+	alerts *alertengine.List
 	// updated at runtime
 	err   mutex.Value[error]                // Whether an error happened while fetching this collection
 	info  mutex.Value[*nft.Collection]      // We only need to fetch this first time
 	stats mutex.Value[*nft.CollectionStats] // This will be updated on every check
+	// health of the stats, see Health
+	statsErr   mutex.Value[error]     // Last stats fetching error, nil after a successful update
+	lastUpdate mutex.Value[time.Time] // Last successful update, or the start time until the first one
+	// recent stats for percent change alerts, updated with stats
+	history *window.Window[nft.CollectionStats]
+	// coarse and longer history for spike alerts
+	baseline *window.Window[nft.CollectionStats]
+	// stream state
+	lastPoll  mutex.Value[time.Time] // Zero forces the next worker run to fetch
+	floorItem mutex.Value[string]    // Nft id of the floor listing if it is known from the stream
 	// gui stuff
 	img    mutex.Value[*widgets.Icon] // May be nil on error or while loading
 	imgErr mutex.Value[error]         // Image fetching or parsing error
@@ -49,13 +63,25 @@ type Collection struct {
 	statsState     CollectionStatsState
 }
 
+// Percent change alerts can look back this far
+const historyLength = time.Hour * 24
+
+// Spike alerts compare with the average of this long, one sample per resolution
+const (
+	baselineLength     = time.Hour * 24 * 7
+	baselineResolution = time.Minute * 15
+)
+
 func NewCollection(daemon *Daemon, market nft.Marketplace, slug string) *Collection {
 	collection := &Collection{
-		Daemon: daemon,
-		alerts: new(AlertList),
+		Daemon:   daemon,
+		alerts:   new(alertengine.List),
+		history:  window.New[nft.CollectionStats](historyLength, time.Second*10),
+		baseline: window.New[nft.CollectionStats](baselineLength, baselineResolution),
 	}
 	collection.Market.Store(market)
 	collection.Symbol.Store(slug)
+	collection.meta.Store(alerts.NewMeta())
 	collection.worker = worker.New(worker.Settings{
 		Name:        collection.String(),
 		Interval:    time.Minute,
@@ -68,29 +94,63 @@ func NewCollection(daemon *Daemon, market nft.Marketplace, slug string) *Collect
 	// alertList initialization
 	collection.alertListState.Title = "Alerts"
 	collection.alertListState.AlertCreationPage = NewAlertCreationPage("New Collection Alert",
-		func(t alerts.Condition, n number.Number, loop bool) {
-			params := alerts.CollectionAlert{
-				Type: t.(alerts.CollectionAlertType),
-				Base: n,
-				Loop: loop,
-			}
-			alert := NewCollectionAlert(params, collection)
+		func(input AlertInput) {
+			alert := NewCollectionAlert(collectionAlertParams("", input), collection)
 			go collection.AddAlert(alert) // TODO: we may not need to go
 		},
 		[]alerts.Condition{
 			alerts.CollectionAlertTypeFloorLessThan,
 			alerts.CollectionAlertTypeFloorGreaterThan,
 			alerts.CollectionAlertTypeSalesGreaterThan,
+			alerts.CollectionAlertTypeFloorDroppedBy,
+			alerts.CollectionAlertTypeFloorRoseBy,
+			alerts.CollectionAlertTypeVolumeSpike,
+			alerts.CollectionAlertTypeSalesSpike,
+			alerts.CollectionAlertTypeListedGreaterThan,
+			alerts.CollectionAlertTypeListedRoseBy,
+			alerts.CollectionAlertTypeOwnersDroppedBy,
+			alerts.CollectionAlertTypeTrailingStop,
+			alerts.CollectionAlertTypeBand,
 		}...,
 	)
+	collection.alertListState.AlertCreationPage.EnableExpressions(alerts.CollectionFields, func(expr string, input AlertInput) {
+		alert := NewCollectionAlert(collectionAlertParams(expr, input), collection)
+		go collection.AddAlert(alert)
+	})
+	collection.alertListState.AlertCreationPage.EnableBacktest(func(expr string, input AlertInput) alertengine.Backtest {
+		return collection.Backtest(collectionAlertParams(expr, input))
+	})
 	return collection
 }
 
+// Builds the alert from the creation page input, expr is only set in advanced mode
+func collectionAlertParams(expr string, input AlertInput) alerts.CollectionAlert {
+	if expr != "" {
+		return alerts.CollectionAlert{Type: alerts.CollectionAlertTypeExpression, Expr: expr, Loop: input.Loop, Trigger: input.Trigger}
+	}
+	return alerts.CollectionAlert{
+		Type:    input.Type.(alerts.CollectionAlertType),
+		Base:    input.Value,
+		High:    input.High,
+		Intv:    input.Interval,
+		Loop:    input.Loop,
+		Trigger: input.Trigger,
+	}
+}
+
 func (collection *Collection) Start() {
+	collection.lastUpdate.Store(time.Now())
+	if collection.Market.Load() == nft.Opensea {
+		collection.Daemon.OpenseaStream.Subscribe(collection.Symbol.Load(), collection.HandleStreamEvent)
+	}
+	collection.Daemon.Samples.Restore(collection)
 	collection.worker.Start()
 }
 
 func (collection *Collection) Stop() {
+	if collection.Market.Load() == nft.Opensea {
+		collection.Daemon.OpenseaStream.Unsubscribe(collection.Symbol.Load())
+	}
 	collection.worker.Stop()
 }
 
@@ -98,6 +158,39 @@ func (collection *Collection) String() string {
 	return fmt.Sprintf("Collection(%s:%s)", collection.Market.Load(), collection.Symbol.Load())
 }
 
+func (collection *Collection) ID() string {
+	return collection.meta.Load().ID
+}
+
+func (collection *Collection) Meta() alerts.Meta {
+	return collection.meta.Load()
+}
+
+// Last error of fetching the collection or its stats
+func (collection *Collection) LastError() error {
+	if err := collection.err.Load(); err != nil {
+		return err
+	}
+	return collection.statsErr.Load()
+}
+
+// Reports whether there was no successful update within d before now
+func (collection *Collection) StaleAt(now time.Time, d time.Duration) bool {
+	last := collection.lastUpdate.Load()
+	return !last.IsZero() && now.Sub(last) > d
+}
+
+// Stale uses the timeout of the system alerts, it is never stale when they are disabled
+func (collection *Collection) Stale() bool {
+	timeout := collection.Daemon.Health.CollectionTimeout()
+	return timeout > 0 && collection.StaleAt(time.Now(), timeout)
+}
+
+// Side of pair alerts referring to this collection
+func (collection *Collection) Asset() alerts.Asset {
+	return alerts.Asset{Market: collection.Market.Load(), Symbol: collection.Symbol.Load()}
+}
+
 // This will return the slug if collection name is not known yet
 func (collection *Collection) Name() (string, bool) {
 	info := collection.info.Load()
@@ -118,6 +211,16 @@ func (collection *Collection) Floor() (number.Number, bool) {
 	return stats.Floor, true
 }
 
+// Latest stats, nil until they are fetched
+func (collection *Collection) Stats() *nft.CollectionStats {
+	return collection.stats.Load()
+}
+
+// Expression variables with the latest stats
+func (collection *Collection) Vars() alerts.Vars {
+	return collection.Daemon.ExprVars(collection.stats.Load())
+}
+
 func (collection *Collection) HasValidInfo() bool {
 	info := collection.info.Load()
 	if info == nil {
@@ -135,6 +238,52 @@ func (collection *Collection) HasValidStats() bool {
 	return stats.IsValid()
 }
 
+// Stores stats and records them in history
+func (collection *Collection) setStats(stats *nft.CollectionStats) {
+	collection.stats.Store(stats)
+	collection.statsErr.Store(nil)
+	collection.lastUpdate.Store(time.Now())
+	collection.history.Add(stats.Time, *stats)
+	collection.baseline.Add(stats.Time, *stats)
+}
+
+// How many times the latest 24h volume and sales are of their average within the last d
+func (collection *Collection) VolumeSpike(d time.Duration) (number.Number, bool) {
+	return alertengine.BaselineRatio(collection.baseline.Since(time.Now().Add(-d)), d, alertengine.DayVolumeStat)
+}
+
+func (collection *Collection) SalesSpike(d time.Duration) (number.Number, bool) {
+	return alertengine.BaselineRatio(collection.baseline.Since(time.Now().Add(-d)), d, alertengine.DaySalesStat)
+}
+
+// Percent drop and rise of a stat within the last d
+func (collection *Collection) StatChange(d time.Duration, stat func(nft.CollectionStats) number.Number) (drop, rise number.Number, ok bool) {
+	return window.PercentChange(collection.history, time.Now().Add(-d), stat)
+}
+
+// Percent change of the floor within the history, negative when it fell
+func (collection *Collection) FloorChange() (number.Number, bool) {
+	var first, latest number.Number
+	for _, sample := range collection.history.Samples() {
+		if sample.Value.Floor.IsNil() {
+			continue
+		}
+		if first.IsNil() {
+			first = sample.Value.Floor
+		}
+		latest = sample.Value.Floor
+	}
+	if first.IsNil() || first.IsZero() {
+		return number.Number{}, false
+	}
+	return latest.Sub(first).Div(first).MulInt64(100), true
+}
+
+// Collection info from the marketplace, nil until it is fetched
+func (collection *Collection) Info() *nft.Collection {
+	return collection.info.Load()
+}
+
 func (collection *Collection) NumAlerts() int {
 	return collection.alerts.Len()
 }
@@ -147,11 +296,85 @@ func (collection *Collection) RemoveAlert(alert *Alert[alerts.CollectionAlert])
 	return collection.alerts.Remove(alert)
 }
 
+// Stream keeps opensea collections up to date, they are only polled occasionally to reconcile
+const streamPollInterval = time.Minute * 10
+
 func (collection *Collection) FetchAndCheck() {
+	if collection.streaming() && time.Since(collection.lastPoll.Load()) < streamPollInterval {
+		return
+	}
+	collection.lastPoll.Store(time.Now())
+	collection.floorItem.Store("")
 	collection.Fetch()
 	collection.Check()
 }
 
+// Whether stream events are received for this collection, polling is used otherwise
+func (collection *Collection) streaming() bool {
+	return collection.Market.Load() == nft.Opensea && collection.Daemon.OpenseaStream.Subscribed(collection.Symbol.Load())
+}
+
+// Fetches as soon as possible even if the collection is streaming
+func (collection *Collection) refresh() {
+	collection.lastPoll.Store(time.Time{})
+	collection.worker.Trigger()
+}
+
+// Applies opensea stream events to the stats and checks alerts without waiting for the interval
+// Events run on the worker so they don't race with fetching
+func (collection *Collection) HandleStreamEvent(event opensea.StreamEvent) {
+	if !collection.worker.Post(func() { collection.applyStreamEvent(event) }) {
+		// Too many events are waiting, fetching catches up with them
+		collection.refresh()
+	}
+}
+
+func (collection *Collection) applyStreamEvent(event opensea.StreamEvent) {
+	stats := collection.stats.Load()
+	if stats == nil || !stats.IsValid() {
+		// Wait for the first fetch
+		return
+	}
+	// Floor and volume are in ether, other payment tokens are not comparable
+	inEther := !event.Price.IsNil() && (event.Currency == "ETH" || event.Currency == "WETH")
+	switch event.Type {
+	case opensea.ItemListed:
+		if !inEther || !event.Price.LessThan(stats.Floor) {
+			return
+		}
+		log.Debug().Println(collection, "floor dropped to", event.Price, "by", event.NftID)
+		updated := *stats
+		updated.Time = time.Now()
+		updated.Floor = event.Price
+		collection.setStats(&updated)
+		collection.floorItem.Store(event.NftID)
+		collection.Check()
+		RefreshWindowChan <- struct{}{}
+	case opensea.ItemSold:
+		if inEther {
+			updated := *stats
+			updated.Time = time.Now()
+			if !updated.DaySales.IsNil() {
+				updated.DaySales = updated.DaySales.AddInt64(1)
+			}
+			if !updated.DayVolume.IsNil() {
+				updated.DayVolume = updated.DayVolume.Add(event.Price)
+			}
+			collection.setStats(&updated)
+			collection.Check()
+			RefreshWindowChan <- struct{}{}
+		}
+		// Floor item is gone, new floor is only known by fetching
+		if event.NftID == collection.floorItem.Load() || (inEther && event.Price.LessThanOrEqual(stats.Floor)) {
+			collection.refresh()
+		}
+	case opensea.ItemCancelled:
+		if event.NftID == collection.floorItem.Load() {
+			collection.refresh()
+		}
+	}
+}
+
 // Fetches full collection if it is not fetched already,
 // Otherwise only fetches the collection stats
 func (collection *Collection) Fetch() {
@@ -268,7 +491,7 @@ func (collection *Collection) FetchStats() {
 	info := collection.info.Load()
 	assert(info != nil, "collection info must not nil here")
 	if info.Stats != nil && info.Stats.IsValid() && info.Stats.IsRecent(time.Minute) {
-		collection.stats.Store(info.Stats)
+		collection.setStats(info.Stats)
 		// Free info.Stats otherwise we have to check this everytime
 		info.Stats = nil
 		return
@@ -277,19 +500,21 @@ func (collection *Collection) FetchStats() {
 	stats, err := apis.FetchCollectionStats(collection.Market.Load(), collection.Symbol.Load())
 	if err != nil {
 		log.Warn().Println("Failed to fetch", collection, "stats:", err)
+		collection.statsErr.Store(err)
 		return
 	}
 	if !stats.IsValid() {
 		log.Warn().Printf("%v stats is not valid %+v", collection, stats)
+		collection.statsErr.Store(errors.New("invalid stats"))
 		return
 	}
-	collection.stats.Store(&stats)
+	collection.setStats(&stats)
 }
 
 func (collection *Collection) Check() {
-	collection.alerts.ForEach(func(index int, alert alerts.Alert) {
-		alert.Check(number.Number{})
-	})
+	collection.alerts.CheckAll()
+	collection.Daemon.CheckPairAlerts(collection.Asset())
+	collection.Daemon.CheckCompoundAlerts(collection.Asset())
 }
 
 // This is for implementing ChildPage
@@ -364,6 +589,16 @@ func (collection *Collection) Layout(gtx layout.Context, theme *Theme, pages *Pa
 			})
 		})
 	*/
+	// Stale warning
+	if collection.Stale() {
+		items = append(items, func(gtx layout.Context) layout.Dimensions {
+			minutes := int(time.Since(collection.lastUpdate.Load()).Minutes())
+			staleLabel := material.Body2(theme.Material(), fmt.Sprintf("No successful update for %d minutes, stats may be outdated", minutes))
+			staleLabel.Alignment = text.Middle
+			staleLabel.Color = theme.Error
+			return layout.Center.Layout(gtx, staleLabel.Layout)
+		})
+	}
 	// Error message
 	if collection.err.Load() != nil {
 		items = append(items, func(gtx layout.Context) layout.Dimensions {
@@ -529,6 +764,17 @@ func (collection *Collection) layoutMinimalHeader(gtx layout.Context, theme *The
 			title.MaxLines = 1
 			return title.Layout(gtx)
 		}),
+		// Stale state, old floor is still shown but it can't be trusted
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if !collection.Stale() {
+				return layout.Dimensions{}
+			}
+			return layout.Inset{Left: theme.MediumSpace}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				label := material.Overline(theme.Material(), "Stale")
+				label.Color = theme.Error
+				return label.Layout(gtx)
+			})
+		}),
 	)
 }
 
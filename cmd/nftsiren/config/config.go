@@ -123,3 +123,8 @@ func Save() error {
 	encoder.SetIndent("", "\t")
 	return encoder.Encode(state.Config)
 }
+
+// Dir returns the folder of the preferences file, other data files can be kept in it
+func Dir() string {
+	return filepath.Dir(state.Path.Load())
+}
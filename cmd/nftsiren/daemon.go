@@ -3,17 +3,23 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime/debug"
 	"sync"
 	"time"
 
+	"nftsiren/cmd/nftsiren/alertengine"
 	"nftsiren/cmd/nftsiren/alerts"
 	"nftsiren/cmd/nftsiren/config"
+	"nftsiren/cmd/nftsiren/history"
+	"nftsiren/cmd/nftsiren/notifier"
+	"nftsiren/pkg/apis"
 	"nftsiren/pkg/apis/etherscan"
 	"nftsiren/pkg/apis/looksrare"
 	"nftsiren/pkg/apis/magiceden"
 	"nftsiren/pkg/apis/opensea"
 	"nftsiren/pkg/bench"
+	"nftsiren/pkg/httpclient"
 	"nftsiren/pkg/log"
 	"nftsiren/pkg/nft"
 	"nftsiren/pkg/number"
@@ -24,22 +30,50 @@ import (
 type Daemon struct {
 	// This constantly fetches current ethereum price and gas
 	GasTracker *GasTracker
+	// Receives opensea events in real time, collections subscribe to it
+	OpenseaStream *opensea.Stream
+	// Sends notifications to every channel, desktop notifications are one of them
+	Notifier *notifier.Dispatcher
+	// Every alert notification goes through it, holds them during quiet hours
+	QuietHours *QuietHours
+	// Every fired alert is recorded here for the notification center
+	History *history.Store
+	// Sends system alerts when collections or the gas tracker stop updating
+	Health *Health
+	// Repeats critical alerts until they are acknowledged
+	Escalations *Escalations
+	// Keeps the baselines of the collections over restarts
+	Samples *Samples
 	// This will check ethereum and gas alerts every 10 second
 	EthGasChecker *worker.Worker
-	ethAlerts     *AlertList // *Alert[alerts.EthereumAlert]
-	gasAlerts     *AlertList // *Alert[alerts.GasAlert]
+	ethAlerts     *alertengine.List // *Alert[alerts.EthereumAlert]
+	gasAlerts     *alertengine.List // *Alert[alerts.GasAlert]
+	// Pair alerts span collections, they are checked whenever either side updates
+	pairAlerts *alertengine.List // *Alert[alerts.PairAlert]
+	// Compound alerts are checked whenever one of their sources updates
+	compoundAlerts *alertengine.List // *Alert[alerts.CompoundAlert]
 	// Collections has their own workers and they are responsible for checking their alerts
 	collectionsMutex sync.RWMutex
 	collections      []*Collection
 }
 
 func NewDaemon() *Daemon {
+	dispatcher := notifier.NewDispatcher(DesktopNotifier)
 	daemon := &Daemon{
-		GasTracker:  NewGasTracker(),
-		ethAlerts:   new(AlertList),
-		gasAlerts:   new(AlertList),
-		collections: make([]*Collection, 0),
+		Notifier:       dispatcher,
+		GasTracker:     NewGasTracker(),
+		OpenseaStream:  opensea.NewStream(opensea.DefaultStreamURL),
+		QuietHours:     NewQuietHours(dispatcher),
+		History:        history.New(filepath.Join(config.Dir(), "history.json"), config.LoadFallback("historyRetention", history.DefaultRetention)),
+		ethAlerts:      new(alertengine.List),
+		gasAlerts:      new(alertengine.List),
+		pairAlerts:     new(alertengine.List),
+		compoundAlerts: new(alertengine.List),
+		collections:    make([]*Collection, 0),
 	}
+	daemon.Health = NewHealth(daemon)
+	daemon.Escalations = NewEscalations(daemon)
+	daemon.Samples = NewSamples(daemon, filepath.Join(config.Dir(), "samples.json"))
 	daemon.EthGasChecker = worker.New(worker.Settings{
 		Name:        "Eth&GasChecker",
 		Interval:    time.Second * 10,
@@ -47,24 +81,50 @@ func NewDaemon() *Daemon {
 		InitialRun:  true,
 		PanicHanler: ReportPanic,
 	})
+	daemon.History.OnChange = func() {
+		RefreshWindowChan <- struct{}{}
+	}
 	return daemon
 }
 
 func (daemon *Daemon) Start() error {
+	daemon.ResetNetwork()
 	daemon.ResetApiKeys()
+	daemon.ResetWebhook()
+	daemon.ResetDiscord()
+	// Loads notification settings and summarizes the held ones after quiet hours
+	daemon.QuietHours.Start()
+	// Continues repeating the critical alerts which weren't acknowledged
+	daemon.Escalations.Start()
+	// Load fired alerts, old ones are dropped by the retention
+	err := daemon.History.Load()
+	if err != nil {
+		log.Error().Println("Failed to load alert history:", err)
+	}
+	// Restores the recorded prices, collections restore their own samples when they start
+	daemon.Samples.Load()
 	// Start gas tracker
 	daemon.GasTracker.Start()
+	// Connects when there is an api key and an opensea collection
+	daemon.OpenseaStream.Start()
 	// Start checking ethereum and gas alarms
 	daemon.EthGasChecker.Start()
 	// Load everything from user configuration saved in our servers
 	daemon.LoadConfig()
+	daemon.Samples.Start()
+	// Watch for stale data after the collections are loaded
+	daemon.Health.Start()
 	log.Debug().Println("Daemon started")
 	return nil
 }
 
 func (daemon *Daemon) Stop() {
+	daemon.Health.Stop()
+	daemon.Escalations.Stop()
 	daemon.GasTracker.Stop()
 	daemon.EthGasChecker.Stop()
+	daemon.OpenseaStream.Stop()
+	daemon.QuietHours.Stop()
 	// Stop every collection worker
 	daemon.collectionsMutex.RLock()
 	for _, c := range daemon.collections {
@@ -72,6 +132,7 @@ func (daemon *Daemon) Stop() {
 	}
 	daemon.collectionsMutex.RUnlock()
 	// Now save everything
+	daemon.Samples.Stop()
 	daemon.SaveConfig()
 	log.Debug().Println("Daemon stopped")
 }
@@ -82,11 +143,68 @@ func (daemon *Daemon) ResetApiKeys() {
 	if err == nil {
 		etherscan.SetApiKey(keys.Etherscan)
 		opensea.SetApiKey(keys.Opensea)
+		daemon.OpenseaStream.SetToken(keys.Opensea)
 		looksrare.SetApiKey(keys.Looksrare)
 		magiceden.SetApiKey(keys.Magiceden)
 	}
 }
 
+// Adds the webhook channel when it is enabled, removes it otherwise
+func (daemon *Daemon) ResetWebhook() {
+	settings := config.LoadFallback("webhook", notifier.WebhookSettings{})
+	if !settings.Enabled {
+		daemon.Notifier.Remove("Webhook")
+		return
+	}
+	webhook, err := notifier.NewWebhook(settings)
+	if err != nil {
+		log.Error().Println("Invalid webhook settings:", err)
+		daemon.Notifier.Remove("Webhook")
+		return
+	}
+	if settings.Escalation {
+		daemon.Notifier.SetEscalation(webhook)
+	} else {
+		daemon.Notifier.Set(webhook)
+	}
+}
+
+// Adds the discord channel when it is enabled, removes it otherwise
+func (daemon *Daemon) ResetDiscord() {
+	settings := config.LoadFallback("discord", notifier.DiscordSettings{})
+	if !settings.Enabled {
+		daemon.Notifier.Remove("Discord")
+		return
+	}
+	discord, err := notifier.NewDiscord(settings, NAME)
+	if err != nil {
+		log.Error().Println("Invalid discord settings:", err)
+		daemon.Notifier.Remove("Discord")
+		return
+	}
+	if settings.Escalation {
+		daemon.Notifier.SetEscalation(discord)
+	} else {
+		daemon.Notifier.Set(discord)
+	}
+}
+
+// Applies saved proxy, certificate and user agent settings to all outbound traffic
+// and points the providers to the mock server if there is one
+func (daemon *Daemon) ResetNetwork() {
+	settings := config.LoadFallback("network", NetworkSettings{})
+	err := httpclient.Configure(settings.Transport())
+	if err != nil {
+		log.Error().Println("Failed to configure network:", err)
+	}
+	err = apis.SetMockServer(settings.MockServer)
+	if err != nil {
+		log.Error().Println("Failed to set mock server:", err)
+	} else if settings.MockServer != "" {
+		log.Warn().Println("Using mock server:", settings.MockServer)
+	}
+}
+
 // This will be called when ui is going to background
 // Currently releases images of all collections
 func (daemon *Daemon) ReleaseResources() {
@@ -111,20 +229,96 @@ func (daemon *Daemon) ReloadResources() {
 }
 
 func (daemon *Daemon) CheckEthAndGasAlerts() {
-	daemon.ethAlerts.ForEach(func(index int, alert alerts.Alert) {
-		alert.Check(number.Number{})
+	daemon.ethAlerts.CheckAll()
+	daemon.gasAlerts.CheckAll()
+	daemon.CheckPairAlerts(alerts.EthAsset)
+	daemon.CheckCompoundAlerts(alerts.EthAsset)
+}
+
+// Checks the pair alerts having asset as one of the sides
+func (daemon *Daemon) CheckPairAlerts(asset alerts.Asset) {
+	daemon.pairAlerts.ForEach(func(index int, alert alerts.Alert) {
+		if alert.(*Alert[alerts.PairAlert]).Handle().Involves(asset) {
+			alert.Check(number.Number{})
+		}
 	})
-	daemon.gasAlerts.ForEach(func(index int, alert alerts.Alert) {
-		alert.Check(number.Number{})
+}
+
+// Checks the compound alerts reading asset, EthAsset stands for both eth and gas
+func (daemon *Daemon) CheckCompoundAlerts(asset alerts.Asset) {
+	daemon.compoundAlerts.ForEach(func(index int, alert alerts.Alert) {
+		if alert.(*Alert[alerts.CompoundAlert]).Handle().Involves(asset) {
+			alert.Check(number.Number{})
+		}
 	})
 }
 
+// Current value of a pair alert side, eth price or floor of a watched collection
+func (daemon *Daemon) AssetValue(asset alerts.Asset) (number.Number, bool) {
+	if asset.IsEth() {
+		if !daemon.GasTracker.EthStillValid() {
+			return number.Number{}, false
+		}
+		return daemon.GasTracker.GetEth(), true
+	}
+	daemon.collectionsMutex.RLock()
+	defer daemon.collectionsMutex.RUnlock()
+	for _, c := range daemon.collections {
+		if c.Asset() == asset {
+			return c.Floor()
+		}
+	}
+	return number.Number{}, false
+}
+
+// Watched collection of the asset, nil if it is not watched
+func (daemon *Daemon) Collection(asset alerts.Asset) *Collection {
+	daemon.collectionsMutex.RLock()
+	defer daemon.collectionsMutex.RUnlock()
+	for _, c := range daemon.collections {
+		if c.Asset() == asset {
+			return c
+		}
+	}
+	return nil
+}
+
+// Watched collections as assets, for conditions of compound alerts
+func (daemon *Daemon) CollectionAssets() []alerts.Asset {
+	return daemon.Assets()[1:]
+}
+
+// Assets which can be compared by pair alerts, eth is always the first
+func (daemon *Daemon) Assets() []alerts.Asset {
+	daemon.collectionsMutex.RLock()
+	defer daemon.collectionsMutex.RUnlock()
+	assets := make([]alerts.Asset, 0, len(daemon.collections)+1)
+	assets = append(assets, alerts.EthAsset)
+	for _, c := range daemon.collections {
+		assets = append(assets, c.Asset())
+	}
+	return assets
+}
+
+// Variables for alert expressions, eth and gas are missing while they are outdated
+func (daemon *Daemon) ExprVars(stats *nft.CollectionStats) alerts.Vars {
+	var eth, gas number.Number
+	if daemon.GasTracker.EthStillValid() {
+		eth = daemon.GasTracker.GetEth()
+	}
+	if daemon.GasTracker.GasStillValid() {
+		gas = daemon.GasTracker.GetGas()
+	}
+	return alerts.NewVars(stats, eth, gas)
+}
+
 func (daemon *Daemon) AddCollection(collection *Collection) bool {
 	daemon.collectionsMutex.Lock()
 	defer daemon.collectionsMutex.Unlock()
-	// Check whether we already have this collection
+	// Check whether we already have this collection, ids are random so the same market and symbol is a duplicate too
+	asset := collection.Asset()
 	for _, c := range daemon.collections {
-		if c.String() == collection.String() {
+		if c.ID() == collection.ID() || c.Asset() == asset {
 			// We can't add this
 			return false
 		}
@@ -140,7 +334,7 @@ func (daemon *Daemon) RemoveCollection(collection *Collection) bool {
 	defer daemon.collectionsMutex.Unlock()
 	// Check whether we already have this collection
 	for i, c := range daemon.collections {
-		if c.String() == collection.String() {
+		if c.ID() == collection.ID() {
 			assert(c == collection, "found different pointers to the same collection")
 			c.Stop()
 			daemon.collections = append(daemon.collections[:i], daemon.collections[i+1:]...)
@@ -151,6 +345,19 @@ func (daemon *Daemon) RemoveCollection(collection *Collection) bool {
 	return false
 }
 
+// Reports whether a collection is already watched from this marketplace
+func (daemon *Daemon) HasCollection(market nft.Marketplace, symbol string) bool {
+	asset := alerts.Asset{Market: market, Symbol: symbol}
+	daemon.collectionsMutex.RLock()
+	defer daemon.collectionsMutex.RUnlock()
+	for _, c := range daemon.collections {
+		if c.Asset() == asset {
+			return true
+		}
+	}
+	return false
+}
+
 func (daemon *Daemon) CollectionCount() int {
 	daemon.collectionsMutex.RLock()
 	defer daemon.collectionsMutex.RUnlock()
@@ -163,6 +370,13 @@ func (daemon *Daemon) CollectionAtIndex(index int) *Collection {
 	return daemon.collections[index]
 }
 
+// Copy of the collection list, safe to iterate while collections are added or removed
+func (daemon *Daemon) Collections() []*Collection {
+	daemon.collectionsMutex.RLock()
+	defer daemon.collectionsMutex.RUnlock()
+	return append([]*Collection(nil), daemon.collections...)
+}
+
 func (daemon *Daemon) AddEthAlert(alert *Alert[alerts.EthereumAlert]) bool {
 	return daemon.ethAlerts.Add(alert)
 }
@@ -179,17 +393,52 @@ func (daemon *Daemon) RemoveGasAlert(alert *Alert[alerts.GasAlert]) bool {
 	return daemon.gasAlerts.Remove(alert)
 }
 
+func (daemon *Daemon) AddPairAlert(alert *Alert[alerts.PairAlert]) bool {
+	return daemon.pairAlerts.Add(alert)
+}
+
+func (daemon *Daemon) RemovePairAlert(alert *Alert[alerts.PairAlert]) bool {
+	return daemon.pairAlerts.Remove(alert)
+}
+
+func (daemon *Daemon) AddCompoundAlert(alert *Alert[alerts.CompoundAlert]) bool {
+	return daemon.compoundAlerts.Add(alert)
+}
+
+func (daemon *Daemon) RemoveCompoundAlert(alert *Alert[alerts.CompoundAlert]) bool {
+	return daemon.compoundAlerts.Remove(alert)
+}
+
 type CollectionSaveInfo struct {
 	Market nft.Marketplace          `json:"market"`
 	Symbol string                   `json:"symbol"`
 	Alerts []alerts.CollectionAlert `json:"alerts"`
+	// State of stateful alerts by alert id, older configs keyed them by alert.String()
+	States map[string]alerts.State `json:"states,omitempty"`
+	// Id and timestamps of the collection, flattened
+	alerts.Meta
+}
+
+// Alerts used to share a global cooldown, it is moved into the ones without their own
+// Returns true when the trigger is changed and must be saved
+func migrateCooldown(trigger *alerts.Trigger, legacy int) bool {
+	if trigger.Cooldown == 0 && legacy > 0 {
+		trigger.Cooldown = legacy
+		return true
+	}
+	return false
 }
 
 func (daemon *Daemon) LoadConfig() {
 	defer bench.Begin()()
+	legacyCooldown := config.LoadFallback[int]("notificationCooldown", 0)
+	// Configs before ids are given ids here and saved right away
+	migrated := false
 	// Load ethereum alerts
 	ethAlerts := config.LoadFallback[[]alerts.EthereumAlert]("ethAlerts", nil)
 	for _, params := range ethAlerts {
+		changed := migrateCooldown(&params.Trigger, legacyCooldown)
+		migrated = migrated || changed || params.ID == ""
 		alert := NewEthAlert(params, daemon)
 		if !daemon.AddEthAlert(alert) {
 			log.Error().Println("Already in the list:", alert)
@@ -198,28 +447,74 @@ func (daemon *Daemon) LoadConfig() {
 	// Load gas alerts
 	gasAlerts := config.LoadFallback[[]alerts.GasAlert]("gasAlerts", nil)
 	for _, params := range gasAlerts {
+		changed := migrateCooldown(&params.Trigger, legacyCooldown)
+		migrated = migrated || changed || params.ID == ""
 		alert := NewGasAlert(params, daemon)
 		if !daemon.AddGasAlert(alert) {
 			log.Error().Println("Already in the list:", alert)
 		}
 	}
+	// Load pair alerts
+	pairAlerts := config.LoadFallback[[]alerts.PairAlert]("pairAlerts", nil)
+	for _, params := range pairAlerts {
+		changed := migrateCooldown(&params.Trigger, legacyCooldown)
+		migrated = migrated || changed || params.ID == ""
+		alert := NewPairAlert(params, daemon)
+		if !daemon.AddPairAlert(alert) {
+			log.Error().Println("Already in the list:", alert)
+		}
+	}
+	// Load compound alerts
+	compoundAlerts := config.LoadFallback[[]alerts.CompoundAlert]("compoundAlerts", nil)
+	for _, params := range compoundAlerts {
+		alert := NewCompoundAlert(params, daemon)
+		if !daemon.AddCompoundAlert(alert) {
+			log.Error().Println("Already in the list:", alert)
+		}
+	}
 	// Load collections
 	collections := config.LoadFallback[[]CollectionSaveInfo]("collections", nil)
 	for _, info := range collections {
 		// log.Debug().Println("Loading user collection:", c.Marketplace, c.Slug)
 		collection := NewCollection(daemon, info.Market, info.Symbol)
+		if info.ID != "" {
+			collection.meta.Store(info.Meta)
+		} else {
+			migrated = true
+		}
 		if !daemon.AddCollection(collection) {
 			log.Error().Println("Already in the list:", collection)
 		} else {
 			// Load alerts
 			for _, params := range info.Alerts {
+				changed := migrateCooldown(&params.Trigger, legacyCooldown)
+				migrated = migrated || changed || params.ID == ""
+				// State is looked up before the alert is given an id
+				state, ok := info.States[params.ID]
+				if !ok {
+					state, ok = info.States[params.String()]
+				}
 				alert := NewCollectionAlert(params, collection)
+				if ok {
+					alert.SetState(state)
+				}
 				if !collection.AddAlert(alert) {
 					log.Error().Println("Already in the list:", alert)
 				}
 			}
 		}
 	}
+	if migrated {
+		log.Info().Println("Saving config after migrating alerts and collections")
+		daemon.SaveConfig()
+	}
+	// Legacy cooldown is removed only after the alerts are saved with their own cooldown
+	if legacyCooldown > 0 {
+		config.Delete("notificationCooldown")
+		if err := config.Save(); err != nil {
+			log.Error().Println("Failed to save config:", err)
+		}
+	}
 }
 
 // Uploads user configuration to the server
@@ -237,16 +532,36 @@ func (daemon *Daemon) SaveConfig() {
 		gasAlerts[index] = alert.(*Alert[alerts.GasAlert]).Handle()
 	})
 	config.Store("gasAlerts", gasAlerts)
+	// Save pair alerts
+	pairAlerts := make([]alerts.PairAlert, daemon.pairAlerts.Len())
+	daemon.pairAlerts.ForEach(func(index int, alert alerts.Alert) {
+		pairAlerts[index] = alert.(*Alert[alerts.PairAlert]).Handle()
+	})
+	config.Store("pairAlerts", pairAlerts)
+	// Save compound alerts
+	compoundAlerts := make([]alerts.CompoundAlert, daemon.compoundAlerts.Len())
+	daemon.compoundAlerts.ForEach(func(index int, alert alerts.Alert) {
+		compoundAlerts[index] = alert.(*Alert[alerts.CompoundAlert]).Handle()
+	})
+	config.Store("compoundAlerts", compoundAlerts)
 	// Save collections
 	daemon.collectionsMutex.RLock()
 	collectionInfos := make([]CollectionSaveInfo, len(daemon.collections))
 	for i, collection := range daemon.collections {
 		collectionInfos[i].Market = collection.Market.Load()
 		collectionInfos[i].Symbol = collection.Symbol.Load()
+		collectionInfos[i].Meta = collection.Meta()
 		// Collection alerts
 		colAlerts := make([]alerts.CollectionAlert, collection.alerts.Len())
 		collection.alerts.ForEach(func(index int, alert alerts.Alert) {
-			colAlerts[index] = alert.(*Alert[alerts.CollectionAlert]).Handle()
+			colAlert := alert.(*Alert[alerts.CollectionAlert])
+			colAlerts[index] = colAlert.Handle()
+			if state := colAlert.State(); !state.IsZero() {
+				if collectionInfos[i].States == nil {
+					collectionInfos[i].States = make(map[string]alerts.State)
+				}
+				collectionInfos[i].States[colAlert.ID()] = state
+			}
 		})
 		collectionInfos[i].Alerts = colAlerts
 	}
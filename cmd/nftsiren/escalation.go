@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"nftsiren/cmd/nftsiren/config"
+	"nftsiren/cmd/nftsiren/escalation"
+	"nftsiren/cmd/nftsiren/notifier"
+	"nftsiren/pkg/log"
+	"nftsiren/pkg/worker"
+)
+
+// Acknowledges every pending critical alert, the tray and ipc send to it
+var AcknowledgeChan = make(chan struct{}, 1)
+
+// Sends to AcknowledgeChan without blocking, one signal is enough
+func RequestAcknowledge() {
+	select {
+	case AcknowledgeChan <- struct{}{}:
+	default:
+	}
+}
+
+// Escalations repeats critical alerts until they are acknowledged
+// Pending alerts are saved so repeats continue after a restart
+type Escalations struct {
+	daemon *Daemon
+	queue  *escalation.Queue
+	worker *worker.Worker
+	done   chan struct{}
+}
+
+func NewEscalations(daemon *Daemon) *Escalations {
+	// Pending alerts of the last run continue repeating
+	pending := config.LoadFallback("escalations", []escalation.Pending{})
+	escalations := &Escalations{
+		daemon: daemon,
+		queue:  escalation.New(config.LoadFallback("escalation", escalation.DefaultSettings), pending),
+	}
+	escalations.queue.OnChange = func(pending []escalation.Pending) {
+		err := config.Store("escalations", pending)
+		if err != nil {
+			log.Error().Println("Failed to save pending alerts:", err)
+		}
+		RefreshWindowChan <- struct{}{}
+	}
+	escalations.worker = worker.New(worker.Settings{
+		Name:        "Escalations",
+		Interval:    time.Second * 10,
+		Work:        escalations.Check,
+		PanicHanler: ReportPanic,
+	})
+	return escalations
+}
+
+func (escalations *Escalations) Start() {
+	escalations.Reset()
+	escalations.done = make(chan struct{})
+	go escalations.listen(escalations.done)
+	escalations.worker.Start()
+}
+
+func (escalations *Escalations) Stop() {
+	escalations.worker.Stop()
+	close(escalations.done)
+}
+
+// Reloads the settings, pending alerts are kept
+func (escalations *Escalations) Reset() {
+	escalations.queue.SetSettings(config.LoadFallback("escalation", escalation.DefaultSettings))
+}
+
+func (escalations *Escalations) Settings() escalation.Settings {
+	return escalations.queue.Settings()
+}
+
+func (escalations *Escalations) listen(done chan struct{}) {
+	for {
+		select {
+		case <-AcknowledgeChan:
+			count := escalations.queue.AcknowledgeAll()
+			log.Info().Println("Acknowledged", count, "critical alerts")
+		case <-done:
+			return
+		}
+	}
+}
+
+// Add starts repeating a fired critical alert, id is its history entry id
+func (escalations *Escalations) Add(pending escalation.Pending) {
+	escalations.queue.Add(pending)
+}
+
+func (escalations *Escalations) Acknowledge(id string) {
+	escalations.queue.Acknowledge(id)
+}
+
+func (escalations *Escalations) AcknowledgeAll() {
+	escalations.queue.AcknowledgeAll()
+}
+
+func (escalations *Escalations) Pending() []escalation.Pending {
+	return escalations.queue.Pending()
+}
+
+// Check repeats the due alerts, quiet hours still apply to the repeats
+func (escalations *Escalations) Check() {
+	for _, action := range escalations.queue.Due(time.Now()) {
+		pending := action.Pending
+		title := fmt.Sprintf("%s (repeat %d)", pending.Title, pending.Repeats)
+		escalations.daemon.QuietHours.Notify(pending.Schedule, notifier.Notification{
+			Title:    title,
+			Body:     pending.Text,
+			Severity: notifier.SeverityCritical,
+			AlertID:  pending.AlertID,
+			URL:      pending.URL,
+		})
+		if action.Escalate {
+			escalations.escalate(pending)
+		}
+	}
+}
+
+// Sends the alert to the escalation channels and brings the app window to the front on desktop,
+// a notification is easy to miss
+func (escalations *Escalations) escalate(pending escalation.Pending) {
+	log.Warn().Println("Escalating unacknowledged alert:", pending.Title)
+	if !escalations.daemon.QuietHours.Allows(pending.Schedule, time.Now()) {
+		return
+	}
+	go func() {
+		err := escalations.daemon.Notifier.Dispatch(notifier.Notification{
+			Title:      "Unacknowledged: " + pending.Title,
+			Body:       pending.Text,
+			Severity:   notifier.SeverityCritical,
+			AlertID:    pending.AlertID,
+			URL:        pending.URL,
+			Escalation: true,
+		})
+		if err != nil {
+			log.Error().Println("Failed to deliver escalation:", err)
+		}
+	}()
+	if isDesktop() && TrayRunning() {
+		go func() { ShowWindowChan <- struct{}{} }()
+	}
+}
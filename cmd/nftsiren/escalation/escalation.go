@@ -0,0 +1,191 @@
+package escalation
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+)
+
+// Settings of repeating critical alerts
+type Settings struct {
+	Intervals     []int `json:"intervals"`               // Seconds before each repeat, the last one repeats until acknowledged
+	EscalateAfter int   `json:"escalateAfter,omitempty"` // Minutes before escalating, zero never escalates
+}
+
+var DefaultSettings = Settings{Intervals: []int{60, 120, 300, 600}, EscalateAfter: 15}
+
+// Repeats can't be more frequent than this
+const MinInterval = 10
+
+func (settings Settings) Validate() error {
+	if len(settings.Intervals) == 0 {
+		return errors.New("at least one repeat interval is required")
+	}
+	for _, interval := range settings.Intervals {
+		if interval < MinInterval {
+			return errors.New("repeat intervals must be at least 10 seconds")
+		}
+	}
+	if settings.EscalateAfter < 0 {
+		return errors.New("escalation time can not be negative")
+	}
+	return nil
+}
+
+// Time before the repeat after the given number of repeats
+func (settings Settings) interval(repeats int) time.Duration {
+	if len(settings.Intervals) == 0 {
+		settings = DefaultSettings
+	}
+	if repeats >= len(settings.Intervals) {
+		repeats = len(settings.Intervals) - 1
+	}
+	return time.Duration(settings.Intervals[repeats]) * time.Second
+}
+
+// Pending is a fired critical alert waiting for acknowledgement
+type Pending struct {
+	ID        string           `json:"id"` // Id of the history entry
+	AlertID   string           `json:"alertId"`
+	Title     string           `json:"title"`
+	Text      string           `json:"text"`
+	URL       string           `json:"url,omitempty"`      // Marketplace page of the collection
+	Schedule  *alerts.Schedule `json:"schedule,omitempty"` // Own schedule of the alert, repeats respect it
+	Fired     int64            `json:"fired"`              // Unix time
+	Next      int64            `json:"next"`               // Unix time of the next repeat
+	Repeats   int              `json:"repeats,omitempty"`
+	Escalated bool             `json:"escalated,omitempty"`
+}
+
+// Action tells what to do for a pending alert now
+type Action struct {
+	Pending  Pending
+	Escalate bool // First repeat after the escalation time, it should reach more channels
+}
+
+// Queue keeps the pending alerts until they are acknowledged
+type Queue struct {
+	mutex    sync.Mutex
+	settings Settings
+	pending  []Pending
+	// Called with a copy of the pending alerts after they change, for saving them
+	OnChange func(pending []Pending)
+}
+
+// New creates a queue with the pending alerts from the last run
+func New(settings Settings, pending []Pending) *Queue {
+	return &Queue{
+		settings: settings,
+		pending:  append([]Pending(nil), pending...),
+	}
+}
+
+func (queue *Queue) Settings() Settings {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	return queue.settings
+}
+
+// New intervals apply after the next repeat
+func (queue *Queue) SetSettings(settings Settings) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	queue.settings = settings
+}
+
+// Add starts repeating the alert, same alert firing again replaces its pending one
+func (queue *Queue) Add(pending Pending) {
+	queue.update(func() {
+		pending.Repeats = 0
+		pending.Escalated = false
+		pending.Next = time.Unix(pending.Fired, 0).Add(queue.settings.interval(0)).Unix()
+		for i, p := range queue.pending {
+			if p.AlertID == pending.AlertID {
+				queue.pending = append(queue.pending[:i], queue.pending[i+1:]...)
+				break
+			}
+		}
+		queue.pending = append(queue.pending, pending)
+	})
+}
+
+// Acknowledge stops repeating the alert with the history entry id
+func (queue *Queue) Acknowledge(id string) bool {
+	found := false
+	queue.update(func() {
+		for i, p := range queue.pending {
+			if p.ID == id {
+				queue.pending = append(queue.pending[:i], queue.pending[i+1:]...)
+				found = true
+				return
+			}
+		}
+	})
+	return found
+}
+
+// AcknowledgeAll stops every repeat and returns how many were pending
+func (queue *Queue) AcknowledgeAll() int {
+	count := 0
+	queue.update(func() {
+		count = len(queue.pending)
+		queue.pending = nil
+	})
+	return count
+}
+
+// Pending returns the alerts waiting for acknowledgement, oldest first
+func (queue *Queue) Pending() []Pending {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	return append([]Pending(nil), queue.pending...)
+}
+
+func (queue *Queue) Len() int {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	return len(queue.pending)
+}
+
+// Due returns the alerts to repeat at now and schedules their next repeat
+// Repeats missed while the app was closed are sent once
+func (queue *Queue) Due(now time.Time) []Action {
+	var actions []Action
+	queue.mutex.Lock()
+	for i := range queue.pending {
+		p := &queue.pending[i]
+		if now.Unix() < p.Next {
+			continue
+		}
+		action := Action{}
+		escalateAt := time.Unix(p.Fired, 0).Add(time.Duration(queue.settings.EscalateAfter) * time.Minute)
+		if !p.Escalated && queue.settings.EscalateAfter > 0 && !now.Before(escalateAt) {
+			p.Escalated = true
+			action.Escalate = true
+		}
+		p.Repeats++
+		p.Next = now.Add(queue.settings.interval(p.Repeats)).Unix()
+		action.Pending = *p
+		actions = append(actions, action)
+	}
+	queue.mutex.Unlock()
+	if len(actions) > 0 {
+		queue.changed()
+	}
+	return actions
+}
+
+func (queue *Queue) update(fn func()) {
+	queue.mutex.Lock()
+	fn()
+	queue.mutex.Unlock()
+	queue.changed()
+}
+
+func (queue *Queue) changed() {
+	if queue.OnChange != nil {
+		queue.OnChange(queue.Pending())
+	}
+}
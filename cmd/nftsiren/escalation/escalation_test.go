@@ -0,0 +1,65 @@
+package escalation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueRepeats(t *testing.T) {
+	begin := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	queue := New(Settings{Intervals: []int{60, 120}, EscalateAfter: 3}, nil)
+	saved := 0
+	queue.OnChange = func(pending []Pending) { saved = len(pending) }
+	queue.Add(Pending{ID: "a", AlertID: "alert", Title: "floor", Fired: begin.Unix()})
+	assert.Equal(t, 1, saved)
+
+	assert.Empty(t, queue.Due(begin.Add(59*time.Second)))
+	actions := queue.Due(begin.Add(time.Minute))
+	require.Len(t, actions, 1)
+	assert.False(t, actions[0].Escalate)
+	assert.Equal(t, 1, actions[0].Pending.Repeats)
+	// Second interval is 2 minutes, it escalates after 3 minutes
+	assert.Empty(t, queue.Due(begin.Add(2*time.Minute)))
+	actions = queue.Due(begin.Add(3 * time.Minute))
+	require.Len(t, actions, 1)
+	assert.True(t, actions[0].Escalate)
+	// Last interval repeats and escalation happens once
+	actions = queue.Due(begin.Add(5 * time.Minute))
+	require.Len(t, actions, 1)
+	assert.False(t, actions[0].Escalate)
+	assert.Equal(t, 3, actions[0].Pending.Repeats)
+
+	assert.False(t, queue.Acknowledge("b"))
+	assert.True(t, queue.Acknowledge("a"))
+	assert.Zero(t, saved)
+	assert.Empty(t, queue.Due(begin.Add(time.Hour)))
+}
+
+func TestQueueRestart(t *testing.T) {
+	begin := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	queue := New(DefaultSettings, nil)
+	queue.Add(Pending{ID: "a", AlertID: "x", Fired: begin.Unix()})
+	queue.Add(Pending{ID: "b", AlertID: "y", Fired: begin.Unix()})
+	// Same alert firing again replaces its pending one
+	queue.Add(Pending{ID: "c", AlertID: "x", Fired: begin.Add(time.Minute).Unix()})
+	pending := queue.Pending()
+	require.Len(t, pending, 2)
+	assert.Equal(t, "c", pending[1].ID)
+
+	// Repeats missed while closed are sent once
+	restarted := New(DefaultSettings, pending)
+	assert.Len(t, restarted.Due(begin.Add(time.Hour)), 2)
+	assert.Empty(t, restarted.Due(begin.Add(time.Hour+time.Second)))
+	assert.Equal(t, 2, restarted.AcknowledgeAll())
+	assert.Zero(t, restarted.Len())
+}
+
+func TestSettingsValidate(t *testing.T) {
+	assert.NoError(t, DefaultSettings.Validate())
+	assert.Error(t, Settings{}.Validate())
+	assert.Error(t, Settings{Intervals: []int{5}}.Validate())
+	assert.Error(t, Settings{Intervals: []int{60}, EscalateAfter: -1}.Validate())
+}
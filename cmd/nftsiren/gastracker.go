@@ -1,32 +1,44 @@
 package main
 
 import (
+	"fmt"
 	"time"
 
+	"nftsiren/cmd/nftsiren/alertengine"
+	"nftsiren/cmd/nftsiren/alerts"
 	"nftsiren/cmd/nftsiren/widgets"
 	"nftsiren/pkg/apis/etherscan"
 	"nftsiren/pkg/log"
 	"nftsiren/pkg/mutex"
 	"nftsiren/pkg/number"
+	"nftsiren/pkg/window"
 	"nftsiren/pkg/worker"
 
 	"gioui.org/layout"
+	"gioui.org/text"
 	"gioui.org/widget/material"
 )
 
 type GasTracker struct {
 	worker *worker.Worker
 	// TODO: solana price in usd
-	// TODO: eip-1159 gas (base fee and priority fee)
 	// TODO: move ethereum price to some other price api
 	eth           mutex.Value[etherscan.EthPrice]
 	ethUpdateTime mutex.Value[time.Time]
 	gas           mutex.Value[etherscan.GasPrice]
 	gasUpdateTime mutex.Value[time.Time]
+	err           mutex.Value[error]     // Last fetch error, nil after a successful fetch
+	started       mutex.Value[time.Time] // Staleness is counted from here until the first update
+	// recent prices for percent change alerts
+	ethHistory *window.Window[number.Number]
+	gasHistory *window.Window[etherscan.GasPrice] // Every tier is recorded
 }
 
 func NewGasTracker() *GasTracker {
-	tracker := &GasTracker{}
+	tracker := &GasTracker{
+		ethHistory: window.New[number.Number](historyLength, time.Second*30),
+		gasHistory: window.New[etherscan.GasPrice](historyLength, time.Second*30),
+	}
 	tracker.worker = worker.New(worker.Settings{
 		Name:              "GasTracker",
 		Interval:          time.Second * 5,
@@ -40,6 +52,7 @@ func NewGasTracker() *GasTracker {
 }
 
 func (tracker *GasTracker) Start() {
+	tracker.started.Store(time.Now())
 	tracker.worker.Start()
 }
 
@@ -48,19 +61,26 @@ func (tracker *GasTracker) Stop() {
 }
 
 func (tracker *GasTracker) FetchEthInfo() {
-	eth, err := etherscan.FetchEthPrice()
-	if err != nil {
-		log.Warn().Println("Failed to fetch eth price from etherscan:", err)
+	eth, ethErr := etherscan.FetchEthPrice()
+	if ethErr != nil {
+		log.Warn().Println("Failed to fetch eth price from etherscan:", ethErr)
 	} else {
 		tracker.eth.Store(eth)
 		tracker.ethUpdateTime.Store(time.Now())
+		tracker.ethHistory.Add(time.Now(), eth.Ethusd)
 	}
-	gas, err := etherscan.FetchGasPrice()
-	if err != nil {
-		log.Warn().Println("Failed to fetch gas price from etherscan:", err)
+	gas, gasErr := etherscan.FetchGasPrice()
+	if gasErr != nil {
+		log.Warn().Println("Failed to fetch gas price from etherscan:", gasErr)
 	} else {
 		tracker.gas.Store(gas)
 		tracker.gasUpdateTime.Store(time.Now())
+		tracker.gasHistory.Add(time.Now(), gas)
+	}
+	if ethErr != nil {
+		tracker.err.Store(ethErr)
+	} else {
+		tracker.err.Store(gasErr)
 	}
 	RefreshWindowChan <- struct{}{}
 }
@@ -77,6 +97,22 @@ func (tracker *GasTracker) GasStillValid() bool {
 	return !updateTime.IsZero() && time.Since(updateTime) < d && tracker.GetGas().Int64() > 0
 }
 
+func (tracker *GasTracker) LastError() error {
+	return tracker.err.Load()
+}
+
+// Reports whether eth price or gas hasn't been updated for d at now
+func (tracker *GasTracker) StaleAt(now time.Time, d time.Duration) bool {
+	last := tracker.ethUpdateTime.Load()
+	if gas := tracker.gasUpdateTime.Load(); gas.Before(last) {
+		last = gas
+	}
+	if started := tracker.started.Load(); last.Before(started) {
+		last = started
+	}
+	return !last.IsZero() && now.Sub(last) > d
+}
+
 func (tracker *GasTracker) GetEth() number.Number {
 	return tracker.eth.Load().Ethusd
 }
@@ -85,6 +121,23 @@ func (tracker *GasTracker) GetGas() number.Number {
 	return tracker.gas.Load().ProposeGasPrice // Average
 }
 
+// Price of the tier, nil when etherscan doesn't give it
+func (tracker *GasTracker) GetGasTier(tier alerts.GasTier) number.Number {
+	return alertengine.GasTierValue(tracker.gas.Load(), tier)
+}
+
+// Percent drop and rise of eth price within the last d
+func (tracker *GasTracker) EthChange(d time.Duration) (drop, rise number.Number, ok bool) {
+	return window.PercentChange(tracker.ethHistory, time.Now().Add(-d), alertengine.Identity)
+}
+
+// Percent drop and rise of the gas tier within the last d
+func (tracker *GasTracker) GasChange(d time.Duration, tier alerts.GasTier) (drop, rise number.Number, ok bool) {
+	return window.PercentChange(tracker.gasHistory, time.Now().Add(-d), func(gas etherscan.GasPrice) number.Number {
+		return alertengine.GasTierValue(gas, tier)
+	})
+}
+
 // A generic layout for gas tracker, you don't have to use it
 func (tracker *GasTracker) Layout(gtx layout.Context, theme *Theme, axis layout.Axis) layout.Dimensions {
 	return layout.Flex{
@@ -100,9 +153,32 @@ func (tracker *GasTracker) Layout(gtx layout.Context, theme *Theme, axis layout.
 		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 			return tracker.layoutPrice(gtx, theme, theme.GasIcon, tracker.GetGas().StringPretty())
 		}),
+		// Other tiers
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return tracker.layoutTiers(gtx, theme, "Safe %s · Fast %s", alerts.GasTierSafe, alerts.GasTierFast)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return tracker.layoutTiers(gtx, theme, "Base %s · Tip %s", alerts.GasTierBaseFee, alerts.GasTierPriorityFee)
+		}),
 	)
 }
 
+// Small line of tier prices, format has a verb for each tier
+func (tracker *GasTracker) layoutTiers(gtx layout.Context, theme *Theme, format string, tiers ...alerts.GasTier) layout.Dimensions {
+	prices := make([]any, len(tiers))
+	for i, tier := range tiers {
+		prices[i] = "-"
+		if price := tracker.GetGasTier(tier); !price.IsNil() {
+			prices[i] = price.StringPretty()
+		}
+	}
+	label := material.Caption(theme.Material(), fmt.Sprintf(format, prices...))
+	label.Color = theme.MediumImpFg
+	label.Alignment = text.Middle
+	label.MaxLines = 1
+	return layout.Center.Layout(gtx, label.Layout)
+}
+
 func (tracker *GasTracker) layoutPrice(gtx layout.Context, theme *Theme, icon *widgets.Icon, price string) layout.Dimensions {
 	// return theme.SmallInset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 	return layout.Flex{
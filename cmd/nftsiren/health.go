@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/cmd/nftsiren/config"
+	"nftsiren/cmd/nftsiren/history"
+	"nftsiren/cmd/nftsiren/notifier"
+	"nftsiren/pkg/httpclient"
+	"nftsiren/pkg/log"
+	"nftsiren/pkg/mutex"
+	"nftsiren/pkg/worker"
+)
+
+// Settings of system alerts, zero minutes disables that check
+type HealthSettings struct {
+	Disabled          bool `json:"disabled,omitempty"`
+	CollectionMinutes int  `json:"collectionMinutes"` // Collections without a successful update this long are stale
+	GasMinutes        int  `json:"gasMinutes"`        // Same for eth price and gas
+}
+
+var DefaultHealthSettings = HealthSettings{CollectionMinutes: 15, GasMinutes: 5}
+
+func (settings HealthSettings) Validate() error {
+	if settings.CollectionMinutes < 0 || settings.GasMinutes < 0 {
+		return fmt.Errorf("minutes can't be negative")
+	}
+	return nil
+}
+
+// What the health checks look at in the gas tracker and the collections
+type healthSource interface {
+	LastError() error
+	StaleAt(now time.Time, d time.Duration) bool
+}
+
+type healthCollection interface {
+	healthSource
+	ID() string
+	Asset() alerts.Asset
+	Name() (string, bool)
+}
+
+// Problem found by a health check
+type healthProblem struct {
+	key        string           // Same problem has the same key on every check
+	collection healthCollection // Nil when it isn't about a collection
+	title      string
+	text       string
+}
+
+// Health watches the data providers and sends system alerts when they fail silently
+// Every problem is notified once, it is notified again only after it is fixed and happens again
+type Health struct {
+	daemon   *Daemon
+	settings mutex.Value[HealthSettings]
+	worker   *worker.Worker
+	mutex    sync.Mutex
+	notified map[string]bool // Keys of the problems which are still there
+}
+
+func NewHealth(daemon *Daemon) *Health {
+	health := &Health{
+		daemon:   daemon,
+		notified: make(map[string]bool),
+	}
+	health.worker = worker.New(worker.Settings{
+		Name:        "Health",
+		Interval:    time.Minute,
+		Work:        health.Check,
+		PanicHanler: ReportPanic,
+	})
+	return health
+}
+
+func (health *Health) Start() {
+	health.Reset()
+	health.worker.Start()
+}
+
+func (health *Health) Stop() {
+	health.worker.Stop()
+}
+
+func (health *Health) Reset() {
+	health.settings.Store(config.LoadFallback("health", DefaultHealthSettings))
+}
+
+func (health *Health) Settings() HealthSettings {
+	return health.settings.Load()
+}
+
+// How long a collection can go without an update, zero is forever
+func (health *Health) CollectionTimeout() time.Duration {
+	settings := health.settings.Load()
+	if settings.Disabled {
+		return 0
+	}
+	return time.Duration(settings.CollectionMinutes) * time.Minute
+}
+
+// Check notifies the new problems and forgets the fixed ones
+func (health *Health) Check() {
+	if health.settings.Load().Disabled {
+		return
+	}
+	collections := health.daemon.Collections()
+	sources := make([]healthCollection, len(collections))
+	for i, collection := range collections {
+		sources[i] = collection
+	}
+	fresh := health.fresh(health.problems(time.Now(), sources, health.daemon.GasTracker))
+	for _, problem := range fresh {
+		health.notify(problem)
+	}
+	if len(fresh) > 0 {
+		RefreshWindowChan <- struct{}{}
+	}
+}
+
+// Remembers the problems and returns the ones which weren't there on the previous check
+func (health *Health) fresh(problems []healthProblem) []healthProblem {
+	health.mutex.Lock()
+	defer health.mutex.Unlock()
+	current := make(map[string]bool, len(problems))
+	var fresh []healthProblem
+	for _, problem := range problems {
+		if current[problem.key] {
+			continue
+		}
+		current[problem.key] = true
+		if !health.notified[problem.key] {
+			fresh = append(fresh, problem)
+		}
+	}
+	health.notified = current
+	return fresh
+}
+
+func (health *Health) problems(now time.Time, collections []healthCollection, tracker healthSource) []healthProblem {
+	settings := health.settings.Load()
+	var problems []healthProblem
+	// Rejected keys are reported once per marketplace instead of once per collection
+	rejected := func(market string) healthProblem {
+		return healthProblem{
+			key:   "auth:" + market,
+			title: fmt.Sprintf("%s | %s api key rejected", NAME, market),
+			text:  fmt.Sprintf("%s doesn't accept the api key, check it in the settings", market),
+		}
+	}
+	collectionTimeout := time.Duration(settings.CollectionMinutes) * time.Minute
+	for _, collection := range collections {
+		err := collection.LastError()
+		if httpclient.IsAuthError(err) {
+			problems = append(problems, rejected(collection.Asset().Market.String()))
+		}
+		if collectionTimeout > 0 && collection.StaleAt(now, collectionTimeout) {
+			name, _ := collection.Name()
+			text := fmt.Sprintf("No successful update for %s in %d minutes", name, settings.CollectionMinutes)
+			if err != nil {
+				text += ": " + err.Error()
+			}
+			problems = append(problems, healthProblem{
+				key:        "stale:" + collection.ID(),
+				collection: collection,
+				title:      fmt.Sprintf("%s | %s is stale", NAME, name),
+				text:       text,
+			})
+		}
+	}
+	err := tracker.LastError()
+	if httpclient.IsAuthError(err) {
+		problems = append(problems, rejected("Etherscan"))
+	}
+	gasTimeout := time.Duration(settings.GasMinutes) * time.Minute
+	if gasTimeout > 0 && tracker.StaleAt(now, gasTimeout) {
+		text := fmt.Sprintf("No eth price or gas update in %d minutes", settings.GasMinutes)
+		if err != nil {
+			text += ": " + err.Error()
+		}
+		problems = append(problems, healthProblem{
+			key:   "gas",
+			title: fmt.Sprintf("%s | Gas tracker is stale", NAME),
+			text:  text,
+		})
+	}
+	return problems
+}
+
+// Goes through the same path as alerts so quiet hours and the notification center apply
+func (health *Health) notify(problem healthProblem) {
+	log.Warn().Println("System alert:", problem.text)
+	entry := history.Entry{Kind: history.KindSystem, Title: problem.title, Text: problem.text}
+	if problem.collection != nil {
+		asset := problem.collection.Asset()
+		entry.Collection = &asset
+		entry.Name, _ = problem.collection.Name()
+	}
+	health.daemon.QuietHours.Notify(nil, health.daemon.withCollection(notifier.Notification{
+		Title:      problem.title,
+		Body:       problem.text,
+		Severity:   notifier.SeverityWarning,
+		Kind:       entry.Kind,
+		Collection: entry.Collection,
+		Name:       entry.Name,
+		URL:        entry.URL(),
+	}))
+	_, err := health.daemon.History.Add(entry)
+	if err != nil {
+		log.Error().Println("Failed to save alert history:", err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/httpclient"
+	"nftsiren/pkg/nft"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSource struct {
+	err        error
+	lastUpdate time.Time
+}
+
+func (source *fakeSource) LastError() error {
+	return source.err
+}
+
+func (source *fakeSource) StaleAt(now time.Time, d time.Duration) bool {
+	return now.Sub(source.lastUpdate) > d
+}
+
+type fakeCollection struct {
+	fakeSource
+	id    string
+	asset alerts.Asset
+}
+
+func (collection *fakeCollection) ID() string           { return collection.id }
+func (collection *fakeCollection) Asset() alerts.Asset  { return collection.asset }
+func (collection *fakeCollection) Name() (string, bool) { return collection.asset.Symbol, false }
+
+func newTestHealth() *Health {
+	health := &Health{notified: make(map[string]bool)}
+	health.settings.Store(DefaultHealthSettings)
+	return health
+}
+
+func problemKeys(problems []healthProblem) []string {
+	var keys []string
+	for _, problem := range problems {
+		keys = append(keys, problem.key)
+	}
+	return keys
+}
+
+func TestHealthStale(t *testing.T) {
+	health := newTestHealth()
+	now := time.Now()
+	tracker := &fakeSource{lastUpdate: now}
+	stale := &fakeCollection{id: "a", asset: alerts.Asset{Symbol: "azuki"}}
+	stale.lastUpdate = now.Add(-20 * time.Minute)
+	fine := &fakeCollection{id: "b", asset: alerts.Asset{Symbol: "doodles"}}
+	fine.lastUpdate = now
+	collections := []healthCollection{stale, fine}
+
+	fresh := health.fresh(health.problems(now, collections, tracker))
+	require.Len(t, fresh, 1)
+	require.Equal(t, "stale:a", fresh[0].key)
+	require.Equal(t, stale, fresh[0].collection)
+	require.Contains(t, fresh[0].text, "azuki")
+
+	// Still stale, already notified
+	now = now.Add(time.Minute)
+	require.Empty(t, health.fresh(health.problems(now, collections, tracker)))
+
+	// Recovers, then goes stale again
+	stale.lastUpdate = now
+	require.Empty(t, health.fresh(health.problems(now, collections, tracker)))
+	now = now.Add(20 * time.Minute)
+	tracker.lastUpdate = now
+	fine.lastUpdate = now
+	require.Equal(t, []string{"stale:a"}, problemKeys(health.fresh(health.problems(now, collections, tracker))))
+}
+
+func TestHealthRejectedKey(t *testing.T) {
+	health := newTestHealth()
+	now := time.Now()
+	rejected := &httpclient.StatusError{Code: http.StatusUnauthorized}
+	var collections []healthCollection
+	for _, symbol := range []string{"azuki", "doodles"} {
+		collection := &fakeCollection{id: symbol, asset: alerts.Asset{Market: nft.Opensea, Symbol: symbol}}
+		collection.err = rejected
+		collection.lastUpdate = now
+		collections = append(collections, collection)
+	}
+
+	fresh := health.fresh(health.problems(now, collections, &fakeSource{lastUpdate: now}))
+	require.Len(t, fresh, 1)
+	require.Equal(t, "auth:"+nft.Opensea.String(), fresh[0].key)
+	require.Contains(t, fresh[0].title, "api key rejected")
+	require.Nil(t, fresh[0].collection)
+}
@@ -0,0 +1,282 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/number"
+	"nftsiren/pkg/util"
+)
+
+// Kinds of alerts, entries can be filtered by them
+const (
+	KindCollection = "Collection"
+	KindEthereum   = "Ethereum"
+	KindGas        = "Gas"
+	KindPair       = "Pair"
+	KindCompound   = "Compound"
+	KindSystem     = "System" // Problems of the app itself, like stale data
+)
+
+var Kinds = []string{KindCollection, KindEthereum, KindGas, KindPair, KindCompound, KindSystem}
+
+// Entry is a fired alert
+type Entry struct {
+	ID         string        `json:"id"`
+	AlertID    string        `json:"alertId"`
+	Kind       string        `json:"kind"`
+	Condition  string        `json:"condition,omitempty"`
+	Collection *alerts.Asset `json:"collection,omitempty"` // Nil when the alert doesn't belong to a collection
+	Name       string        `json:"name,omitempty"`       // Name of the collection
+	Value      number.Number `json:"value"`                // Triggering value, nil when nothing is compared
+	Title      string        `json:"title"`
+	Text       string        `json:"text"`
+	Time       int64         `json:"time"` // Unix time
+	Read       bool          `json:"read,omitempty"`
+}
+
+// Marketplace page of the collection, empty when there is no collection
+func (entry Entry) URL() string {
+	if entry.Collection == nil || entry.Collection.IsEth() {
+		return ""
+	}
+	return entry.Collection.Market.MakeCollectionURL(entry.Collection.Symbol)
+}
+
+// Retention decides which entries are kept
+type Retention struct {
+	Days int `json:"days,omitempty"` // Entries older than this are removed, zero keeps forever
+	Max  int `json:"max,omitempty"`  // Only newest this many entries are kept, zero is unlimited
+}
+
+var DefaultRetention = Retention{Days: 30, Max: 1000}
+
+func (retention Retention) Validate() error {
+	if retention.Days < 0 || retention.Max < 0 {
+		return errors.New("retention can not be negative")
+	}
+	return nil
+}
+
+// Filter selects entries, zero value selects everything
+type Filter struct {
+	Kind       string        // Empty is every kind
+	Collection *alerts.Asset // Nil is every collection
+	Unread     bool          // Only unread ones
+}
+
+func (filter Filter) Matches(entry Entry) bool {
+	if filter.Kind != "" && entry.Kind != filter.Kind {
+		return false
+	}
+	if filter.Collection != nil && (entry.Collection == nil || *entry.Collection != *filter.Collection) {
+		return false
+	}
+	return !filter.Unread || !entry.Read
+}
+
+// Store keeps entries in a json file, every change is written immediately
+type Store struct {
+	path      string // Empty keeps entries only in memory
+	mutex     sync.RWMutex
+	entries   []Entry // Oldest first
+	retention Retention
+	version   uint64 // Increases on every change
+	// Called after every change, may be nil
+	OnChange func()
+}
+
+func New(path string, retention Retention) *Store {
+	return &Store{
+		path:      path,
+		retention: retention,
+	}
+}
+
+// Loads entries from the file, missing file is not an error
+func (store *Store) Load() error {
+	if store.path == "" || !util.FileExists(store.path) {
+		return nil
+	}
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		return err
+	}
+	var entries []Entry
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time < entries[j].Time
+	})
+	store.mutex.Lock()
+	store.entries = entries
+	store.prune(time.Now())
+	store.version++
+	store.mutex.Unlock()
+	return nil
+}
+
+// Writes to a temporary file first so a crash doesn't corrupt the history
+// Must be called with the lock held
+func (store *Store) save() error {
+	if store.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(store.entries)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(store.path), 0666)
+	if err != nil {
+		return err
+	}
+	tmp := store.path + ".tmp"
+	err = os.WriteFile(tmp, data, 0666)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, store.path)
+}
+
+// Must be called with the lock held
+func (store *Store) prune(now time.Time) {
+	entries := store.entries
+	if store.retention.Days > 0 {
+		limit := now.AddDate(0, 0, -store.retention.Days).Unix()
+		i := sort.Search(len(entries), func(i int) bool {
+			return entries[i].Time >= limit
+		})
+		entries = entries[i:]
+	}
+	if store.retention.Max > 0 && len(entries) > store.retention.Max {
+		entries = entries[len(entries)-store.retention.Max:]
+	}
+	if len(entries) != len(store.entries) {
+		store.entries = append([]Entry(nil), entries...)
+	}
+}
+
+// Applies the change and saves, returns the save error
+func (store *Store) update(change func()) error {
+	store.mutex.Lock()
+	change()
+	store.version++
+	err := store.save()
+	store.mutex.Unlock()
+	if store.OnChange != nil {
+		store.OnChange()
+	}
+	return err
+}
+
+// Add records the entry, id and time are set when empty
+func (store *Store) Add(entry Entry) (Entry, error) {
+	if entry.ID == "" {
+		entry.ID = alerts.NewID()
+	}
+	if entry.Time == 0 {
+		entry.Time = time.Now().Unix()
+	}
+	err := store.update(func() {
+		store.entries = append(store.entries, entry)
+		store.prune(time.Now())
+	})
+	return entry, err
+}
+
+// Entries returns matching entries, newest first
+func (store *Store) Entries(filter Filter) []Entry {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	var entries []Entry
+	for i := len(store.entries) - 1; i >= 0; i-- {
+		if filter.Matches(store.entries[i]) {
+			entries = append(entries, store.entries[i])
+		}
+	}
+	return entries
+}
+
+// Collections returns every distinct collection in the history
+func (store *Store) Collections() []alerts.Asset {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	var assets []alerts.Asset
+	seen := make(map[alerts.Asset]bool)
+	for _, entry := range store.entries {
+		if entry.Collection != nil && !seen[*entry.Collection] {
+			seen[*entry.Collection] = true
+			assets = append(assets, *entry.Collection)
+		}
+	}
+	return assets
+}
+
+func (store *Store) Unread() int {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	count := 0
+	for _, entry := range store.entries {
+		if !entry.Read {
+			count++
+		}
+	}
+	return count
+}
+
+func (store *Store) MarkRead(id string) error {
+	return store.update(func() {
+		for i := range store.entries {
+			if store.entries[i].ID == id {
+				store.entries[i].Read = true
+			}
+		}
+	})
+}
+
+func (store *Store) MarkAllRead() error {
+	if store.Unread() == 0 {
+		return nil
+	}
+	return store.update(func() {
+		for i := range store.entries {
+			store.entries[i].Read = true
+		}
+	})
+}
+
+// Removes every entry
+func (store *Store) Clear() error {
+	return store.update(func() {
+		store.entries = nil
+	})
+}
+
+// Version changes whenever the entries may have changed, so views can cache them
+func (store *Store) Version() uint64 {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.version
+}
+
+func (store *Store) Retention() Retention {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+	return store.retention
+}
+
+// Sets the retention and removes the entries it doesn't keep
+func (store *Store) SetRetention(retention Retention) error {
+	return store.update(func() {
+		store.retention = retention
+		store.prune(time.Now())
+	})
+}
@@ -0,0 +1,88 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/nft"
+	"nftsiren/pkg/number"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	store := New(path, DefaultRetention)
+	azuki := &alerts.Asset{Market: nft.Opensea, Symbol: "azuki"}
+	entry, err := store.Add(Entry{AlertID: "a1", Kind: KindCollection, Collection: azuki, Value: number.NewFromInt(12), Title: "Opensea | Azuki", Text: "Floor is less than 13"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, entry.ID)
+	assert.NotZero(t, entry.Time)
+	_, err = store.Add(Entry{AlertID: "a2", Kind: KindGas, Text: "Gas is less than 20"})
+	require.NoError(t, err)
+	require.NoError(t, store.MarkRead(entry.ID))
+
+	loaded := New(path, DefaultRetention)
+	require.NoError(t, loaded.Load())
+	entries := loaded.Entries(Filter{})
+	require.Len(t, entries, 2)
+	// Newest first
+	assert.Equal(t, "a2", entries[0].AlertID)
+	assert.Equal(t, "12", entries[1].Value.String())
+	assert.True(t, entries[0].Value.IsNil())
+	assert.Equal(t, "https://opensea.io/collection/azuki", entries[1].URL())
+	assert.Empty(t, entries[0].URL())
+	assert.Equal(t, 1, loaded.Unread())
+	assert.Equal(t, []alerts.Asset{*azuki}, loaded.Collections())
+
+	// Missing file is an empty history
+	assert.NoError(t, New(filepath.Join(t.TempDir(), "none.json"), DefaultRetention).Load())
+}
+
+func TestStoreFilter(t *testing.T) {
+	store := New("", Retention{})
+	azuki := &alerts.Asset{Market: nft.Opensea, Symbol: "azuki"}
+	doodles := &alerts.Asset{Market: nft.Looksrare, Symbol: "doodles"}
+	store.Add(Entry{AlertID: "a", Kind: KindCollection, Collection: azuki})
+	store.Add(Entry{AlertID: "b", Kind: KindCollection, Collection: doodles})
+	store.Add(Entry{AlertID: "c", Kind: KindEthereum})
+	store.Add(Entry{AlertID: "d", Kind: KindPair, Read: true})
+
+	ids := func(filter Filter) []string {
+		var ids []string
+		for _, entry := range store.Entries(filter) {
+			ids = append(ids, entry.AlertID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"d", "c", "b", "a"}, ids(Filter{}))
+	assert.Equal(t, []string{"b", "a"}, ids(Filter{Kind: KindCollection}))
+	assert.Equal(t, []string{"a"}, ids(Filter{Collection: &alerts.Asset{Market: nft.Opensea, Symbol: "azuki"}}))
+	assert.Equal(t, []string{"c", "b", "a"}, ids(Filter{Unread: true}))
+
+	version := store.Version()
+	require.NoError(t, store.MarkAllRead())
+	assert.Zero(t, store.Unread())
+	assert.Greater(t, store.Version(), version)
+	require.NoError(t, store.Clear())
+	assert.Empty(t, store.Entries(Filter{}))
+}
+
+func TestStoreRetention(t *testing.T) {
+	store := New("", Retention{Max: 3})
+	old := time.Now().AddDate(0, 0, -10).Unix()
+	for i := 0; i < 5; i++ {
+		store.Add(Entry{Time: old + int64(i)})
+	}
+	assert.Len(t, store.Entries(Filter{}), 3)
+	store.Add(Entry{})
+	assert.Len(t, store.Entries(Filter{}), 3)
+
+	// Only the new one is younger than a week
+	require.NoError(t, store.SetRetention(Retention{Days: 7}))
+	assert.Len(t, store.Entries(Filter{}), 1)
+	assert.Error(t, Retention{Days: -1}.Validate())
+}
@@ -32,6 +32,15 @@ func (h *IpcServerHandler) ShowWindow(args IpcRequestArgs, reply *IpcReplyArgs)
 	return nil
 }
 
+// Acknowledges every pending critical alert
+func (h *IpcServerHandler) Acknowledge(args IpcRequestArgs, reply *IpcReplyArgs) error {
+	if args.MacAddress != macAddress() {
+		return errors.New("requests are only accepted on same machine")
+	}
+	RequestAcknowledge()
+	return nil
+}
+
 type IpcClient struct {
 	conn   net.Conn
 	client *rpc.Client
@@ -61,6 +70,13 @@ func (client *IpcClient) ShowWindow() error {
 	)
 }
 
+func (client *IpcClient) Acknowledge() error {
+	return client.client.Call("IpcServerHandler.Acknowledge",
+		&IpcRequestArgs{MacAddress: macAddress()},
+		&IpcReplyArgs{},
+	)
+}
+
 func (client *IpcClient) Close() error {
 	err := client.conn.Close()
 	if err != nil {
@@ -125,6 +141,15 @@ func ShowExistingInstance(port string) error {
 	return nil
 }
 
+func AcknowledgeExistingInstance(port string) error {
+	client, err := NewIpcClient(port)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.Acknowledge()
+}
+
 // Returns the mac address of current hardware
 func macAddress() uint64 {
 	interfaces, err := net.Interfaces()
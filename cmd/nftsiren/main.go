@@ -13,6 +13,7 @@ import (
 	"nftsiren/cmd/nftsiren/assets"
 	"nftsiren/cmd/nftsiren/cache"
 	"nftsiren/cmd/nftsiren/config"
+	"nftsiren/cmd/nftsiren/escalation"
 	"nftsiren/pkg/bench"
 	"nftsiren/pkg/log"
 	"nftsiren/pkg/util"
@@ -270,6 +271,16 @@ func connectUdpServer(addr string) (net.Conn, error) {
 // Always defer the returned function
 func checkExistingInstance() (bool, func()) {
 	port := config.LoadFallback("port", "")
+	// Acknowledges critical alerts without opening the app, like nftsiren --acknowledge
+	if hasFlag("--acknowledge") {
+		if port != "" && AcknowledgeExistingInstance(port) == nil {
+			log.Info().Println("Acknowledged alerts of the open instance")
+		} else {
+			// Nothing is running, forget the saved ones so they don't repeat on the next start
+			config.Store("escalations", []escalation.Pending{})
+		}
+		return true, func() {}
+	}
 	if port != "" {
 		err := ShowExistingInstance(port)
 		if err != nil {
@@ -306,6 +317,16 @@ func checkExistingInstance() (bool, func()) {
 	}
 }
 
+// Reports whether the flag is in the command line arguments
+func hasFlag(flag string) bool {
+	for _, arg := range os.Args[1:] {
+		if arg == flag {
+			return true
+		}
+	}
+	return false
+}
+
 func TODO(msg string) {
 	log.Debug().Println("TODO:", msg)
 }
@@ -0,0 +1,236 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nftsiren/cmd/nftsiren/history"
+	"nftsiren/pkg/httpclient"
+)
+
+// Settings of the discord channel, url is the webhook url of a discord channel
+type DiscordSettings struct {
+	Enabled    bool   `json:"enabled,omitempty"`
+	URL        string `json:"url"`
+	Escalation bool   `json:"escalation,omitempty"` // Only escalations of unacknowledged critical alerts are sent
+}
+
+func (settings DiscordSettings) Validate() error {
+	u, err := url.Parse(settings.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || !strings.Contains(u.Path, "/api/webhooks/") {
+		return errors.New("discord webhook url looks like https://discord.com/api/webhooks/...")
+	}
+	return nil
+}
+
+// Discord allows 5 requests in 2 seconds to a webhook
+const (
+	discordBurst    = 5
+	discordInterval = 2 * time.Second
+)
+
+// Embed colors by kind of the alert, critical ones are red
+var discordColors = map[string]int{
+	history.KindCollection: 0x3498db,
+	history.KindEthereum:   0x9b59b6,
+	history.KindGas:        0xe67e22,
+	history.KindPair:       0x1abc9c,
+	history.KindCompound:   0x2ecc71,
+	history.KindSystem:     0xf1c40f,
+}
+
+const (
+	discordCriticalColor = 0xe74c3c
+	discordDefaultColor  = 0x95a5a6
+)
+
+type discordMessage struct {
+	Username string         `json:"username,omitempty"`
+	Embeds   []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description,omitempty"`
+	URL         string            `json:"url,omitempty"`
+	Color       int               `json:"color"`
+	Timestamp   string            `json:"timestamp,omitempty"`
+	Thumbnail   *discordThumbnail `json:"thumbnail,omitempty"`
+	Fields      []discordField    `json:"fields,omitempty"`
+	Footer      *discordFooter    `json:"footer,omitempty"`
+}
+
+type discordThumbnail struct {
+	URL string `json:"url"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+// Rate limit of a webhook, every channel of the same url shares it
+type discordBucket struct {
+	client  *httpclient.Client
+	mutex   sync.Mutex
+	blocked time.Time // No requests until then, the bucket of the webhook is empty
+}
+
+var discordBuckets = struct {
+	sync.Mutex
+	byURL map[string]*discordBucket
+}{byURL: make(map[string]*discordBucket)}
+
+func bucketOf(url string) *discordBucket {
+	discordBuckets.Lock()
+	defer discordBuckets.Unlock()
+	bucket, ok := discordBuckets.byURL[url]
+	if !ok {
+		bucket = &discordBucket{client: httpclient.NewClientWithLimit(url, discordBurst, discordInterval)}
+		discordBuckets.byURL[url] = bucket
+	}
+	return bucket
+}
+
+// Discord sends an embed per notification to a discord webhook
+type Discord struct {
+	settings DiscordSettings
+	bucket   *discordBucket
+	retry    httpclient.Retry
+	// Shown as the sender of the messages
+	Username string
+}
+
+// Channels of the same url share the rate limit, so test messages and alerts don't exceed it together
+func NewDiscord(settings DiscordSettings, username string) (*Discord, error) {
+	err := settings.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &Discord{
+		settings: settings,
+		bucket:   bucketOf(settings.URL),
+		retry:    httpclient.DefaultRetry,
+		Username: username,
+	}, nil
+}
+
+func (discord *Discord) Name() string {
+	return "Discord"
+}
+
+func discordColor(notification Notification) int {
+	if notification.Severity == SeverityCritical {
+		return discordCriticalColor
+	}
+	if color, ok := discordColors[notification.Kind]; ok {
+		return color
+	}
+	return discordDefaultColor
+}
+
+func (discord *Discord) embed(notification Notification) discordEmbed {
+	embed := discordEmbed{
+		Title:       notification.Title,
+		Description: notification.Body,
+		URL:         notification.URL,
+		Color:       discordColor(notification),
+		Footer:      &discordFooter{Text: notification.Severity.String()},
+	}
+	if notification.Name != "" {
+		embed.Title = notification.Name
+	}
+	if !notification.Time.IsZero() {
+		embed.Timestamp = notification.Time.UTC().Format(time.RFC3339)
+	}
+	if notification.ImageURL != "" {
+		embed.Thumbnail = &discordThumbnail{URL: notification.ImageURL}
+	}
+	if notification.Condition != "" {
+		embed.Fields = append(embed.Fields, discordField{Name: "Condition", Value: notification.Condition, Inline: true})
+	}
+	if !notification.Value.IsNil() {
+		embed.Fields = append(embed.Fields, discordField{Name: "Value", Value: notification.Value.StringPretty(), Inline: true})
+	}
+	if !notification.Floor.IsNil() {
+		floor := strings.TrimSpace(notification.Floor.StringPretty() + " " + notification.Currency)
+		embed.Fields = append(embed.Fields, discordField{Name: "Floor", Value: floor, Inline: true})
+	}
+	if !notification.FloorChange.IsNil() {
+		change := notification.FloorChange.StringFixed(2) + "%"
+		if !strings.HasPrefix(change, "-") {
+			change = "+" + change
+		}
+		embed.Fields = append(embed.Fields, discordField{Name: "24h Change", Value: change, Inline: true})
+	}
+	return embed
+}
+
+// Waits while the bucket of the webhook is empty
+func (discord *Discord) wait() {
+	discord.bucket.mutex.Lock()
+	blocked := discord.bucket.blocked
+	discord.bucket.mutex.Unlock()
+	if d := time.Until(blocked); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// Discord tells how many requests are left and when they are refilled
+func (discord *Discord) updateLimit(resp *http.Response) {
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return
+	}
+	seconds, err := strconv.ParseFloat(resp.Header.Get("X-RateLimit-Reset-After"), 64)
+	if err != nil || seconds <= 0 {
+		return
+	}
+	discord.bucket.mutex.Lock()
+	discord.bucket.blocked = time.Now().Add(time.Duration(seconds * float64(time.Second)))
+	discord.bucket.mutex.Unlock()
+}
+
+func (discord *Discord) Notify(notification Notification) error {
+	body, err := json.Marshal(discordMessage{
+		Username: discord.Username,
+		Embeds:   []discordEmbed{discord.embed(notification)},
+	})
+	if err != nil {
+		return err
+	}
+	discord.wait()
+	req := httpclient.NewRequest(http.MethodPost, nil).SetRawURL(discord.settings.URL).SetPayloadBytes(body)
+	req.SetContentTypeJSON()
+	// 429 responses have Retry-After, retries wait for it
+	resp, err := discord.bucket.client.DoRequestRetry(req, discord.retry)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	discord.updateLimit(resp)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		statusErr := &httpclient.StatusError{Code: resp.StatusCode}
+		var discordErr struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if json.Unmarshal(data, &discordErr) == nil && discordErr.Message != "" {
+			statusErr.Message = fmt.Sprintf("%s: %s", http.StatusText(resp.StatusCode), discordErr.Message)
+		}
+		return statusErr
+	}
+	return nil
+}
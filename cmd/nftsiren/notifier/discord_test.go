@@ -0,0 +1,91 @@
+package notifier
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nftsiren/pkg/httpclient"
+	"nftsiren/pkg/number"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscordEmbed(t *testing.T) {
+	notification := testNotification()
+	notification.ImageURL = "https://example.com/azuki.png"
+	notification.Floor = number.NewFromFloat(4.5)
+	notification.FloorChange = number.NewFromFloat(-3.25)
+	notification.Currency = "ETH"
+	discord := &Discord{}
+	embed := discord.embed(notification)
+	assert.Equal(t, "Azuki", embed.Title)
+	assert.Equal(t, "https://opensea.io/collection/azuki", embed.URL)
+	assert.Equal(t, discordCriticalColor, embed.Color)
+	assert.Equal(t, "https://example.com/azuki.png", embed.Thumbnail.URL)
+	assert.Equal(t, "2023-11-14T22:13:20Z", embed.Timestamp)
+	require.Len(t, embed.Fields, 4)
+	assert.Equal(t, "4.5 ETH", embed.Fields[2].Value)
+	assert.Equal(t, "-3.25%", embed.Fields[3].Value)
+
+	notification.Severity = SeverityInfo
+	notification.Kind = "Gas"
+	assert.Equal(t, 0xe67e22, discord.embed(notification).Color)
+	notification.Kind = ""
+	assert.Equal(t, discordDefaultColor, discord.embed(notification).Color)
+}
+
+func TestDiscordRateLimit(t *testing.T) {
+	var times []time.Time
+	var message discordMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		switch len(times) {
+		case 1:
+			w.Header().Set("Retry-After", "0.05")
+			w.WriteHeader(http.StatusTooManyRequests)
+			io.WriteString(w, `{"message": "You are being rate limited.", "retry_after": 0.05}`)
+		case 2:
+			body, _ := io.ReadAll(r.Body)
+			json.Unmarshal(body, &message)
+			// Bucket is empty after this one
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset-After", "0.05")
+			w.WriteHeader(http.StatusNoContent)
+		case 3:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message": "Unknown Webhook", "code": 10015}`)
+		}
+	}))
+	defer server.Close()
+
+	discord, err := NewDiscord(DiscordSettings{URL: server.URL + "/api/webhooks/1/token"}, "NFTsiren")
+	require.NoError(t, err)
+	discord.retry = httpclient.Retry{Attempts: 2, Backoff: time.Millisecond}
+	require.NoError(t, discord.Notify(testNotification()))
+	require.Len(t, times, 2)
+	assert.GreaterOrEqual(t, times[1].Sub(times[0]), 50*time.Millisecond)
+	assert.Equal(t, "NFTsiren", message.Username)
+	require.Len(t, message.Embeds, 1)
+
+	// Next request waits for the bucket to refill, even from another channel of the same url
+	other, err := NewDiscord(DiscordSettings{URL: server.URL + "/api/webhooks/1/token"}, "NFTsiren")
+	require.NoError(t, err)
+	require.NoError(t, other.Notify(testNotification()))
+	assert.GreaterOrEqual(t, times[2].Sub(times[1]), 40*time.Millisecond)
+
+	err = discord.Notify(testNotification())
+	assert.EqualError(t, err, "Not Found: Unknown Webhook")
+}
+
+func TestDiscordSettingsValidate(t *testing.T) {
+	assert.NoError(t, DiscordSettings{URL: "https://discord.com/api/webhooks/1/token"}.Validate())
+	assert.Error(t, DiscordSettings{URL: "https://discord.com/channels/1"}.Validate())
+	assert.Error(t, DiscordSettings{}.Validate())
+}
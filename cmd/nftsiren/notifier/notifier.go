@@ -0,0 +1,202 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/number"
+)
+
+type Severity int
+
+const (
+	SeverityInfo     Severity = iota // Fired alerts
+	SeverityWarning                  // System alerts
+	SeverityCritical                 // Critical alerts and their repeats
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityInfo:
+		return "Info"
+	case SeverityWarning:
+		return "Warning"
+	case SeverityCritical:
+		return "Critical"
+	}
+	return "UNKNOWN"
+}
+
+// Notification is what channels receive, channels pick the fields they can show
+type Notification struct {
+	Title      string
+	Body       string
+	Severity   Severity
+	Kind       string        // Kind of the alert like in the history
+	AlertID    string        // Empty for system alerts and summaries
+	Condition  string        // Condition of the alert, like Floor < X
+	Collection *alerts.Asset // Nil when it isn't about a collection
+	Name       string        // Name of the collection
+	URL        string        // Marketplace page, empty when there is none
+	Value      number.Number // Triggering value, nil when nothing is compared
+	Time       time.Time
+	Escalation bool // Unacknowledged critical alert, only escalation channels receive it
+	// Details of the collection, empty when they aren't known
+	ImageURL    string
+	Floor       number.Number
+	FloorChange number.Number // Percent within the last 24h
+	Currency    string
+}
+
+// Notifier is a channel which delivers notifications, like desktop notifications or webhooks
+type Notifier interface {
+	// Unique name of the channel, shown to the user
+	Name() string
+	// Delivers the notification, must be safe to call from multiple goroutines
+	Notify(notification Notification) error
+}
+
+// Func wraps a function as a channel
+type Func struct {
+	ChannelName string
+	Send        func(notification Notification) error
+}
+
+func (f Func) Name() string {
+	return f.ChannelName
+}
+
+func (f Func) Notify(notification Notification) error {
+	return f.Send(notification)
+}
+
+// Result of the last delivery of a channel
+type Delivery struct {
+	Time time.Time
+	Err  error // Nil when delivered
+}
+
+// Dispatcher fans notifications out to every channel
+// Escalation channels only receive escalations, the other channels receive everything else
+type Dispatcher struct {
+	mutex       sync.RWMutex
+	channels    []Notifier
+	escalations map[string]bool // Names of the escalation channels
+	deliveries  map[string]Delivery
+	// Called for every failed delivery, may be called from multiple goroutines
+	OnError func(channel string, err error)
+}
+
+func NewDispatcher(channels ...Notifier) *Dispatcher {
+	return &Dispatcher{
+		channels:    channels,
+		escalations: make(map[string]bool),
+		deliveries:  make(map[string]Delivery),
+	}
+}
+
+// Set adds the channel or replaces the one with the same name
+func (dispatcher *Dispatcher) Set(channel Notifier) {
+	dispatcher.set(channel, false)
+}
+
+// SetEscalation is like Set but the channel only receives escalations
+func (dispatcher *Dispatcher) SetEscalation(channel Notifier) {
+	dispatcher.set(channel, true)
+}
+
+func (dispatcher *Dispatcher) set(channel Notifier, escalation bool) {
+	dispatcher.mutex.Lock()
+	defer dispatcher.mutex.Unlock()
+	if escalation {
+		dispatcher.escalations[channel.Name()] = true
+	} else {
+		delete(dispatcher.escalations, channel.Name())
+	}
+	for i, c := range dispatcher.channels {
+		if c.Name() == channel.Name() {
+			dispatcher.channels[i] = channel
+			return
+		}
+	}
+	dispatcher.channels = append(dispatcher.channels, channel)
+}
+
+// Remove removes the channel with the name, does nothing when there is none
+func (dispatcher *Dispatcher) Remove(name string) {
+	dispatcher.mutex.Lock()
+	defer dispatcher.mutex.Unlock()
+	for i, c := range dispatcher.channels {
+		if c.Name() == name {
+			dispatcher.channels = append(dispatcher.channels[:i], dispatcher.channels[i+1:]...)
+			delete(dispatcher.escalations, name)
+			delete(dispatcher.deliveries, name)
+			return
+		}
+	}
+}
+
+// Names of the channels in the order they are added
+func (dispatcher *Dispatcher) Channels() []string {
+	dispatcher.mutex.RLock()
+	defer dispatcher.mutex.RUnlock()
+	names := make([]string, len(dispatcher.channels))
+	for i, c := range dispatcher.channels {
+		names[i] = c.Name()
+	}
+	return names
+}
+
+// Whether the channel only receives escalations
+func (dispatcher *Dispatcher) IsEscalation(name string) bool {
+	dispatcher.mutex.RLock()
+	defer dispatcher.mutex.RUnlock()
+	return dispatcher.escalations[name]
+}
+
+// Last delivery of the channel, false when nothing is sent to it yet
+func (dispatcher *Dispatcher) LastDelivery(name string) (Delivery, bool) {
+	dispatcher.mutex.RLock()
+	defer dispatcher.mutex.RUnlock()
+	delivery, ok := dispatcher.deliveries[name]
+	return delivery, ok
+}
+
+// Dispatch sends the notification to the channels of its kind at the same time and waits for them
+// A slow channel doesn't delay the others, returned error joins the failed deliveries
+func (dispatcher *Dispatcher) Dispatch(notification Notification) error {
+	if notification.Time.IsZero() {
+		notification.Time = time.Now()
+	}
+	dispatcher.mutex.RLock()
+	var channels []Notifier
+	for _, c := range dispatcher.channels {
+		if dispatcher.escalations[c.Name()] == notification.Escalation {
+			channels = append(channels, c)
+		}
+	}
+	dispatcher.mutex.RUnlock()
+	errs := make([]error, len(channels))
+	var wg sync.WaitGroup
+	for i, channel := range channels {
+		wg.Add(1)
+		go func(i int, channel Notifier) {
+			defer wg.Done()
+			err := channel.Notify(notification)
+			dispatcher.mutex.Lock()
+			dispatcher.deliveries[channel.Name()] = Delivery{Time: time.Now(), Err: err}
+			dispatcher.mutex.Unlock()
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", channel.Name(), err)
+				if dispatcher.OnError != nil {
+					dispatcher.OnError(channel.Name(), err)
+				}
+			}
+		}(i, channel)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
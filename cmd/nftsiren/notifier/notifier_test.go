@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mutex    sync.Mutex
+	received []Notification
+}
+
+func (r *recorder) channel(name string, err error) Func {
+	return Func{ChannelName: name, Send: func(notification Notification) error {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		r.received = append(r.received, notification)
+		return err
+	}}
+}
+
+func TestDispatch(t *testing.T) {
+	r := &recorder{}
+	dispatcher := NewDispatcher(r.channel("desktop", nil), r.channel("webhook", errors.New("timeout")))
+	var failed []string
+	dispatcher.OnError = func(channel string, err error) { failed = append(failed, channel) }
+
+	err := dispatcher.Dispatch(Notification{Title: "floor", Severity: SeverityCritical})
+	require.Error(t, err)
+	assert.Equal(t, "webhook: timeout", err.Error())
+	assert.Equal(t, []string{"webhook"}, failed)
+	require.Len(t, r.received, 2)
+	assert.False(t, r.received[0].Time.IsZero())
+
+	delivery, ok := dispatcher.LastDelivery("desktop")
+	assert.True(t, ok)
+	assert.NoError(t, delivery.Err)
+	delivery, ok = dispatcher.LastDelivery("webhook")
+	assert.True(t, ok)
+	assert.EqualError(t, delivery.Err, "timeout")
+
+	// Same name replaces the channel
+	dispatcher.Set(r.channel("webhook", nil))
+	assert.Equal(t, []string{"desktop", "webhook"}, dispatcher.Channels())
+	assert.NoError(t, dispatcher.Dispatch(Notification{Title: "gas"}))
+
+	dispatcher.Remove("webhook")
+	assert.Equal(t, []string{"desktop"}, dispatcher.Channels())
+	_, ok = dispatcher.LastDelivery("webhook")
+	assert.False(t, ok)
+}
+
+func TestDispatchEscalation(t *testing.T) {
+	r := &recorder{}
+	dispatcher := NewDispatcher(r.channel("desktop", nil))
+	dispatcher.SetEscalation(r.channel("discord", nil))
+	assert.True(t, dispatcher.IsEscalation("discord"))
+
+	require.NoError(t, dispatcher.Dispatch(Notification{Title: "floor"}))
+	require.NoError(t, dispatcher.Dispatch(Notification{Title: "unacknowledged", Escalation: true}))
+	_, ok := dispatcher.LastDelivery("desktop")
+	assert.True(t, ok)
+	require.Len(t, r.received, 2)
+	assert.Equal(t, "floor", r.received[0].Title)
+	assert.Equal(t, "unacknowledged", r.received[1].Title)
+
+	// Setting it again as a regular channel receives everything but escalations
+	dispatcher.Set(r.channel("discord", nil))
+	assert.False(t, dispatcher.IsEscalation("discord"))
+	require.NoError(t, dispatcher.Dispatch(Notification{Title: "unacknowledged", Escalation: true}))
+	assert.Len(t, r.received, 2)
+}
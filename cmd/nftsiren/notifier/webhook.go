@@ -0,0 +1,173 @@
+package notifier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/httpclient"
+	"nftsiren/pkg/number"
+)
+
+// Hex HMAC-SHA256 of the body with the secret, like sha256=...
+const SignatureHeader = "X-Nftsiren-Signature"
+
+// Settings of the outgoing webhook
+type WebhookSettings struct {
+	Enabled  bool              `json:"enabled,omitempty"`
+	URL      string            `json:"url"`
+	Template string            `json:"template,omitempty"` // Body of the request, empty sends the payload as json
+	Headers  map[string]string `json:"headers,omitempty"`
+	Secret   string            `json:"secret,omitempty"` // Signs the body when set
+	// Only escalations of unacknowledged critical alerts are posted
+	Escalation bool `json:"escalation,omitempty"`
+}
+
+func (settings WebhookSettings) Validate() error {
+	u, err := url.Parse(settings.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("webhook url must be an http or https url")
+	}
+	_, err = parseTemplate(settings.Template)
+	return err
+}
+
+// WebhookPayload is sent as json, templates are executed with it
+type WebhookPayload struct {
+	Title      string        `json:"title"`
+	Body       string        `json:"body"`
+	Severity   string        `json:"severity"`
+	Kind       string        `json:"kind,omitempty"`
+	AlertID    string        `json:"alertId,omitempty"`
+	Condition  string        `json:"condition,omitempty"`
+	Collection *alerts.Asset `json:"collection,omitempty"`
+	Name       string        `json:"name,omitempty"`
+	Value      number.Number `json:"value"`
+	URL        string        `json:"url,omitempty"`
+	Time       int64         `json:"time"` // Unix time
+	Escalation bool          `json:"escalation,omitempty"`
+}
+
+func NewWebhookPayload(notification Notification) WebhookPayload {
+	return WebhookPayload{
+		Title:      notification.Title,
+		Body:       notification.Body,
+		Severity:   notification.Severity.String(),
+		Kind:       notification.Kind,
+		AlertID:    notification.AlertID,
+		Condition:  notification.Condition,
+		Collection: notification.Collection,
+		Name:       notification.Name,
+		Value:      notification.Value,
+		URL:        notification.URL,
+		Time:       notification.Time.Unix(),
+		Escalation: notification.Escalation,
+	}
+}
+
+// Templates can quote values with json, like {"text": {{json .Title}}}
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// Nil template is returned for empty text
+func parseTemplate(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("webhook").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Webhook posts every notification to a url
+type Webhook struct {
+	settings WebhookSettings
+	template *template.Template
+	client   *httpclient.Client
+	retry    httpclient.Retry
+}
+
+func NewWebhook(settings WebhookSettings) (*Webhook, error) {
+	err := settings.Validate()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, _ := parseTemplate(settings.Template)
+	return &Webhook{
+		settings: settings,
+		template: tmpl,
+		client:   httpclient.NewClient(settings.URL),
+		retry:    httpclient.DefaultRetry,
+	}, nil
+}
+
+func (webhook *Webhook) Name() string {
+	return "Webhook"
+}
+
+// Body renders the request body of the notification
+func (webhook *Webhook) Body(notification Notification) ([]byte, error) {
+	payload := NewWebhookPayload(notification)
+	if webhook.template == nil {
+		return json.Marshal(payload)
+	}
+	var buf bytes.Buffer
+	err := webhook.template.Execute(&buf, payload)
+	if err != nil {
+		return nil, fmt.Errorf("template failed: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Sign returns the signature header value of the body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (webhook *Webhook) Notify(notification Notification) error {
+	body, err := webhook.Body(notification)
+	if err != nil {
+		return err
+	}
+	req := httpclient.NewRequest(http.MethodPost, nil).SetRawURL(webhook.settings.URL).SetPayloadBytes(body)
+	req.SetContentTypeJSON()
+	for key, value := range webhook.settings.Headers {
+		req.SetHeader(key, value)
+	}
+	if webhook.settings.Secret != "" {
+		req.SetHeader(SignatureHeader, Sign(webhook.settings.Secret, body))
+	}
+	resp, err := webhook.client.DoRequestRetry(req, webhook.retry)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Short error bodies help with fixing templates
+		statusErr := &httpclient.StatusError{Code: resp.StatusCode}
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		if text := strings.TrimSpace(string(message)); text != "" {
+			statusErr.Message = fmt.Sprintf("%s: %s", http.StatusText(resp.StatusCode), text)
+		}
+		return statusErr
+	}
+	return nil
+}
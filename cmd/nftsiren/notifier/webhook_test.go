@@ -0,0 +1,105 @@
+package notifier
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/httpclient"
+	"nftsiren/pkg/nft"
+	"nftsiren/pkg/number"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNotification() Notification {
+	return Notification{
+		Title:      "NFTsiren | Azuki",
+		Body:       "Floor is below 5",
+		Severity:   SeverityCritical,
+		Kind:       "Collection",
+		AlertID:    "a1",
+		Condition:  "Floor < X",
+		Collection: &alerts.Asset{Market: nft.Opensea, Symbol: "azuki"},
+		Name:       "Azuki",
+		Value:      number.NewFromInt(4),
+		URL:        "https://opensea.io/collection/azuki",
+		Time:       time.Unix(1700000000, 0),
+	}
+}
+
+func TestWebhookNotify(t *testing.T) {
+	var requests []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		if len(requests) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhook, err := NewWebhook(WebhookSettings{
+		URL:     server.URL + "/hook?team=ops",
+		Headers: map[string]string{"X-Team": "ops"},
+		Secret:  "secret",
+	})
+	require.NoError(t, err)
+	webhook.retry = httpclient.Retry{Attempts: 2, Backoff: time.Millisecond}
+	require.NoError(t, webhook.Notify(testNotification()))
+
+	// Failed request is retried with the same body
+	require.Len(t, requests, 2)
+	assert.Equal(t, bodies[0], bodies[1])
+	req := requests[1]
+	assert.Equal(t, "/hook", req.URL.Path)
+	assert.Equal(t, "ops", req.URL.Query().Get("team"))
+	assert.Equal(t, "ops", req.Header.Get("X-Team"))
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, Sign("secret", bodies[1]), req.Header.Get(SignatureHeader))
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(bodies[1], &payload))
+	assert.Equal(t, "Critical", payload["severity"])
+	assert.Equal(t, "4", payload["value"])
+	assert.Equal(t, "https://opensea.io/collection/azuki", payload["url"])
+	assert.Equal(t, map[string]any{"market": "Opensea", "symbol": "azuki"}, payload["collection"])
+	assert.Equal(t, float64(1700000000), payload["time"])
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	webhook, err := NewWebhook(WebhookSettings{
+		URL:      "https://example.com/hook",
+		Template: `{"text": {{json .Title}}, "value": "{{.Value}}"}`,
+	})
+	require.NoError(t, err)
+	body, err := webhook.Body(testNotification())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text": "NFTsiren | Azuki", "value": "4"}`, string(body))
+
+	_, err = NewWebhook(WebhookSettings{URL: "https://example.com", Template: "{{.Title"})
+	assert.Error(t, err)
+	_, err = NewWebhook(WebhookSettings{URL: "example.com/hook"})
+	assert.Error(t, err)
+}
+
+func TestWebhookStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, "bad template\n")
+	}))
+	defer server.Close()
+	webhook, err := NewWebhook(WebhookSettings{URL: server.URL})
+	require.NoError(t, err)
+	err = webhook.Notify(testNotification())
+	assert.EqualError(t, err, "Bad Request: bad template")
+}
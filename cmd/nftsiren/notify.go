@@ -0,0 +1,16 @@
+package main
+
+import (
+	"nftsiren/cmd/nftsiren/notifier"
+
+	"gioui.org/x/notify"
+)
+
+// Desktop notifications on desktop and system notifications on mobile
+var DesktopNotifier = notifier.Func{
+	ChannelName: "Desktop",
+	Send: func(notification notifier.Notification) error {
+		_, err := notify.Push(notification.Title, notification.Body)
+		return err
+	},
+}
@@ -109,9 +109,12 @@ func (page *CollectionCreationPage) AddCollection() error {
 		// Maybe user directly entered collection slug or address and not URL
 		symbol = urlstr
 	}
+	if page.Daemon.HasCollection(market, symbol) {
+		return errors.New("this collection is already in the list")
+	}
 	collection := NewCollection(page.Daemon, market, symbol)
-	ok = page.Daemon.AddCollection(collection)
-	if !ok {
+	// Another add may have won the race since the check above
+	if !page.Daemon.AddCollection(collection) {
 		return errors.New("this collection is already in the list")
 	}
 	return nil
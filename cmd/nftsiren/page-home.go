@@ -1,10 +1,10 @@
 package main
 
 import (
+	"nftsiren/cmd/nftsiren/alertengine"
 	"nftsiren/cmd/nftsiren/alerts"
 	"nftsiren/pkg/bench"
 	"nftsiren/pkg/log"
-	"nftsiren/pkg/number"
 
 	"gioui.org/layout"
 	"gioui.org/widget"
@@ -17,6 +17,8 @@ type HomePage struct {
 	List     widget.List
 	Ethereum AlertListState
 	Gas      AlertListState
+	Pairs    AlertListState
+	Compound AlertListState
 }
 
 func NewHomePage(daemon *Daemon) *HomePage {
@@ -27,40 +29,119 @@ func NewHomePage(daemon *Daemon) *HomePage {
 	// init ethereum alerts
 	page.Ethereum.Title = "Ethereum Alerts"
 	page.Ethereum.AlertCreationPage = NewAlertCreationPage("New Ethereum Alert",
-		func(t alerts.Condition, n number.Number, loop bool) {
-			params := alerts.EthereumAlert{
-				Type: t.(alerts.EthereumAlertType),
-				Base: n,
-				Loop: loop,
-			}
-			alert := NewEthAlert(params, page.Daemon)
+		func(input AlertInput) {
+			alert := NewEthAlert(ethereumAlertParams("", input), page.Daemon)
 			go page.Daemon.AddEthAlert(alert)
 		},
 		[]alerts.Condition{
 			alerts.EthereumAlertTypeLessThan,
 			alerts.EthereumAlertTypeGreaterThan,
+			alerts.EthereumAlertTypeDroppedBy,
+			alerts.EthereumAlertTypeRoseBy,
 		}...,
 	)
+	page.Ethereum.AlertCreationPage.EnableExpressions(alerts.EthGasFields, func(expr string, input AlertInput) {
+		alert := NewEthAlert(ethereumAlertParams(expr, input), page.Daemon)
+		go page.Daemon.AddEthAlert(alert)
+	})
+	page.Ethereum.AlertCreationPage.EnableBacktest(func(expr string, input AlertInput) alertengine.Backtest {
+		return page.Daemon.GasTracker.BacktestEth(ethereumAlertParams(expr, input))
+	})
 	// init gas alerts
 	page.Gas.Title = "Gas Alerts"
 	page.Gas.AlertCreationPage = NewAlertCreationPage("New Gas Alert",
-		func(t alerts.Condition, n number.Number, loop bool) {
-			params := alerts.GasAlert{
-				Type: t.(alerts.GasAlertType),
-				Base: n,
-				Loop: loop,
-			}
-			alert := NewGasAlert(params, page.Daemon)
+		func(input AlertInput) {
+			alert := NewGasAlert(gasAlertParams("", input), page.Daemon)
 			go page.Daemon.AddGasAlert(alert)
 		},
 		[]alerts.Condition{
 			alerts.GasAlertTypeLessThan,
 			alerts.GasAlertTypeGreaterThan,
+			alerts.GasAlertTypeDroppedBy,
+			alerts.GasAlertTypeRoseBy,
+		}...,
+	)
+	page.Gas.AlertCreationPage.EnableTiers()
+	page.Gas.AlertCreationPage.EnableExpressions(alerts.EthGasFields, func(expr string, input AlertInput) {
+		alert := NewGasAlert(gasAlertParams(expr, input), page.Daemon)
+		go page.Daemon.AddGasAlert(alert)
+	})
+	page.Gas.AlertCreationPage.EnableBacktest(func(expr string, input AlertInput) alertengine.Backtest {
+		return page.Daemon.GasTracker.BacktestGas(gasAlertParams(expr, input))
+	})
+	// init pair alerts
+	page.Pairs.Title = "Pair Alerts"
+	page.Pairs.AlertCreationPage = NewAlertCreationPage("New Pair Alert",
+		func(input AlertInput) {
+			params := alerts.PairAlert{
+				Type:    input.Type.(alerts.PairAlertType),
+				A:       input.A,
+				B:       input.B,
+				Base:    input.Value,
+				Loop:    input.Loop,
+				Trigger: input.Trigger,
+			}
+			alert := NewPairAlert(params, page.Daemon)
+			go page.Daemon.AddPairAlert(alert)
+		},
+		[]alerts.Condition{
+			alerts.PairAlertTypeRatioGreaterThan,
+			alerts.PairAlertTypeRatioLessThan,
+			alerts.PairAlertTypeDiffGreaterThan,
+			alerts.PairAlertTypeDiffLessThan,
+		}...,
+	)
+	page.Pairs.AlertCreationPage.EnablePairs(page.Daemon.Assets)
+	// init compound alerts
+	page.Compound.Title = "Compound Alerts"
+	page.Compound.AlertCreationPage = NewAlertCreationPage("New Compound Alert",
+		func(input AlertInput) {
+			params := alerts.CompoundAlert{
+				Type:    input.Type.(alerts.CompoundAlertType),
+				Clauses: input.Clauses,
+				Loop:    input.Loop,
+				Trigger: input.Trigger,
+			}
+			alert := NewCompoundAlert(params, page.Daemon)
+			go page.Daemon.AddCompoundAlert(alert)
+		},
+		[]alerts.Condition{
+			alerts.CompoundAlertTypeAll,
+			alerts.CompoundAlertTypeAny,
 		}...,
 	)
+	page.Compound.AlertCreationPage.EnableClauses(page.Daemon.CollectionAssets)
 	return page
 }
 
+// Builds the alert from the creation page input, expr is only set in advanced mode
+func ethereumAlertParams(expr string, input AlertInput) alerts.EthereumAlert {
+	if expr != "" {
+		return alerts.EthereumAlert{Type: alerts.EthereumAlertTypeExpression, Expr: expr, Loop: input.Loop, Trigger: input.Trigger}
+	}
+	return alerts.EthereumAlert{
+		Type:    input.Type.(alerts.EthereumAlertType),
+		Base:    input.Value,
+		Intv:    input.Interval,
+		Loop:    input.Loop,
+		Trigger: input.Trigger,
+	}
+}
+
+func gasAlertParams(expr string, input AlertInput) alerts.GasAlert {
+	if expr != "" {
+		return alerts.GasAlert{Type: alerts.GasAlertTypeExpression, Expr: expr, Loop: input.Loop, Trigger: input.Trigger}
+	}
+	return alerts.GasAlert{
+		Type:    input.Type.(alerts.GasAlertType),
+		Base:    input.Value,
+		Intv:    input.Interval,
+		Tier:    input.Tier,
+		Loop:    input.Loop,
+		Trigger: input.Trigger,
+	}
+}
+
 func (page *HomePage) Title() string {
 	return "Home"
 }
@@ -83,6 +164,12 @@ func (page *HomePage) Layout(gtx layout.Context, theme *Theme, pages *PageStack)
 		func(gtx layout.Context) layout.Dimensions {
 			return page.layoutGasAlertList(gtx, theme, pages)
 		},
+		func(gtx layout.Context) layout.Dimensions {
+			return page.Pairs.Layout(gtx, theme, pages, page.Daemon.pairAlerts)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return page.Compound.Layout(gtx, theme, pages, page.Daemon.compoundAlerts)
+		},
 	)
 }
 
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/cmd/nftsiren/escalation"
+	"nftsiren/cmd/nftsiren/history"
+	"nftsiren/pkg/bench"
+	"nftsiren/pkg/log"
+
+	"gioui.org/font"
+	"gioui.org/layout"
+	"gioui.org/text"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// NotificationsPage lists fired alerts, they are marked as read when leaving
+type NotificationsPage struct {
+	Daemon *Daemon
+	List   widget.List
+	// Filters
+	Kind        widget.Enum // Empty value is every kind
+	Collection  widget.Enum // Empty value is every collection
+	collections []alerts.Asset
+	// Buttons
+	MarkAllRead    widget.Clickable
+	Clear          widget.Clickable
+	AcknowledgeAll widget.Clickable
+	links          map[string]*widget.Clickable // Marketplace links by entry id
+	acknowledge    map[string]*widget.Clickable // Acknowledge buttons of critical alerts by entry id
+	Error          error
+	// Entry widgets are cached until the history or the filter changes
+	entries    []layout.Widget
+	entriesKey entriesKey
+}
+
+type entriesKey struct {
+	version    uint64 // History version
+	kind       string
+	collection string
+	theme      *Theme
+}
+
+func NewNotificationsPage(daemon *Daemon) *NotificationsPage {
+	page := &NotificationsPage{
+		Daemon:      daemon,
+		links:       make(map[string]*widget.Clickable),
+		acknowledge: make(map[string]*widget.Clickable),
+	}
+	page.List.Axis = layout.Vertical
+	return page
+}
+
+func (page *NotificationsPage) Title() string {
+	return "Notifications"
+}
+
+func (page *NotificationsPage) Entering() {
+	page.collections = page.Daemon.History.Collections()
+	page.Error = nil
+	page.entries = nil
+}
+
+func (page *NotificationsPage) Leaving() {
+	err := page.Daemon.History.MarkAllRead()
+	if err != nil {
+		log.Error().Println("Failed to save alert history:", err)
+	}
+}
+
+func (page *NotificationsPage) filter() history.Filter {
+	filter := history.Filter{Kind: page.Kind.Value}
+	for i := range page.collections {
+		if page.collections[i].String() == page.Collection.Value {
+			filter.Collection = &page.collections[i]
+		}
+	}
+	return filter
+}
+
+func (page *NotificationsPage) link(id string) *widget.Clickable {
+	return clickableOf(page.links, id)
+}
+
+func clickableOf(buttons map[string]*widget.Clickable, id string) *widget.Clickable {
+	button, ok := buttons[id]
+	if !ok {
+		button = new(widget.Clickable)
+		buttons[id] = button
+	}
+	return button
+}
+
+func (page *NotificationsPage) Layout(gtx layout.Context, theme *Theme, pages *PageStack) layout.Dimensions {
+	defer bench.Begin()()
+
+	if page.MarkAllRead.Clicked() {
+		page.Error = page.Daemon.History.MarkAllRead()
+	}
+	if page.Clear.Clicked() {
+		page.Error = page.Daemon.History.Clear()
+		page.collections = nil
+		page.Collection.Value = ""
+	}
+	if page.AcknowledgeAll.Clicked() {
+		page.Daemon.Escalations.AcknowledgeAll()
+		page.acknowledge = make(map[string]*widget.Clickable)
+	}
+
+	items := []layout.Widget{
+		// Buttons
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween}.Layout(gtx,
+				layout.Rigid(theme.Button("Mark all read", &page.MarkAllRead, RegularButton).Layout),
+				layout.Rigid(theme.Button("Clear", &page.Clear, ErrorButton).Layout),
+			)
+		},
+		// Kind filter
+		func(gtx layout.Context) layout.Dimensions {
+			children := []layout.FlexChild{
+				layout.Rigid(material.RadioButton(theme.Material(), &page.Kind, "", "All").Layout),
+			}
+			for _, kind := range history.Kinds {
+				children = append(children, layout.Rigid(material.RadioButton(theme.Material(), &page.Kind, kind, kind).Layout))
+			}
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, children...)
+		},
+	}
+	// Critical alerts waiting for acknowledgement
+	pending := page.Daemon.Escalations.Pending()
+	// Buttons are created for pending alerts only, more of them means some are acknowledged elsewhere
+	if len(page.acknowledge) > len(pending) {
+		page.prunePending(pending)
+	}
+	if len(pending) > 0 {
+		items = append(items, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Spacing: layout.SpaceBetween, Alignment: layout.Middle}.Layout(gtx,
+				layout.Rigid(material.Body1(theme.Material(), fmt.Sprintf("%d critical alerts repeating", len(pending))).Layout),
+				layout.Rigid(theme.Button("Acknowledge all", &page.AcknowledgeAll, ErrorButton).Layout),
+			)
+		})
+		for _, p := range pending {
+			p := p
+			items = append(items, func(gtx layout.Context) layout.Dimensions {
+				return page.layoutPending(gtx, theme, p)
+			})
+		}
+	}
+	// Collection filter
+	if len(page.collections) > 0 {
+		items = append(items, material.RadioButton(theme.Material(), &page.Collection, "", "All collections").Layout)
+		for _, asset := range page.collections {
+			items = append(items, material.RadioButton(theme.Material(), &page.Collection, asset.String(), asset.String()).Layout)
+		}
+	}
+	// Error
+	if page.Error != nil {
+		items = append(items, func(gtx layout.Context) layout.Dimensions {
+			label := material.Caption(theme.Material(), page.Error.Error())
+			label.Color = theme.Error
+			label.Alignment = text.Middle
+			return label.Layout(gtx)
+		})
+	}
+	// Entries
+	items = append(items, page.entryWidgets(theme)...)
+
+	return theme.LayoutListSpaced(gtx, &page.List, theme.MediumVSpacer, items...)
+}
+
+// Returns the cached entry widgets, they are rebuilt only when the history or the filter changes
+func (page *NotificationsPage) entryWidgets(theme *Theme) []layout.Widget {
+	key := entriesKey{
+		version:    page.Daemon.History.Version(),
+		kind:       page.Kind.Value,
+		collection: page.Collection.Value,
+		theme:      theme,
+	}
+	if page.entries != nil && key == page.entriesKey {
+		return page.entries
+	}
+	page.entriesKey = key
+	entries := page.Daemon.History.Entries(page.filter())
+	page.entries = make([]layout.Widget, 0, max(len(entries), 1))
+	if len(entries) == 0 {
+		page.entries = append(page.entries, func(gtx layout.Context) layout.Dimensions {
+			label := material.Body2(theme.Material(), "No notifications")
+			label.Color = theme.MediumImpFg
+			label.Alignment = text.Middle
+			return label.Layout(gtx)
+		})
+	}
+	// Links of the entries which aren't shown anymore are dropped
+	links := make(map[string]*widget.Clickable)
+	for _, entry := range entries {
+		entry := entry
+		if link, ok := page.links[entry.ID]; ok {
+			links[entry.ID] = link
+		}
+		page.entries = append(page.entries, func(gtx layout.Context) layout.Dimensions {
+			return page.layoutEntry(gtx, theme, entry)
+		})
+	}
+	page.links = links
+	return page.entries
+}
+
+// Drops the acknowledge buttons of the alerts which aren't pending anymore
+func (page *NotificationsPage) prunePending(pending []escalation.Pending) {
+	ids := make(map[string]bool, len(pending))
+	for _, p := range pending {
+		ids[p.ID] = true
+	}
+	for id := range page.acknowledge {
+		if !ids[id] {
+			delete(page.acknowledge, id)
+		}
+	}
+}
+
+func (page *NotificationsPage) layoutEntry(gtx layout.Context, theme *Theme, entry history.Entry) layout.Dimensions {
+	url := entry.URL()
+	var link *widget.Clickable
+	if url != "" {
+		link = page.link(entry.ID)
+		if link.Clicked() {
+			page.Error = OpenURL(url)
+			if page.Error == nil {
+				page.Error = page.Daemon.History.MarkRead(entry.ID)
+			}
+		}
+	}
+	return theme.Background(gtx, theme.DarkerBg, func(gtx layout.Context) layout.Dimensions {
+		return theme.SmallInset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				// title
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					label := material.Body1(theme.Material(), entry.Title)
+					if !entry.Read {
+						label.Font.Weight = font.Bold
+					}
+					return label.Layout(gtx)
+				}),
+				// text
+				layout.Rigid(material.Body2(theme.Material(), entry.Text).Layout),
+				// time, kind and value
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					info := fmt.Sprintf("%s | %s", time.Unix(entry.Time, 0).Format("Jan 2 15:04"), entry.Kind)
+					if entry.Name != "" {
+						info += " | " + entry.Name
+					}
+					if !entry.Value.IsNil() {
+						info += " | value " + entry.Value.String()
+					}
+					label := material.Caption(theme.Material(), info)
+					label.Color = theme.MediumImpFg
+					return label.Layout(gtx)
+				}),
+				// marketplace link
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if link == nil {
+						return layout.Dimensions{}
+					}
+					return theme.Hyperlink("Open marketplace page", link)(gtx)
+				}),
+			)
+		})
+	})
+}
+
+func (page *NotificationsPage) layoutPending(gtx layout.Context, theme *Theme, pending escalation.Pending) layout.Dimensions {
+	button := clickableOf(page.acknowledge, pending.ID)
+	if button.Clicked() {
+		page.Daemon.Escalations.Acknowledge(pending.ID)
+		delete(page.acknowledge, pending.ID)
+	}
+	return theme.Background(gtx, theme.DarkerBg, func(gtx layout.Context) layout.Dimensions {
+		return theme.SmallInset.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							label := material.Body1(theme.Material(), pending.Title)
+							label.Color = theme.Error
+							label.Font.Weight = font.Bold
+							return label.Layout(gtx)
+						}),
+						layout.Rigid(material.Body2(theme.Material(), pending.Text).Layout),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							info := fmt.Sprintf("Fired %s | repeated %d times", time.Unix(pending.Fired, 0).Format("Jan 2 15:04"), pending.Repeats)
+							label := material.Caption(theme.Material(), info)
+							label.Color = theme.MediumImpFg
+							return label.Layout(gtx)
+						}),
+					)
+				}),
+				layout.Rigid(theme.MediumHSpacer.Layout),
+				layout.Rigid(theme.Button("Acknowledge", button, RegularButton).Layout),
+			)
+		})
+	})
+}
@@ -1,14 +1,25 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"nftsiren/cmd/nftsiren/alerts"
 	"nftsiren/cmd/nftsiren/config"
+	"nftsiren/cmd/nftsiren/escalation"
+	"nftsiren/cmd/nftsiren/history"
+	"nftsiren/cmd/nftsiren/notifier"
 	"nftsiren/cmd/nftsiren/widgets"
+	"nftsiren/pkg/apis"
 	"nftsiren/pkg/bench"
+	"nftsiren/pkg/httpclient"
 	"nftsiren/pkg/log"
+	"nftsiren/pkg/mutex"
 
-	"gioui.org/io/key"
 	"gioui.org/layout"
 	"gioui.org/text"
 	"gioui.org/widget"
@@ -21,11 +32,24 @@ type SettingsPage struct {
 	// State
 	List widget.List
 	// Settings
-	NotificationCooldown component.TextField
-	StartInBackground    widget.Bool // Silent start
-	Autostart            widget.Bool
+	StartInBackground widget.Bool // Silent start
+	Autostart         widget.Bool
 	// Api key entries
 	ApiKeys widget.Clickable
+	// Proxy and certificates
+	Network widget.Clickable
+	// Do not disturb and schedule
+	QuietHours widget.Clickable
+	// Retention of the notification center
+	History widget.Clickable
+	// Stale data and provider failure alerts
+	Health widget.Clickable
+	// Repeats of critical alerts
+	Escalation widget.Clickable
+	// Outgoing webhook channel
+	Webhook widget.Clickable
+	// Discord channel
+	Discord widget.Clickable
 	// Buttons
 	// Save     widget.Clickable
 	// SaveText string
@@ -41,10 +65,6 @@ func NewSettingsPage(daemon *Daemon) *SettingsPage {
 
 	settings.List.Axis = layout.Vertical
 
-	settings.NotificationCooldown.SingleLine = true
-	settings.NotificationCooldown.Filter = "0123456789"
-	settings.NotificationCooldown.InputHint = key.HintNumeric
-
 	return settings
 }
 
@@ -55,8 +75,6 @@ func (page *SettingsPage) Title() string {
 func (page *SettingsPage) Entering() {
 	log.Debug().Println("Loading preferences")
 	// Load user setting
-	cooldown := config.LoadFallback[int]("notificationCooldown", 60)
-	page.NotificationCooldown.SetText(strconv.Itoa(cooldown))
 	page.StartInBackground.Value = config.LoadFallback[bool]("startInBackground", false)
 	page.Autostart.Value = config.GetAutostart()
 }
@@ -64,7 +82,6 @@ func (page *SettingsPage) Entering() {
 func (page *SettingsPage) Leaving() {
 	log.Debug().Println("Saving preferences")
 	// Save settings
-	config.Store("notificationCooldown", mustParseNumber(page.NotificationCooldown.Text()))
 	config.Store("startInBackground", page.StartInBackground.Value)
 	config.SetAutostart(page.Autostart.Value)
 }
@@ -74,10 +91,6 @@ func (page *SettingsPage) Layout(gtx layout.Context, theme *Theme, pages *PageSt
 
 	items := []layout.Widget{}
 
-	items = append(items, func(gtx layout.Context) layout.Dimensions {
-		return page.NotificationCooldown.Layout(gtx, theme.Material(), "Notification cooldown in seconds")
-	})
-
 	if isDesktop() {
 		// Start in background
 		items = append(items, material.CheckBox(theme.Material(), &page.StartInBackground,
@@ -92,6 +105,48 @@ func (page *SettingsPage) Layout(gtx layout.Context, theme *Theme, pages *PageSt
 		pages.Push(&ApiKeysPage{Daemon: page.Daemon})
 	}
 	items = append(items, theme.Hyperlink("Api Keys", &page.ApiKeys))
+
+	// Network button
+	if page.Network.Clicked() {
+		pages.Push(NewNetworkSettingsPage(page.Daemon))
+	}
+	items = append(items, theme.Hyperlink("Network", &page.Network))
+
+	// Quiet hours button
+	if page.QuietHours.Clicked() {
+		pages.Push(NewQuietHoursPage(page.Daemon))
+	}
+	items = append(items, theme.Hyperlink("Quiet Hours", &page.QuietHours))
+
+	// Notification history button
+	if page.History.Clicked() {
+		pages.Push(NewHistorySettingsPage(page.Daemon))
+	}
+	items = append(items, theme.Hyperlink("Notification History", &page.History))
+
+	// System alerts button
+	if page.Health.Clicked() {
+		pages.Push(NewHealthSettingsPage(page.Daemon))
+	}
+	items = append(items, theme.Hyperlink("System Alerts", &page.Health))
+
+	// Critical alerts button
+	if page.Escalation.Clicked() {
+		pages.Push(NewEscalationSettingsPage(page.Daemon))
+	}
+	items = append(items, theme.Hyperlink("Critical Alerts", &page.Escalation))
+
+	// Webhook button
+	if page.Webhook.Clicked() {
+		pages.Push(NewWebhookSettingsPage(page.Daemon))
+	}
+	items = append(items, theme.Hyperlink("Webhook", &page.Webhook))
+
+	// Discord button
+	if page.Discord.Clicked() {
+		pages.Push(NewDiscordSettingsPage(page.Daemon))
+	}
+	items = append(items, theme.Hyperlink("Discord", &page.Discord))
 	// items = append(items, func(gtx layout.Context) layout.Dimensions {
 	// 	return page.ApiKeys.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 	// 		pointer.CursorPointer.Add(gtx.Ops)
@@ -141,14 +196,6 @@ func (page *SettingsPage) Layout(gtx layout.Context, theme *Theme, pages *PageSt
 	return theme.LayoutListSpaced(gtx, &page.List, theme.MediumVSpacer, items...)
 }
 
-func mustParseNumber(s string) int {
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		panic(err)
-	}
-	return i
-}
-
 type ApiKeys struct {
 	Etherscan  string `json:"etherscan,omitempty"`
 	Opensea    string `json:"opensea,omitempty"`
@@ -233,6 +280,861 @@ func (apiKeys *ApiKeysPage) Layout(gtx layout.Context, theme *Theme, pages *Page
 	return theme.LayoutListSpaced(gtx, &apiKeys.List, theme.MediumVSpacer, items...)
 }
 
+type NetworkSettings struct {
+	Proxy      string `json:"proxy,omitempty"`
+	RootCAFile string `json:"rootCAFile,omitempty"`
+	UserAgent  string `json:"userAgent,omitempty"`
+	MockServer string `json:"mockServer,omitempty"` // Root url of nftsiren-mock, replaces every provider when set
+}
+
+func (settings NetworkSettings) Transport() httpclient.TransportSettings {
+	return httpclient.TransportSettings{
+		Proxy:      settings.Proxy,
+		RootCAFile: settings.RootCAFile,
+		UserAgent:  settings.UserAgent,
+	}
+}
+
+type NetworkSettingsPage struct {
+	Daemon     *Daemon
+	List       widget.List
+	Proxy      component.TextField
+	RootCAFile component.TextField
+	UserAgent  component.TextField
+	MockServer component.TextField
+	Error      error
+	Ok         widget.Clickable
+}
+
+func NewNetworkSettingsPage(daemon *Daemon) *NetworkSettingsPage {
+	page := &NetworkSettingsPage{
+		Daemon: daemon,
+	}
+	page.List.Axis = layout.Vertical
+	page.Proxy.SingleLine = true
+	page.RootCAFile.SingleLine = true
+	page.UserAgent.SingleLine = true
+	page.MockServer.SingleLine = true
+	return page
+}
+
+func (page *NetworkSettingsPage) Title() string {
+	return "Network"
+}
+
+func (page *NetworkSettingsPage) Entering() {
+	settings := config.LoadFallback("network", NetworkSettings{})
+	page.Proxy.SetText(settings.Proxy)
+	page.RootCAFile.SetText(settings.RootCAFile)
+	page.UserAgent.SetText(settings.UserAgent)
+	page.MockServer.SetText(settings.MockServer)
+	page.Error = nil
+}
+
+func (page *NetworkSettingsPage) Leaving() {}
+
+func (page *NetworkSettingsPage) Layout(gtx layout.Context, theme *Theme, pages *PageStack) layout.Dimensions {
+	if page.Ok.Clicked() {
+		err := page.Save()
+		if err != nil {
+			page.Error = err
+		} else {
+			pages.Pop()
+		}
+	}
+	return theme.LayoutForm(gtx, &page.List, &page.Ok,
+		func(gtx layout.Context) layout.Dimensions {
+			return page.Proxy.Layout(gtx, theme.Material(), "Proxy (http://, https:// or socks5://)")
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return page.RootCAFile.Layout(gtx, theme.Material(), "Custom root CA file (PEM)")
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return page.UserAgent.Layout(gtx, theme.Material(), "User agent")
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return page.MockServer.Layout(gtx, theme.Material(), "Mock server url (testing only)")
+		},
+		// Error
+		func(gtx layout.Context) layout.Dimensions {
+			if page.Error == nil {
+				return layout.Dimensions{}
+			}
+			label := material.Caption(theme.Material(), page.Error.Error())
+			label.Color = theme.Error
+			label.Alignment = text.Middle
+			return label.Layout(gtx)
+		},
+	)
+}
+
+// Validates and applies the settings, nothing is stored on error
+func (page *NetworkSettingsPage) Save() error {
+	settings := NetworkSettings{
+		Proxy:      strings.TrimSpace(page.Proxy.Text()),
+		RootCAFile: strings.TrimSpace(page.RootCAFile.Text()),
+		UserAgent:  strings.TrimSpace(page.UserAgent.Text()),
+		MockServer: strings.TrimSpace(page.MockServer.Text()),
+	}
+	// Validate everything first so invalid settings change nothing
+	_, err := httpclient.NewTransport(settings.Transport())
+	if err != nil {
+		return err
+	}
+	err = apis.ValidateMockServer(settings.MockServer)
+	if err != nil {
+		return err
+	}
+	err = httpclient.Configure(settings.Transport())
+	if err != nil {
+		return err
+	}
+	err = apis.SetMockServer(settings.MockServer)
+	if err != nil {
+		return err
+	}
+	config.Store("network", settings)
+	return nil
+}
+
+type QuietHoursPage struct {
+	Daemon       *Daemon
+	List         widget.List
+	DoNotDisturb widget.Bool
+	Enabled      widget.Bool // Global schedule
+	Mode         widget.Enum // quiet or active
+	Ranges       component.TextField
+	Days         [7]widget.Bool // Indexed by time.Weekday
+	TimeZone     component.TextField
+	Error        error
+	Ok           widget.Clickable
+}
+
+func NewQuietHoursPage(daemon *Daemon) *QuietHoursPage {
+	page := &QuietHoursPage{
+		Daemon: daemon,
+	}
+	page.List.Axis = layout.Vertical
+	page.Ranges.SingleLine = true
+	page.Ranges.Filter = "0123456789:-, "
+	page.TimeZone.SingleLine = true
+	return page
+}
+
+func (page *QuietHoursPage) Title() string {
+	return "Quiet Hours"
+}
+
+func (page *QuietHoursPage) Entering() {
+	settings := page.Daemon.QuietHours.Settings()
+	page.DoNotDisturb.Value = settings.DoNotDisturb
+	page.Enabled.Value = settings.Schedule != nil
+	schedule := alerts.Schedule{Quiet: true}
+	if settings.Schedule != nil {
+		schedule = *settings.Schedule
+	}
+	page.Mode.Value = "active"
+	if schedule.Quiet {
+		page.Mode.Value = "quiet"
+	}
+	page.Ranges.SetText(alerts.FormatTimeRanges(schedule.Ranges))
+	for i := range page.Days {
+		page.Days[i].Value = len(schedule.Days) == 0
+	}
+	for _, day := range schedule.Days {
+		page.Days[day].Value = true
+	}
+	page.TimeZone.SetText(schedule.TimeZone)
+	page.Error = nil
+}
+
+func (page *QuietHoursPage) Leaving() {}
+
+func (page *QuietHoursPage) Layout(gtx layout.Context, theme *Theme, pages *PageStack) layout.Dimensions {
+	if page.Ok.Clicked() {
+		err := page.Save()
+		if err != nil {
+			page.Error = err
+		} else {
+			pages.Pop()
+		}
+	}
+	items := []layout.Widget{
+		material.CheckBox(theme.Material(), &page.DoNotDisturb, "Do not disturb. Notifications are held until this is turned off.").Layout,
+		material.CheckBox(theme.Material(), &page.Enabled, "Schedule").Layout,
+	}
+	if page.Enabled.Value {
+		items = append(items,
+			material.RadioButton(theme.Material(), &page.Mode, "quiet", "Quiet within the hours").Layout,
+			material.RadioButton(theme.Material(), &page.Mode, "active", "Only active within the hours").Layout,
+			func(gtx layout.Context) layout.Dimensions {
+				return page.Ranges.Layout(gtx, theme.Material(), "Hours, like 23:00-07:00 (empty is all day)")
+			},
+		)
+		for i := range page.Days {
+			items = append(items, material.CheckBox(theme.Material(), &page.Days[i], time.Weekday(i).String()).Layout)
+		}
+		items = append(items, func(gtx layout.Context) layout.Dimensions {
+			return page.TimeZone.Layout(gtx, theme.Material(), "Time zone, like Europe/Istanbul (empty is local)")
+		})
+	}
+	// Held notifications
+	if queued := page.Daemon.QuietHours.Queued(); queued > 0 {
+		items = append(items, func(gtx layout.Context) layout.Dimensions {
+			label := material.Caption(theme.Material(), fmt.Sprintf("%d notifications are held", queued))
+			label.Color = theme.MediumImpFg
+			return label.Layout(gtx)
+		})
+	}
+	// Channels and their last deliveries
+	items = append(items, material.Body1(theme.Material(), "Channels").Layout)
+	for _, name := range page.Daemon.Notifier.Channels() {
+		status := name + ": nothing sent yet"
+		color := theme.MediumImpFg
+		if delivery, ok := page.Daemon.Notifier.LastDelivery(name); ok {
+			status = fmt.Sprintf("%s: delivered %s", name, delivery.Time.Format("Jan 2 15:04"))
+			if delivery.Err != nil {
+				status = fmt.Sprintf("%s: failed %s, %v", name, delivery.Time.Format("Jan 2 15:04"), delivery.Err)
+				color = theme.Error
+			}
+		}
+		label := material.Caption(theme.Material(), status)
+		label.Color = color
+		items = append(items, label.Layout)
+	}
+	// Error
+	items = append(items, func(gtx layout.Context) layout.Dimensions {
+		if page.Error == nil {
+			return layout.Dimensions{}
+		}
+		label := material.Caption(theme.Material(), page.Error.Error())
+		label.Color = theme.Error
+		label.Alignment = text.Middle
+		return label.Layout(gtx)
+	})
+	return theme.LayoutForm(gtx, &page.List, &page.Ok, items...)
+}
+
+// Validates and applies the settings, nothing is stored on error
+func (page *QuietHoursPage) Save() error {
+	settings := NotificationSettings{DoNotDisturb: page.DoNotDisturb.Value}
+	if page.Enabled.Value {
+		ranges, err := alerts.ParseTimeRanges(page.Ranges.Text())
+		if err != nil {
+			return err
+		}
+		schedule := &alerts.Schedule{
+			Quiet:    page.Mode.Value == "quiet",
+			TimeZone: strings.TrimSpace(page.TimeZone.Text()),
+			Ranges:   ranges,
+		}
+		for i := range page.Days {
+			if page.Days[i].Value {
+				schedule.Days = append(schedule.Days, time.Weekday(i))
+			}
+		}
+		if len(schedule.Days) == 0 {
+			return errors.New("select at least one day")
+		}
+		// Every day is same as no day filter
+		if len(schedule.Days) == len(page.Days) {
+			schedule.Days = nil
+		}
+		err = schedule.Validate()
+		if err != nil {
+			return err
+		}
+		settings.Schedule = schedule
+	}
+	config.Store("notifications", settings)
+	page.Daemon.QuietHours.Reset()
+	return nil
+}
+
+type HistorySettingsPage struct {
+	Daemon *Daemon
+	List   widget.List
+	Days   component.TextField
+	Max    component.TextField
+	Error  error
+	Ok     widget.Clickable
+}
+
+func NewHistorySettingsPage(daemon *Daemon) *HistorySettingsPage {
+	page := &HistorySettingsPage{
+		Daemon: daemon,
+	}
+	page.List.Axis = layout.Vertical
+	page.Days.SingleLine = true
+	page.Days.Filter = "0123456789"
+	page.Max.SingleLine = true
+	page.Max.Filter = "0123456789"
+	return page
+}
+
+func (page *HistorySettingsPage) Title() string {
+	return "Notification History"
+}
+
+func (page *HistorySettingsPage) Entering() {
+	retention := page.Daemon.History.Retention()
+	page.Days.SetText(formatOptionalInt(retention.Days))
+	page.Max.SetText(formatOptionalInt(retention.Max))
+	page.Error = nil
+}
+
+func (page *HistorySettingsPage) Leaving() {}
+
+func (page *HistorySettingsPage) Layout(gtx layout.Context, theme *Theme, pages *PageStack) layout.Dimensions {
+	if page.Ok.Clicked() {
+		err := page.Save()
+		if err != nil {
+			page.Error = err
+		} else {
+			pages.Pop()
+		}
+	}
+	return theme.LayoutForm(gtx, &page.List, &page.Ok,
+		func(gtx layout.Context) layout.Dimensions {
+			return page.Days.Layout(gtx, theme.Material(), "Keep for days (empty is forever)")
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return page.Max.Layout(gtx, theme.Material(), "Keep at most (empty is unlimited)")
+		},
+		// Error
+		func(gtx layout.Context) layout.Dimensions {
+			if page.Error == nil {
+				return layout.Dimensions{}
+			}
+			label := material.Caption(theme.Material(), page.Error.Error())
+			label.Color = theme.Error
+			label.Alignment = text.Middle
+			return label.Layout(gtx)
+		},
+	)
+}
+
+// Validates and applies the retention, old entries are removed immediately
+func (page *HistorySettingsPage) Save() error {
+	var retention history.Retention
+	var err error
+	if retention.Days, err = parseOptionalInt(page.Days.Text()); err != nil {
+		return fmt.Errorf("invalid days: %w", err)
+	}
+	if retention.Max, err = parseOptionalInt(page.Max.Text()); err != nil {
+		return fmt.Errorf("invalid count: %w", err)
+	}
+	err = retention.Validate()
+	if err != nil {
+		return err
+	}
+	config.Store("historyRetention", retention)
+	return page.Daemon.History.SetRetention(retention)
+}
+
+type HealthSettingsPage struct {
+	Daemon     *Daemon
+	List       widget.List
+	Enabled    widget.Bool
+	Collection component.TextField
+	Gas        component.TextField
+	Error      error
+	Ok         widget.Clickable
+}
+
+func NewHealthSettingsPage(daemon *Daemon) *HealthSettingsPage {
+	page := &HealthSettingsPage{
+		Daemon: daemon,
+	}
+	page.List.Axis = layout.Vertical
+	page.Collection.SingleLine = true
+	page.Collection.Filter = "0123456789"
+	page.Gas.SingleLine = true
+	page.Gas.Filter = "0123456789"
+	return page
+}
+
+func (page *HealthSettingsPage) Title() string {
+	return "System Alerts"
+}
+
+func (page *HealthSettingsPage) Entering() {
+	settings := page.Daemon.Health.Settings()
+	page.Enabled.Value = !settings.Disabled
+	page.Collection.SetText(formatOptionalInt(settings.CollectionMinutes))
+	page.Gas.SetText(formatOptionalInt(settings.GasMinutes))
+	page.Error = nil
+}
+
+func (page *HealthSettingsPage) Leaving() {}
+
+func (page *HealthSettingsPage) Layout(gtx layout.Context, theme *Theme, pages *PageStack) layout.Dimensions {
+	if page.Ok.Clicked() {
+		err := page.Save()
+		if err != nil {
+			page.Error = err
+		} else {
+			pages.Pop()
+		}
+	}
+	items := []layout.Widget{
+		material.CheckBox(theme.Material(), &page.Enabled, "Notify when collections or the gas tracker stop updating, or an api key is rejected").Layout,
+	}
+	if page.Enabled.Value {
+		items = append(items,
+			func(gtx layout.Context) layout.Dimensions {
+				return page.Collection.Layout(gtx, theme.Material(), "Collection is stale after minutes (empty is never)")
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return page.Gas.Layout(gtx, theme.Material(), "Gas tracker is stale after minutes (empty is never)")
+			},
+		)
+	}
+	// Error
+	items = append(items, func(gtx layout.Context) layout.Dimensions {
+		if page.Error == nil {
+			return layout.Dimensions{}
+		}
+		label := material.Caption(theme.Material(), page.Error.Error())
+		label.Color = theme.Error
+		label.Alignment = text.Middle
+		return label.Layout(gtx)
+	})
+	return theme.LayoutForm(gtx, &page.List, &page.Ok, items...)
+}
+
+func (page *HealthSettingsPage) Save() error {
+	settings := HealthSettings{Disabled: !page.Enabled.Value}
+	var err error
+	if settings.CollectionMinutes, err = parseOptionalInt(page.Collection.Text()); err != nil {
+		return fmt.Errorf("invalid collection minutes: %w", err)
+	}
+	if settings.GasMinutes, err = parseOptionalInt(page.Gas.Text()); err != nil {
+		return fmt.Errorf("invalid gas tracker minutes: %w", err)
+	}
+	err = settings.Validate()
+	if err != nil {
+		return err
+	}
+	config.Store("health", settings)
+	page.Daemon.Health.Reset()
+	return nil
+}
+
+type EscalationSettingsPage struct {
+	Daemon    *Daemon
+	List      widget.List
+	Intervals component.TextField
+	Escalate  component.TextField
+	Error     error
+	Ok        widget.Clickable
+}
+
+func NewEscalationSettingsPage(daemon *Daemon) *EscalationSettingsPage {
+	page := &EscalationSettingsPage{
+		Daemon: daemon,
+	}
+	page.List.Axis = layout.Vertical
+	page.Intervals.SingleLine = true
+	page.Intervals.Filter = "0123456789.hms, "
+	page.Escalate.SingleLine = true
+	page.Escalate.Filter = "0123456789"
+	return page
+}
+
+func (page *EscalationSettingsPage) Title() string {
+	return "Critical Alerts"
+}
+
+func (page *EscalationSettingsPage) Entering() {
+	settings := page.Daemon.Escalations.Settings()
+	intervals := make([]string, len(settings.Intervals))
+	for i, interval := range settings.Intervals {
+		intervals[i] = alerts.FormatInterval(interval)
+	}
+	page.Intervals.SetText(strings.Join(intervals, ", "))
+	page.Escalate.SetText(formatOptionalInt(settings.EscalateAfter))
+	page.Error = nil
+}
+
+func (page *EscalationSettingsPage) Leaving() {}
+
+func (page *EscalationSettingsPage) Layout(gtx layout.Context, theme *Theme, pages *PageStack) layout.Dimensions {
+	if page.Ok.Clicked() {
+		err := page.Save()
+		if err != nil {
+			page.Error = err
+		} else {
+			pages.Pop()
+		}
+	}
+	return theme.LayoutForm(gtx, &page.List, &page.Ok,
+		func(gtx layout.Context) layout.Dimensions {
+			label := material.Body2(theme.Material(), "Critical alerts repeat until they are acknowledged from the notifications page, the tray menu or with the --acknowledge flag")
+			label.Color = theme.MediumImpFg
+			return label.Layout(gtx)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return page.Intervals.Layout(gtx, theme.Material(), "Repeat after, like 1m, 2m, 5m (last one repeats)")
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return page.Escalate.Layout(gtx, theme.Material(), "Escalate after minutes (empty is never)")
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			label := material.Caption(theme.Material(), "Escalations bring the app to front and are sent to the webhook and discord channels set to escalations only")
+			label.Color = theme.MediumImpFg
+			return label.Layout(gtx)
+		},
+		// Error
+		func(gtx layout.Context) layout.Dimensions {
+			if page.Error == nil {
+				return layout.Dimensions{}
+			}
+			label := material.Caption(theme.Material(), page.Error.Error())
+			label.Color = theme.Error
+			label.Alignment = text.Middle
+			return label.Layout(gtx)
+		},
+	)
+}
+
+func (page *EscalationSettingsPage) Save() error {
+	var settings escalation.Settings
+	for _, field := range strings.Split(page.Intervals.Text(), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		d, err := time.ParseDuration(field)
+		if err != nil {
+			return fmt.Errorf("invalid repeat interval %q, use durations like 1m or 30s", field)
+		}
+		settings.Intervals = append(settings.Intervals, int(d/time.Second))
+	}
+	var err error
+	if settings.EscalateAfter, err = parseOptionalInt(page.Escalate.Text()); err != nil {
+		return fmt.Errorf("invalid escalation minutes: %w", err)
+	}
+	err = settings.Validate()
+	if err != nil {
+		return err
+	}
+	config.Store("escalation", settings)
+	page.Daemon.Escalations.Reset()
+	return nil
+}
+
+type WebhookSettingsPage struct {
+	Daemon   *Daemon
+	List     widget.List
+	Enabled  widget.Bool
+	URL      component.TextField
+	Template component.TextField
+	Headers  component.TextField
+	Secret   component.TextField
+	// Only escalations are posted
+	Escalation widget.Bool
+	Error      error
+	Ok         widget.Clickable
+}
+
+func NewWebhookSettingsPage(daemon *Daemon) *WebhookSettingsPage {
+	page := &WebhookSettingsPage{
+		Daemon: daemon,
+	}
+	page.List.Axis = layout.Vertical
+	page.URL.SingleLine = true
+	page.Secret.SingleLine = true
+	page.Secret.Mask = '*'
+	return page
+}
+
+func (page *WebhookSettingsPage) Title() string {
+	return "Webhook"
+}
+
+func (page *WebhookSettingsPage) Entering() {
+	settings := config.LoadFallback("webhook", notifier.WebhookSettings{})
+	page.Enabled.Value = settings.Enabled
+	page.URL.SetText(settings.URL)
+	page.Template.SetText(settings.Template)
+	page.Headers.SetText(formatHeaders(settings.Headers))
+	page.Secret.SetText(settings.Secret)
+	page.Escalation.Value = settings.Escalation
+	page.Error = nil
+}
+
+func (page *WebhookSettingsPage) Leaving() {}
+
+func (page *WebhookSettingsPage) Layout(gtx layout.Context, theme *Theme, pages *PageStack) layout.Dimensions {
+	if page.Ok.Clicked() {
+		err := page.Save()
+		if err != nil {
+			page.Error = err
+		} else {
+			pages.Pop()
+		}
+	}
+	items := []layout.Widget{
+		material.CheckBox(theme.Material(), &page.Enabled, "Post every notification to a url").Layout,
+	}
+	if page.Enabled.Value {
+		items = append(items,
+			func(gtx layout.Context) layout.Dimensions {
+				return page.URL.Layout(gtx, theme.Material(), "Url, like https://example.com/hook")
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return page.Template.Layout(gtx, theme.Material(), "Body template (empty sends the json payload)")
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				label := material.Caption(theme.Material(), "Fields: .Title .Body .Severity .Kind .AlertID .Condition .Collection .Name .Value .URL .Time .Escalation, quote them with json like {{json .Title}}")
+				label.Color = theme.MediumImpFg
+				return label.Layout(gtx)
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return page.Headers.Layout(gtx, theme.Material(), "Headers, one Key: Value per line (optional)")
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return page.Secret.Layout(gtx, theme.Material(), "Signing secret (optional)")
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				label := material.Caption(theme.Material(), "Signed requests have the "+notifier.SignatureHeader+" header, hex HMAC-SHA256 of the body")
+				label.Color = theme.MediumImpFg
+				return label.Layout(gtx)
+			},
+			material.CheckBox(theme.Material(), &page.Escalation, "Only post escalations of unacknowledged critical alerts").Layout,
+		)
+	}
+	// Error
+	items = append(items, func(gtx layout.Context) layout.Dimensions {
+		if page.Error == nil {
+			return layout.Dimensions{}
+		}
+		label := material.Caption(theme.Material(), page.Error.Error())
+		label.Color = theme.Error
+		label.Alignment = text.Middle
+		return label.Layout(gtx)
+	})
+	return theme.LayoutForm(gtx, &page.List, &page.Ok, items...)
+}
+
+func (page *WebhookSettingsPage) Save() error {
+	headers, err := parseHeaders(page.Headers.Text())
+	if err != nil {
+		return err
+	}
+	settings := notifier.WebhookSettings{
+		Enabled:    page.Enabled.Value,
+		URL:        strings.TrimSpace(page.URL.Text()),
+		Template:   page.Template.Text(),
+		Headers:    headers,
+		Secret:     page.Secret.Text(),
+		Escalation: page.Escalation.Value,
+	}
+	if settings.Enabled {
+		err = settings.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	config.Store("webhook", settings)
+	page.Daemon.ResetWebhook()
+	return nil
+}
+
+type DiscordSettingsPage struct {
+	Daemon  *Daemon
+	List    widget.List
+	Enabled widget.Bool
+	URL     component.TextField
+	// Only escalations are sent
+	Escalation widget.Bool
+	Test       widget.Clickable
+	testing    mutex.Value[bool]
+	tested     mutex.Value[string] // Result of the last test
+	testErr    mutex.Value[error]
+	Error      error
+	Ok         widget.Clickable
+}
+
+func NewDiscordSettingsPage(daemon *Daemon) *DiscordSettingsPage {
+	page := &DiscordSettingsPage{
+		Daemon: daemon,
+	}
+	page.List.Axis = layout.Vertical
+	page.URL.SingleLine = true
+	return page
+}
+
+func (page *DiscordSettingsPage) Title() string {
+	return "Discord"
+}
+
+func (page *DiscordSettingsPage) Entering() {
+	settings := config.LoadFallback("discord", notifier.DiscordSettings{})
+	page.Enabled.Value = settings.Enabled
+	page.URL.SetText(settings.URL)
+	page.Escalation.Value = settings.Escalation
+	page.tested.Store("")
+	page.testErr.Store(nil)
+	page.Error = nil
+}
+
+func (page *DiscordSettingsPage) Leaving() {}
+
+func (page *DiscordSettingsPage) settings() notifier.DiscordSettings {
+	return notifier.DiscordSettings{
+		Enabled:    page.Enabled.Value,
+		URL:        strings.TrimSpace(page.URL.Text()),
+		Escalation: page.Escalation.Value,
+	}
+}
+
+// Sends a sample alert to the entered url, it doesn't need to be saved
+// Rate limit is shared with the channel of the daemon when the url is the same
+func (page *DiscordSettingsPage) sendTest() {
+	page.testing.Store(true)
+	page.testErr.Store(nil)
+	defer func() {
+		page.testing.Store(false)
+		RefreshWindowChan <- struct{}{}
+	}()
+	discord, err := notifier.NewDiscord(page.settings(), NAME)
+	if err == nil {
+		err = discord.Notify(notifier.Notification{
+			Title:    NAME + " | Test",
+			Body:     "Alerts will look like this",
+			Severity: notifier.SeverityInfo,
+			URL:      WEBSITE,
+			Time:     time.Now(),
+		})
+	}
+	if err != nil {
+		page.testErr.Store(err)
+		return
+	}
+	page.tested.Store("Sent at " + time.Now().Format("15:04:05"))
+}
+
+func (page *DiscordSettingsPage) Layout(gtx layout.Context, theme *Theme, pages *PageStack) layout.Dimensions {
+	if page.Ok.Clicked() {
+		err := page.Save()
+		if err != nil {
+			page.Error = err
+		} else {
+			pages.Pop()
+		}
+	}
+	if page.Test.Clicked() && !page.testing.Load() {
+		go page.sendTest()
+	}
+	items := []layout.Widget{
+		material.CheckBox(theme.Material(), &page.Enabled, "Send every notification to a discord channel").Layout,
+	}
+	if page.Enabled.Value {
+		items = append(items,
+			func(gtx layout.Context) layout.Dimensions {
+				return page.URL.Layout(gtx, theme.Material(), "Webhook url, like https://discord.com/api/webhooks/...")
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				label := material.Caption(theme.Material(), "Create it in the channel settings under Integrations > Webhooks")
+				label.Color = theme.MediumImpFg
+				return label.Layout(gtx)
+			},
+			material.CheckBox(theme.Material(), &page.Escalation, "Only send escalations of unacknowledged critical alerts").Layout,
+			func(gtx layout.Context) layout.Dimensions {
+				title := "Send test"
+				if page.testing.Load() {
+					title = "Sending..."
+				}
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(theme.Button(title, &page.Test, RegularButton).Layout),
+					layout.Rigid(theme.MediumHSpacer.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						label := material.Caption(theme.Material(), page.tested.Load())
+						label.Color = theme.MediumImpFg
+						if err := page.testErr.Load(); err != nil {
+							label.Text = err.Error()
+							label.Color = theme.Error
+						}
+						return label.Layout(gtx)
+					}),
+				)
+			},
+		)
+	}
+	// Error
+	items = append(items, func(gtx layout.Context) layout.Dimensions {
+		if page.Error == nil {
+			return layout.Dimensions{}
+		}
+		label := material.Caption(theme.Material(), page.Error.Error())
+		label.Color = theme.Error
+		label.Alignment = text.Middle
+		return label.Layout(gtx)
+	})
+	return theme.LayoutForm(gtx, &page.List, &page.Ok, items...)
+}
+
+func (page *DiscordSettingsPage) Save() error {
+	settings := page.settings()
+	if settings.Enabled {
+		err := settings.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	config.Store("discord", settings)
+	page.Daemon.ResetDiscord()
+	return nil
+}
+
+// Parses Key: Value lines, empty lines are skipped
+func parseHeaders(s string) (map[string]string, error) {
+	var headers map[string]string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid header %q, use Key: Value", line)
+		}
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		headers[key] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+func formatHeaders(headers map[string]string) string {
+	lines := make([]string, 0, len(headers))
+	for key, value := range headers {
+		lines = append(lines, key+": "+value)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// Empty text is zero
+func parseOptionalInt(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+func formatOptionalInt(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
 // DEBUG
 
 type NamedIcon struct {
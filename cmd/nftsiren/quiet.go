@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/cmd/nftsiren/config"
+	"nftsiren/cmd/nftsiren/notifier"
+	"nftsiren/pkg/log"
+	"nftsiren/pkg/mutex"
+	"nftsiren/pkg/worker"
+)
+
+// Global notification settings, alerts may have their own schedule too
+type NotificationSettings struct {
+	DoNotDisturb bool             `json:"doNotDisturb,omitempty"`
+	Schedule     *alerts.Schedule `json:"schedule,omitempty"` // Nil when there is no global schedule
+}
+
+type quietNotification struct {
+	notification notifier.Notification
+	schedule     *alerts.Schedule // Own schedule of the alert
+}
+
+// QuietHours holds the notifications suppressed by schedules or do not disturb
+// and sends a summary of them when they are allowed again
+type QuietHours struct {
+	dispatcher *notifier.Dispatcher
+	settings   mutex.Value[NotificationSettings]
+	worker     *worker.Worker
+	mutex      sync.Mutex
+	queued     []quietNotification
+}
+
+// At most this many notifications are listed in a summary
+const quietSummaryLines = 5
+
+func NewQuietHours(dispatcher *notifier.Dispatcher) *QuietHours {
+	quiet := &QuietHours{dispatcher: dispatcher}
+	quiet.worker = worker.New(worker.Settings{
+		Name:        "QuietHours",
+		Interval:    time.Minute,
+		Work:        quiet.Flush,
+		PanicHanler: ReportPanic,
+	})
+	return quiet
+}
+
+func (quiet *QuietHours) Start() {
+	quiet.Reset()
+	quiet.worker.Start()
+}
+
+func (quiet *QuietHours) Stop() {
+	quiet.worker.Stop()
+}
+
+// Reloads the settings and flushes the queue if they allow it now
+func (quiet *QuietHours) Reset() {
+	quiet.settings.Store(config.LoadFallback("notifications", NotificationSettings{}))
+	quiet.worker.Trigger()
+}
+
+func (quiet *QuietHours) Settings() NotificationSettings {
+	return quiet.settings.Load()
+}
+
+// Allows reports whether a notification with the given alert schedule can be pushed at t
+func (quiet *QuietHours) Allows(schedule *alerts.Schedule, t time.Time) bool {
+	settings := quiet.settings.Load()
+	if settings.DoNotDisturb {
+		return false
+	}
+	if settings.Schedule != nil && !settings.Schedule.Allows(t) {
+		return false
+	}
+	return schedule == nil || schedule.Allows(t)
+}
+
+// Sends the notification to every channel or queues it until it is allowed
+func (quiet *QuietHours) Notify(schedule *alerts.Schedule, notification notifier.Notification) {
+	if quiet.Allows(schedule, time.Now()) {
+		go quiet.dispatch(notification)
+		return
+	}
+	log.Info().Println("Queued notification for quiet hours:", notification.Title, notification.Body)
+	quiet.mutex.Lock()
+	quiet.queued = append(quiet.queued, quietNotification{notification, schedule})
+	quiet.mutex.Unlock()
+}
+
+// Failed channels are logged, the dispatcher keeps their errors for the settings
+func (quiet *QuietHours) dispatch(notification notifier.Notification) {
+	err := quiet.dispatcher.Dispatch(notification)
+	if err != nil {
+		log.Error().Println("Failed to deliver notification:", err)
+	}
+}
+
+// Queued returns the number of notifications waiting for quiet hours to end
+func (quiet *QuietHours) Queued() int {
+	quiet.mutex.Lock()
+	defer quiet.mutex.Unlock()
+	return len(quiet.queued)
+}
+
+// Pushes one summary of the queued notifications which are allowed now
+func (quiet *QuietHours) Flush() {
+	now := time.Now()
+	var ready []quietNotification
+	quiet.mutex.Lock()
+	remaining := quiet.queued[:0]
+	for _, n := range quiet.queued {
+		if quiet.Allows(n.schedule, now) {
+			ready = append(ready, n)
+		} else {
+			remaining = append(remaining, n)
+		}
+	}
+	quiet.queued = remaining
+	quiet.mutex.Unlock()
+	if len(ready) == 0 {
+		return
+	}
+	if len(ready) == 1 {
+		quiet.dispatch(ready[0].notification)
+		return
+	}
+	summary := notifier.Notification{Title: fmt.Sprintf("%s | %d alerts during quiet hours", NAME, len(ready))}
+	// Summary is as severe as the most severe notification in it
+	for _, n := range ready {
+		summary.Severity = max(summary.Severity, n.notification.Severity)
+	}
+	lines := make([]string, 0, quietSummaryLines+1)
+	for i, n := range ready {
+		if i == quietSummaryLines {
+			lines = append(lines, fmt.Sprintf("and %d more", len(ready)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", n.notification.Title, n.notification.Body))
+	}
+	summary.Body = strings.Join(lines, "\n")
+	quiet.dispatch(summary)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/apis/etherscan"
+	"nftsiren/pkg/log"
+	"nftsiren/pkg/nft"
+	"nftsiren/pkg/number"
+	"nftsiren/pkg/util"
+	"nftsiren/pkg/window"
+	"nftsiren/pkg/worker"
+)
+
+// Saved samples of a collection
+type CollectionSamples struct {
+	Asset    alerts.Asset                         `json:"asset"`
+	History  []window.Sample[nft.CollectionStats] `json:"history,omitempty"`
+	Baseline []window.Sample[nft.CollectionStats] `json:"baseline,omitempty"`
+}
+
+type savedSamples struct {
+	Collections []CollectionSamples                 `json:"collections,omitempty"`
+	Eth         []window.Sample[number.Number]      `json:"eth,omitempty"`
+	Gas         []window.Sample[etherscan.GasPrice] `json:"gas,omitempty"`
+}
+
+// Samples keeps the windows in a file, so alerts and backtests don't start with empty windows after a restart
+// It is saved periodically and on stop, collections restore their samples when they start
+type Samples struct {
+	daemon *Daemon
+	path   string
+	worker *worker.Worker
+	mutex  sync.Mutex
+	loaded map[alerts.Asset]CollectionSamples // Saved samples which aren't restored yet
+}
+
+func NewSamples(daemon *Daemon, path string) *Samples {
+	samples := &Samples{
+		daemon: daemon,
+		path:   path,
+		loaded: make(map[alerts.Asset]CollectionSamples),
+	}
+	samples.worker = worker.New(worker.Settings{
+		Name:        "Samples",
+		Interval:    baselineResolution,
+		Work:        samples.Save,
+		PanicHanler: ReportPanic,
+	})
+	return samples
+}
+
+// Loads the file and restores the gas tracker, must be called before the gas tracker and the collections are started
+func (samples *Samples) Load() {
+	if !util.FileExists(samples.path) {
+		return
+	}
+	data, err := os.ReadFile(samples.path)
+	if err != nil {
+		log.Error().Println("Failed to read samples:", err)
+		return
+	}
+	var saved savedSamples
+	err = json.Unmarshal(data, &saved)
+	if err != nil {
+		log.Error().Println("Failed to parse samples:", err)
+		return
+	}
+	samples.mutex.Lock()
+	for _, c := range saved.Collections {
+		samples.loaded[c.Asset] = c
+	}
+	samples.mutex.Unlock()
+	tracker := samples.daemon.GasTracker
+	restoreWindow(tracker.ethHistory, saved.Eth)
+	restoreWindow(tracker.gasHistory, saved.Gas)
+}
+
+// Adds the saved samples which the window still covers
+func restoreWindow[T any](w *window.Window[T], samples []window.Sample[T]) {
+	cutoff := time.Now().Add(-w.Length())
+	for _, sample := range window.After(samples, cutoff) {
+		w.Add(sample.Time, sample.Value)
+	}
+}
+
+func (samples *Samples) Start() {
+	samples.worker.Start()
+}
+
+func (samples *Samples) Stop() {
+	samples.worker.Stop()
+	samples.Save()
+}
+
+// Adds the saved samples of the collection to its windows, only the first call restores anything
+// Must be called before the first stats are set, windows ignore samples older than their latest
+func (samples *Samples) Restore(collection *Collection) {
+	samples.mutex.Lock()
+	saved, ok := samples.loaded[collection.Asset()]
+	delete(samples.loaded, collection.Asset())
+	samples.mutex.Unlock()
+	if !ok {
+		return
+	}
+	restoreWindow(collection.history, saved.History)
+	restoreWindow(collection.baseline, saved.Baseline)
+}
+
+// Writes the samples of every collection, removed collections are dropped from the file
+func (samples *Samples) Save() {
+	tracker := samples.daemon.GasTracker
+	saved := savedSamples{
+		Eth: tracker.ethHistory.Samples(),
+		Gas: tracker.gasHistory.Samples(),
+	}
+	samples.daemon.collectionsMutex.RLock()
+	for _, collection := range samples.daemon.collections {
+		saved.Collections = append(saved.Collections, CollectionSamples{
+			Asset:    collection.Asset(),
+			History:  collection.history.Samples(),
+			Baseline: collection.baseline.Samples(),
+		})
+	}
+	samples.daemon.collectionsMutex.RUnlock()
+	err := writeFileAtomic(samples.path, saved)
+	if err != nil {
+		log.Error().Println("Failed to save samples:", err)
+	}
+}
+
+// Writes to a temporary file first so a crash doesn't corrupt the file
+func writeFileAtomic(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0666)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0666)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
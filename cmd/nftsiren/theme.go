@@ -83,6 +83,10 @@ type Theme struct {
 	FilterIcon      *widgets.Icon
 	LogoutIcon      *widgets.Icon
 	QuitIcon        *widgets.Icon
+	EditIcon        *widgets.Icon
+	PauseIcon       *widgets.Icon
+	SnoozeIcon      *widgets.Icon
+	BellIcon        *widgets.Icon
 }
 
 func DefaultTheme() *Theme {
@@ -164,6 +168,10 @@ func DefaultTheme() *Theme {
 		FilterIcon:      widgets.NewIconFromIconVG(icons.ContentFilterList),
 		LogoutIcon:      widgets.NewIconFromIconVG(icons.ActionExitToApp),
 		QuitIcon:        widgets.NewIconFromIconVG(icons.ActionPowerSettingsNew),
+		EditIcon:        widgets.NewIconFromIconVG(icons.EditorModeEdit),
+		PauseIcon:       widgets.NewIconFromIconVG(icons.AVPause),
+		SnoozeIcon:      widgets.NewIconFromIconVG(icons.AVSnooze),
+		BellIcon:        widgets.NewIconFromIconVG(icons.SocialNotifications),
 	}
 
 	return theme
@@ -193,7 +201,7 @@ func (theme *Theme) AlertIcon(alert alerts.Alert) *widgets.Icon {
 		return theme.EthereumIcon
 	case alerts.GasAlert:
 		return theme.GasIcon
-	case alerts.CollectionAlert:
+	case alerts.CollectionAlert, alerts.PairAlert, alerts.CompoundAlert:
 		return theme.AlarmIcon
 	}
 	// This shouldn't happen
@@ -40,6 +40,7 @@ func TrayStart(iconData []byte) {
 			systray.SetIcon(systrayIcon(iconData))
 			systray.SetTitle(NAME)
 			mShow := systray.AddMenuItem("Show", "Show app")
+			mAck := systray.AddMenuItem("Acknowledge alerts", "Stop repeating critical alerts")
 			mQuit := systray.AddMenuItem("Quit", "Quit from app")
 			// go func() {
 			trayRunningMutex.Lock()
@@ -51,6 +52,8 @@ func TrayStart(iconData []byte) {
 				case <-mShow.ClickedCh:
 					// log.Debug().Println("Tray received show event")
 					ShowWindowChan <- struct{}{}
+				case <-mAck.ClickedCh:
+					RequestAcknowledge()
 				case <-mQuit.ClickedCh:
 					// log.Debug().Println("Tray received quit event")
 					QuitChan <- struct{}{}
@@ -15,9 +15,10 @@ type UI struct {
 
 	Pages PageStack
 
-	HomePage        *HomePage
-	CollectionsPage *CollectionsPage
-	SettingsPage    *SettingsPage
+	HomePage          *HomePage
+	CollectionsPage   *CollectionsPage
+	NotificationsPage *NotificationsPage
+	SettingsPage      *SettingsPage
 
 	Navbar         widgets.NavbarState
 	AboutButton    widget.Clickable
@@ -38,6 +39,9 @@ func NewUI(daemon *Daemon, theme *Theme) *UI {
 	ui.CollectionsPage = NewCollectionsPage(ui.Daemon)
 	ui.Navbar.AddButton("Collections", ui.Theme.CollectionsIcon)
 
+	ui.NotificationsPage = NewNotificationsPage(ui.Daemon)
+	ui.Navbar.AddButton("Notifications", ui.Theme.BellIcon)
+
 	ui.SettingsPage = NewSettingsPage(ui.Daemon)
 	ui.Navbar.AddButton("Settings", ui.Theme.SettingsIcon)
 
@@ -86,7 +90,7 @@ func (ui *UI) LayoutPageDesktop(gtx layout.Context) layout.Dimensions {
 					})
 				}),
 				// Gas area
-				layout.Flexed(0.1, func(gtx layout.Context) layout.Dimensions {
+				layout.Flexed(0.15, func(gtx layout.Context) layout.Dimensions {
 					return ui.Daemon.GasTracker.Layout(gtx, ui.Theme, layout.Vertical)
 				}),
 				// Divider
@@ -94,7 +98,9 @@ func (ui *UI) LayoutPageDesktop(gtx layout.Context) layout.Dimensions {
 				// 	return widgets.DividerLine(gtx, layout.Horizontal, 1, widgets.Lighter(ui.Theme.Bg))
 				// }),
 				// Navbar
-				layout.Flexed(0.8, func(gtx layout.Context) layout.Dimensions {
+				layout.Flexed(0.75, func(gtx layout.Context) layout.Dimensions {
+					// Unread notifications
+					ui.Navbar.Buttons[2].Badge = ui.Daemon.History.Unread()
 					return widgets.Navbar(ui.Theme.Material(), &ui.Navbar, layout.Vertical).Layout(gtx)
 				}),
 			)
@@ -125,6 +131,8 @@ func (ui *UI) LayoutActivePage(gtx layout.Context) layout.Dimensions {
 		case 1:
 			ui.Pages.Push(ui.CollectionsPage)
 		case 2:
+			ui.Pages.Push(ui.NotificationsPage)
+		case 3:
 			ui.Pages.Push(ui.SettingsPage)
 		default:
 			panic("unknown page number")
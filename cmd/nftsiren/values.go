@@ -0,0 +1,57 @@
+package main
+
+import (
+	"time"
+
+	"nftsiren/cmd/nftsiren/alertengine"
+	"nftsiren/cmd/nftsiren/alerts"
+	"nftsiren/pkg/number"
+)
+
+// Live eth or gas values of the tracker
+type livePrice struct {
+	daemon *Daemon
+	gas    bool
+	tier   alerts.GasTier // Only used for gas
+}
+
+func (price livePrice) Latest() (number.Number, bool) {
+	tracker := price.daemon.GasTracker
+	if price.gas {
+		gas := tracker.GetGasTier(price.tier)
+		return gas, tracker.GasStillValid() && !gas.IsNil()
+	}
+	return tracker.GetEth(), tracker.EthStillValid()
+}
+
+func (price livePrice) Change(d time.Duration) (drop, rise number.Number, ok bool) {
+	if price.gas {
+		return price.daemon.GasTracker.GasChange(d, price.tier)
+	}
+	return price.daemon.GasTracker.EthChange(d)
+}
+
+func (price livePrice) Vars() alerts.Vars {
+	return price.daemon.ExprVars(nil)
+}
+
+// Live values of the daemon for the clauses of compound alerts
+type liveSources struct {
+	daemon *Daemon
+}
+
+func (sources liveSources) Collection(asset alerts.Asset) (alertengine.CollectionValues, bool) {
+	collection := sources.daemon.Collection(asset)
+	if collection == nil {
+		return nil, false
+	}
+	return collection, true
+}
+
+func (sources liveSources) Eth() alertengine.PriceValues {
+	return livePrice{daemon: sources.daemon}
+}
+
+func (sources liveSources) Gas(tier alerts.GasTier) alertengine.PriceValues {
+	return livePrice{daemon: sources.daemon, gas: true, tier: tier}
+}
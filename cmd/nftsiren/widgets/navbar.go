@@ -2,6 +2,7 @@ package widgets
 
 import (
 	"image/color"
+	"strconv"
 
 	"gioui.org/io/pointer"
 	"gioui.org/layout"
@@ -14,6 +15,7 @@ import (
 type NavbarButton struct {
 	Text   string
 	Icon   *Icon
+	Badge  int // Shown over the icon when it is not zero, like unread count
 	Button widget.Clickable
 }
 
@@ -38,7 +40,17 @@ func (button *NavbarButton) Layout(gtx layout.Context, state *NavbarState, style
 			}.Layout(gtx,
 				// icon
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					return button.Icon.Layout(gtx, style.IconSize, fg)
+					if button.Badge == 0 {
+						return button.Icon.Layout(gtx, style.IconSize, fg)
+					}
+					return layout.Stack{Alignment: layout.NE}.Layout(gtx,
+						layout.Stacked(func(gtx layout.Context) layout.Dimensions {
+							return button.Icon.Layout(gtx, style.IconSize, fg)
+						}),
+						layout.Stacked(func(gtx layout.Context) layout.Dimensions {
+							return button.layoutBadge(gtx, style)
+						}),
+					)
 				}),
 				// padding
 				layout.Rigid(style.ButtonSpacer.Layout),
@@ -58,6 +70,25 @@ func (button *NavbarButton) Layout(gtx layout.Context, state *NavbarState, style
 	})
 }
 
+func (button *NavbarButton) layoutBadge(gtx layout.Context, style *NavbarStyle) layout.Dimensions {
+	txt := strconv.Itoa(button.Badge)
+	if button.Badge > 99 {
+		txt = "99+"
+	}
+	return BackgroundRect(gtx, style.BadgeColor, unit.Dp(style.BadgeTextSize), func(gtx layout.Context) layout.Dimensions {
+		return layout.Inset{Left: 4, Right: 4}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return material.LabelStyle{
+				Color:     style.BadgeTextColor,
+				Alignment: text.Middle,
+				MaxLines:  1,
+				Text:      txt,
+				TextSize:  style.BadgeTextSize,
+				Shaper:    style.Theme.Shaper,
+			}.Layout(gtx)
+		})
+	})
+}
+
 type NavbarState struct {
 	Buttons []NavbarButton
 	Active  int
@@ -76,6 +107,9 @@ type NavbarStyle struct {
 	ButtonColorActive  color.NRGBA
 	ButtonInset        layout.Inset
 	ButtonSpacer       layout.Spacer
+	BadgeColor         color.NRGBA
+	BadgeTextColor     color.NRGBA
+	BadgeTextSize      unit.Sp
 }
 
 func Navbar(theme *material.Theme, state *NavbarState, axis layout.Axis) NavbarStyle {
@@ -90,6 +124,9 @@ func Navbar(theme *material.Theme, state *NavbarState, axis layout.Axis) NavbarS
 		TextSize:           theme.TextSize,
 		ButtonInset:        layout.UniformInset(unit.Dp(6)),
 		ButtonSpacer:       layout.Spacer{Height: unit.Dp(4)},
+		BadgeColor:         color.NRGBA{R: 0xe0, G: 0x20, B: 0x20, A: 0xff},
+		BadgeTextColor:     color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		BadgeTextSize:      theme.TextSize * 0.7,
 	}
 }
 
@@ -21,6 +21,8 @@ func (td *TypedEnum[T]) SetKeys(types ...T) {
 	for i, t := range td.Types {
 		td.Keys[i] = t.String()
 	}
+	// Rebuilt on next layout
+	td.Childs = nil
 }
 
 func (td *TypedEnum[T]) SelectedType() (T, bool) {
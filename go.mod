@@ -10,6 +10,7 @@ require (
 	github.com/emersion/go-autostart v0.0.0-20210130080809-00ed301c8e9a
 	github.com/fyne-io/image v0.0.0-20220602074514-4956b0afb3d2
 	github.com/gio-eui/ivgconv v0.0.0-20230728141110-3b7424472495
+	github.com/inkeliz/giosvg v0.0.0-20230915151051-c8ae55d003c6
 	github.com/magefile/mage v1.14.0
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/srwiley/oksvg v0.0.0-20221011165216-be6e8873101c
@@ -18,6 +19,7 @@ require (
 	golang.org/x/exp v0.0.0-20221012211006-4de253d81b95
 	golang.org/x/exp/shiny v0.0.0-20230725093048-515e97ebf090
 	golang.org/x/image v0.12.0
+	golang.org/x/net v0.15.0
 )
 
 require (
@@ -31,7 +33,6 @@ require (
 	github.com/go-ole/go-ole v1.2.6 // indirect
 	github.com/go-text/typesetting v0.0.0-20230803102845-24e03d8b5372 // indirect
 	github.com/godbus/dbus/v5 v5.1.0 // indirect
-	github.com/inkeliz/giosvg v0.0.0-20230915151051-c8ae55d003c6 // indirect
 	github.com/jsummers/gobmp v0.0.0-20151104160322-e2ba15ffa76e // indirect
 	github.com/kr/pretty v0.3.0 // indirect
 	github.com/mattn/go-runewidth v0.0.14 // indirect
@@ -39,7 +40,6 @@ require (
 	github.com/rivo/uniseg v0.4.2 // indirect
 	github.com/rogpeppe/go-internal v1.8.0 // indirect
 	github.com/tevino/abool v1.2.0 // indirect
-	golang.org/x/net v0.15.0 // indirect
 	golang.org/x/sys v0.12.0 // indirect
 	golang.org/x/text v0.13.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
@@ -1,21 +1,26 @@
 package etherscan
 
 import (
-	"errors"
 	"fmt"
-	"net/http"
 
 	"nftsiren/pkg/httpclient"
 	"nftsiren/pkg/mutex"
 )
 
-var client = httpclient.NewClient("https://api.etherscan.io/api")
+const DefaultBaseURL = "https://api.etherscan.io/api"
+
+var client = httpclient.NewClient(DefaultBaseURL)
 var apiKey mutex.Value[string]
 
 func SetApiKey(key string) {
 	apiKey.Store(key)
 }
 
+// Overrides the api url, pass DefaultBaseURL to restore it
+func SetBaseURL(baseUrl string) error {
+	return client.SetBaseURL(baseUrl)
+}
+
 func get[T any](module, action string) (EtherscanCommonResponse[T], error) {
 	params := map[string]string{
 		"module": module,
@@ -28,7 +33,7 @@ func get[T any](module, action string) (EtherscanCommonResponse[T], error) {
 	status, err := client.GetJson(nil, params, &resp)
 	if err != nil {
 		if status >= 400 {
-			return EtherscanCommonResponse[T]{}, errors.New(http.StatusText(status))
+			return EtherscanCommonResponse[T]{}, &httpclient.StatusError{Code: status}
 		}
 		return EtherscanCommonResponse[T]{}, err
 	}
@@ -0,0 +1,49 @@
+package etherscan
+
+import (
+	"path/filepath"
+	"testing"
+
+	"nftsiren/pkg/httpclient"
+	"nftsiren/pkg/httpclient/cassette"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchPrices(t *testing.T) {
+	cassette.Use(t, client, filepath.Join("testdata", "prices.json"))
+	// api key is not recorded
+	SetApiKey("secret")
+	defer SetApiKey("")
+	eth, err := FetchEthPrice()
+	require.NoError(t, err)
+	assert.Equal(t, "1563.27", eth.Ethusd.String())
+	assert.Equal(t, "0.05291", eth.Ethbtc.String())
+	gas, err := FetchGasPrice()
+	require.NoError(t, err)
+	assert.Equal(t, "6", gas.SafeGasPrice.String())
+	assert.Equal(t, "7", gas.ProposeGasPrice.String())
+	assert.Equal(t, "8", gas.FastGasPrice.String())
+	assert.Equal(t, "5.861213405", gas.SuggestBaseFee.String())
+	assert.Equal(t, "18381234", gas.LastBlock.String())
+	assert.Equal(t, "1.138786595", gas.PriorityFee().String())
+}
+
+func TestErrors(t *testing.T) {
+	cassette.Use(t, client, filepath.Join("testdata", "errors.json"))
+	// status 0 puts the error in the result field
+	_, err := FetchEthPrice()
+	assert.EqualError(t, err, "NOTOK: Invalid API Key")
+	assert.True(t, httpclient.IsAuthError(err))
+	_, err = FetchGasPrice()
+	assert.EqualError(t, err, "NOTOK: Max rate limit reached")
+	assert.False(t, httpclient.IsAuthError(err))
+}
+
+func TestHttpError(t *testing.T) {
+	cassette.Use(t, client, filepath.Join("testdata", "http.json"))
+	_, err := FetchEthPrice()
+	assert.EqualError(t, err, "Bad Gateway")
+	assert.False(t, httpclient.IsAuthError(err))
+}
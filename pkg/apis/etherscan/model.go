@@ -3,6 +3,10 @@ package etherscan
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
+
+	"nftsiren/pkg/httpclient"
 	"nftsiren/pkg/number"
 )
 
@@ -43,6 +47,10 @@ func (resp *EtherscanCommonResponse[T]) UnmarshalJSON(data []byte) error {
 			}
 		}
 		resp.Error = fmt.Errorf("%s: %s", resp.Message, errstr)
+		// Rejected keys still come with 200, report them like the other apis do
+		if strings.Contains(errstr, "Invalid API Key") {
+			resp.Error = &httpclient.StatusError{Code: http.StatusUnauthorized, Message: resp.Error.Error()}
+		}
 	} else { // No error
 		if result, ok := pre["result"]; ok {
 			if err := json.Unmarshal(result, &resp.Result); err != nil {
@@ -68,3 +76,16 @@ type GasPrice struct {
 	SuggestBaseFee  number.Number `json:"suggestBaseFee"`
 	GasUsedRatio    string        `json:"gasUsedRatio"`
 }
+
+// Estimated EIP-1559 priority fee, the proposed price above the base fee
+// Nil when either is missing
+func (gas GasPrice) PriorityFee() number.Number {
+	if gas.ProposeGasPrice.IsNil() || gas.SuggestBaseFee.IsNil() {
+		return number.Number{}
+	}
+	// Propose can be rounded below the base fee
+	if gas.ProposeGasPrice.LessThan(gas.SuggestBaseFee) {
+		return number.NewFromInt(0)
+	}
+	return gas.ProposeGasPrice.Sub(gas.SuggestBaseFee)
+}
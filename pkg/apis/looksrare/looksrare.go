@@ -2,7 +2,6 @@ package looksrare
 
 import (
 	"errors"
-	"net/http"
 	"time"
 
 	"nftsiren/pkg/httpclient"
@@ -12,12 +11,19 @@ import (
 
 var errSomethingWentWrong = errors.New("something went wrong")
 
-var client = httpclient.NewClientWithLimit("https://api.looksrare.org/api/v1", 120, time.Minute)
+const DefaultBaseURL = "https://api.looksrare.org/api/v1"
+
+var client = httpclient.NewClientWithLimit(DefaultBaseURL, 120, time.Minute)
 
 func SetApiKey(apiKey string) {
 	client.SetDefaultHeader("X-Looks-Api-Key", apiKey)
 }
 
+// Overrides the api url, pass DefaultBaseURL to restore it
+func SetBaseURL(baseUrl string) error {
+	return client.SetBaseURL(baseUrl)
+}
+
 type genericResponse[T any] struct {
 	Success bool          `json:"success"`
 	Message string        `json:"message"`
@@ -30,7 +36,7 @@ func get[T any](path []string, params map[string]string) (T, error) {
 	status, err := client.GetJson(path, params, &resp)
 	if err != nil {
 		if status >= 400 {
-			return *new(T), errors.New(http.StatusText(status))
+			return *new(T), &httpclient.StatusError{Code: status}
 		}
 		return *new(T), err
 	}
@@ -0,0 +1,57 @@
+package looksrare
+
+import (
+	"path/filepath"
+	"testing"
+
+	"nftsiren/pkg/httpclient/cassette"
+	"nftsiren/pkg/nft"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bayc = "0xBC4CA0EdA7647A8aB7C2061c2E118A18a936f13D"
+
+func TestFetchCollection(t *testing.T) {
+	cassette.Use(t, client, filepath.Join("testdata", "collection.json"))
+	c, err := FetchCollection(bayc)
+	require.NoError(t, err)
+	assert.Equal(t, nft.ETH, c.Currency)
+	assert.Equal(t, nft.Looksrare, c.Marketplace)
+	assert.Equal(t, bayc, c.Symbol)
+	assert.Equal(t, bayc, c.Address)
+	assert.Equal(t, "BoredApeYachtClub", c.Name)
+	assert.Equal(t, "https://static.looksnice.org/bayc/logo.png", c.ImageURL)
+	assert.Equal(t, "https://looksrare.org/collections/"+bayc, c.Marketpage)
+	assert.Equal(t, "https://twitter.com/BoredApeYC", c.Twitter)
+	assert.Nil(t, c.Stats)
+}
+
+func TestFetchCollectionStats(t *testing.T) {
+	cassette.Use(t, client, filepath.Join("testdata", "stats.json"))
+	stats, err := FetchCollectionStats(bayc)
+	require.NoError(t, err)
+	assert.True(t, stats.IsValid())
+	// Prices are converted from wei
+	assert.Equal(t, "28.95", stats.Floor.StringFixed(2))
+	assert.Equal(t, "173.70", stats.DayVolume.StringFixed(2))
+	assert.Equal(t, "1012345.68", stats.TotalVolume.StringFixed(2))
+	assert.Equal(t, "6", stats.DaySales.String())
+	assert.Equal(t, "16540", stats.TotalSales.String())
+	assert.Equal(t, "5734", stats.NumOwners.String())
+	assert.Equal(t, "9998", stats.TotalSupply.String())
+}
+
+func TestErrors(t *testing.T) {
+	cassette.Use(t, client, filepath.Join("testdata", "errors.json"))
+	// validation error with message
+	_, err := FetchCollection("invalid")
+	assert.EqualError(t, err, "Invalid queries, check 'errors' property for more info.")
+	// success false without message
+	_, err = FetchCollectionStats("invalid")
+	assert.ErrorIs(t, err, errSomethingWentWrong)
+	// non json body
+	_, err = FetchCollection("limited")
+	assert.EqualError(t, err, "Too Many Requests")
+}
@@ -2,7 +2,6 @@ package magiceden
 
 import (
 	"errors"
-	"net/http"
 	"time"
 
 	"nftsiren/pkg/httpclient"
@@ -11,12 +10,19 @@ import (
 )
 
 // This public API is free to use and the default limit is 120 QPM or 2 QPS
-var client = httpclient.NewClientWithLimit("https://api-mainnet.magiceden.dev/v2", 120, time.Minute)
+const DefaultBaseURL = "https://api-mainnet.magiceden.dev/v2"
+
+var client = httpclient.NewClientWithLimit(DefaultBaseURL, 120, time.Minute)
 
 func SetApiKey(apiKey string) {
 	client.SetBearerAuth(apiKey)
 }
 
+// Overrides the api url, pass DefaultBaseURL to restore it
+func SetBaseURL(baseUrl string) error {
+	return client.SetBaseURL(baseUrl)
+}
+
 type errorFields struct {
 	StatusCode *int    `json:"statusCode"`
 	Error      *string `json:"error"`
@@ -31,7 +37,7 @@ func (resp errorFields) check() error {
 		if resp.Error != nil {
 			return errors.New(*resp.Error)
 		}
-		return errors.New(http.StatusText(*resp.StatusCode))
+		return &httpclient.StatusError{Code: *resp.StatusCode}
 	}
 	return nil
 }
@@ -47,7 +53,7 @@ func get[T hasErrorCheck](path []string) (T, error) {
 		// Error is either json or network error
 		// Return status code if it
 		if status >= 400 {
-			return *new(T), errors.New(http.StatusText(status))
+			return *new(T), &httpclient.StatusError{Code: status}
 		}
 		return *new(T), err
 	}
@@ -0,0 +1,51 @@
+package magiceden
+
+import (
+	"path/filepath"
+	"testing"
+
+	"nftsiren/pkg/httpclient/cassette"
+	"nftsiren/pkg/nft"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchCollection(t *testing.T) {
+	cassette.Use(t, client, filepath.Join("testdata", "collection.json"))
+	c, err := FetchCollection("okay_bears")
+	require.NoError(t, err)
+	assert.Equal(t, nft.SOL, c.Currency)
+	assert.Equal(t, nft.Magiceden, c.Marketplace)
+	assert.Equal(t, "okay_bears", c.Symbol)
+	assert.Equal(t, "Okay Bears", c.Name)
+	assert.Equal(t, "https://img-cdn.magiceden.dev/okay_bears.png", c.ImageURL)
+	assert.Equal(t, "https://magiceden.io/marketplace/okay_bears", c.Marketpage)
+	require.NotNil(t, c.Stats)
+	assert.Equal(t, "12.5", c.Stats.Floor.String())
+}
+
+func TestFetchCollectionStats(t *testing.T) {
+	cassette.Use(t, client, filepath.Join("testdata", "stats.json"))
+	stats, err := FetchCollectionStats("okay_bears")
+	require.NoError(t, err)
+	assert.True(t, stats.IsValid())
+	// Prices are converted from lamports
+	assert.Equal(t, "12.5", stats.Floor.String())
+	assert.Equal(t, "1254321", stats.TotalVolume.String())
+	assert.Equal(t, "412", stats.Listed.String())
+	assert.True(t, stats.DaySales.IsNil())
+}
+
+func TestErrors(t *testing.T) {
+	cassette.Use(t, client, filepath.Join("testdata", "errors.json"))
+	// status code in body with message
+	_, err := FetchCollection("unknown")
+	assert.EqualError(t, err, "collection not found")
+	// status code in body without message
+	_, err = FetchCollectionStats("unknown")
+	assert.EqualError(t, err, "Bad Request")
+	// non json body
+	_, err = FetchCollection("down")
+	assert.EqualError(t, err, "Service Unavailable")
+}
@@ -0,0 +1,60 @@
+package apis
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"nftsiren/pkg/apis/etherscan"
+	"nftsiren/pkg/apis/looksrare"
+	"nftsiren/pkg/apis/magiceden"
+	"nftsiren/pkg/apis/opensea"
+)
+
+// Every provider is served under these paths by the mock server (cmd/nftsiren-mock)
+const (
+	MockOpenseaPath   = "/opensea"
+	MockLooksrarePath = "/looksrare"
+	MockMagicedenPath = "/magiceden"
+	MockEtherscanPath = "/etherscan"
+)
+
+// Reports whether root can be a mock server url, empty root is valid
+func ValidateMockServer(root string) error {
+	if root == "" {
+		return nil
+	}
+	u, err := url.Parse(root)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("mock server url must be absolute: %s", root)
+	}
+	return nil
+}
+
+// Points every provider to the mock server running at root
+// Empty root restores the default urls, nothing changes if root is invalid
+func SetMockServer(root string) error {
+	if err := ValidateMockServer(root); err != nil {
+		return err
+	}
+	root = strings.TrimRight(root, "/")
+	urls := []struct {
+		set         func(string) error
+		path, deflt string
+	}{
+		{opensea.SetBaseURL, MockOpenseaPath, opensea.DefaultBaseURL},
+		{looksrare.SetBaseURL, MockLooksrarePath, looksrare.DefaultBaseURL},
+		{magiceden.SetBaseURL, MockMagicedenPath, magiceden.DefaultBaseURL},
+		{etherscan.SetBaseURL, MockEtherscanPath, etherscan.DefaultBaseURL},
+	}
+	for _, u := range urls {
+		baseUrl := u.deflt
+		if root != "" {
+			baseUrl = root + u.path
+		}
+		if err := u.set(baseUrl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
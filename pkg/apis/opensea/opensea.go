@@ -2,7 +2,6 @@ package opensea
 
 import (
 	"errors"
-	"net/http"
 	"time"
 
 	"nftsiren/pkg/httpclient"
@@ -11,18 +10,25 @@ import (
 
 var errSomethingWentWrong = errors.New("something went wrong")
 
+const DefaultBaseURL = "https://api.opensea.io/api/v1"
+
 // GET requests are limited to 4/sec per API key. POST requests are limited to 2/sec per API key.
-var client = httpclient.NewClientWithLimit("https://api.opensea.io/api/v1", 4, time.Second)
+var client = httpclient.NewClientWithLimit(DefaultBaseURL, 4, time.Second)
 
 func SetApiKey(apiKey string) {
 	client.SetDefaultHeader("X-API-KEY", apiKey)
 }
 
+// Overrides the api url, pass DefaultBaseURL to restore it
+func SetBaseURL(baseUrl string) error {
+	return client.SetBaseURL(baseUrl)
+}
+
 func get(path []string, resp hasErrorCheck) error {
 	status, err := client.GetJson(path, nil, resp)
 	if err != nil {
 		if status >= 400 {
-			return errors.New(http.StatusText(status))
+			return &httpclient.StatusError{Code: status}
 		}
 		return err
 	}
@@ -0,0 +1,61 @@
+package opensea
+
+import (
+	"path/filepath"
+	"testing"
+
+	"nftsiren/pkg/httpclient/cassette"
+	"nftsiren/pkg/nft"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchCollection(t *testing.T) {
+	cassette.Use(t, client, filepath.Join("testdata", "collection.json"))
+	c, err := FetchCollection("azuki")
+	require.NoError(t, err)
+	assert.Equal(t, nft.ETH, c.Currency)
+	assert.Equal(t, nft.Opensea, c.Marketplace)
+	assert.Equal(t, "azuki", c.Symbol)
+	assert.Equal(t, "Azuki", c.Name)
+	assert.Equal(t, "https://i.seadn.io/azuki.png", c.ImageURL)
+	assert.Equal(t, "https://opensea.io/collection/azuki", c.Marketpage)
+	assert.Equal(t, "https://www.azuki.com", c.Website)
+	assert.Equal(t, "https://twitter.com/azukiofficial", c.Twitter)
+	assert.Equal(t, "https://discord.gg/azuki", c.Discord)
+	require.NotNil(t, c.Stats)
+	assert.True(t, c.Stats.IsValid())
+	assert.Equal(t, "6.45", c.Stats.Floor.String())
+}
+
+func TestFetchCollectionStats(t *testing.T) {
+	cassette.Use(t, client, filepath.Join("testdata", "stats.json"))
+	stats, err := FetchCollectionStats("azuki")
+	require.NoError(t, err)
+	assert.True(t, stats.IsValid())
+	assert.Equal(t, "6.45", stats.Floor.String())
+	assert.Equal(t, "8", stats.DaySales.String())
+	assert.Equal(t, "52.31", stats.DayVolume.String())
+	assert.Equal(t, "51230", stats.TotalSales.String())
+	assert.Equal(t, "384512.7", stats.TotalVolume.String())
+	assert.Equal(t, "4612", stats.NumOwners.String())
+	assert.Equal(t, "10000", stats.TotalSupply.String())
+	assert.True(t, stats.Listed.IsNil())
+}
+
+func TestErrors(t *testing.T) {
+	cassette.Use(t, client, filepath.Join("testdata", "errors.json"))
+	// success false without messages
+	_, err := FetchCollection("does-not-exist")
+	assert.ErrorIs(t, err, errSomethingWentWrong)
+	// success false with messages
+	_, err = FetchCollectionStats("rejected")
+	assert.EqualError(t, err, "Invalid collection slug, Try again")
+	// non json body
+	_, err = FetchCollection("unauthorized")
+	assert.EqualError(t, err, "Unauthorized")
+	// missing stats
+	_, err = FetchCollectionStats("empty")
+	assert.EqualError(t, err, "collections statistics not available")
+}
@@ -0,0 +1,392 @@
+package opensea
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"nftsiren/pkg/httpclient"
+	"nftsiren/pkg/log"
+	"nftsiren/pkg/mutex"
+	"nftsiren/pkg/number"
+
+	"golang.org/x/net/websocket"
+)
+
+// Stream api uses the phoenix channel protocol over websocket
+// https://docs.opensea.io/reference/stream-api-overview
+const DefaultStreamURL = "wss://stream.openseabeta.com/socket/websocket"
+
+const (
+	streamHeartbeat  = 30 * time.Second
+	streamMinBackoff = time.Second
+	streamMaxBackoff = time.Minute
+)
+
+type StreamEventType string
+
+const (
+	ItemListed    StreamEventType = "item_listed"
+	ItemSold      StreamEventType = "item_sold"
+	ItemCancelled StreamEventType = "item_cancelled"
+)
+
+type StreamEvent struct {
+	Type       StreamEventType
+	Collection string        // Slug
+	NftID      string        // chain/contract/token
+	Permalink  string        //
+	Price      number.Number // Listing or sale price in payment token, nil for cancellations
+	Currency   string        // Payment token symbol
+	Time       time.Time     // Event time
+}
+
+type StreamHandler func(StreamEvent)
+
+// phoenix message
+type phxMessage struct {
+	Topic   string          `json:"topic"`
+	Event   string          `json:"event"`
+	Payload json.RawMessage `json:"payload"`
+	Ref     *string         `json:"ref"`
+}
+
+type streamPayload struct {
+	EventType string `json:"event_type"`
+	SentAt    string `json:"sent_at"`
+	Payload   struct {
+		Item struct {
+			NftID     string `json:"nft_id"`
+			Permalink string `json:"permalink"`
+		} `json:"item"`
+		Collection struct {
+			Slug string `json:"slug"`
+		} `json:"collection"`
+		BasePrice    string `json:"base_price"`
+		SalePrice    string `json:"sale_price"`
+		PaymentToken *struct {
+			Symbol   string `json:"symbol"`
+			Decimals int    `json:"decimals"`
+		} `json:"payment_token"`
+		EventTimestamp string `json:"event_timestamp"`
+	} `json:"payload"`
+}
+
+func (p streamPayload) convert() StreamEvent {
+	event := StreamEvent{
+		Type:       StreamEventType(p.EventType),
+		Collection: p.Payload.Collection.Slug,
+		NftID:      p.Payload.Item.NftID,
+		Permalink:  p.Payload.Item.Permalink,
+		Time:       time.Now(),
+	}
+	if t, err := time.Parse(time.RFC3339Nano, p.Payload.EventTimestamp); err == nil {
+		event.Time = t
+	}
+	raw := p.Payload.BasePrice
+	if event.Type == ItemSold {
+		raw = p.Payload.SalePrice
+	}
+	if raw != "" {
+		decimals := 18
+		if p.Payload.PaymentToken != nil {
+			decimals = p.Payload.PaymentToken.Decimals
+			event.Currency = p.Payload.PaymentToken.Symbol
+		}
+		if price, ok := number.NewFromString(raw); ok {
+			event.Price = price.Div(number.NewFromBigInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+		}
+	}
+	return event
+}
+
+// Stream keeps a websocket connection open as long as there are subscriptions
+// and a token, reconnects with exponential backoff when the connection drops
+type Stream struct {
+	url       mutex.Value[string]
+	token     mutex.Value[string]
+	connected mutex.Value[bool]
+	mutex     sync.Mutex
+	handlers  map[string]StreamHandler // slug -> handler
+	conn      *websocket.Conn
+	ref       int
+	gen       int           // Increased on every close, so a connection dialed before the close is dropped
+	wake      chan struct{} // reconnect immediately
+	stop      chan struct{}
+	stopped   chan struct{}
+}
+
+func NewStream(streamUrl string) *Stream {
+	stream := &Stream{
+		handlers: make(map[string]StreamHandler),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	stream.url.Store(streamUrl)
+	return stream
+}
+
+func (stream *Stream) Start() {
+	go stream.run()
+}
+
+func (stream *Stream) Stop() {
+	close(stream.stop)
+	stream.closeConn()
+	<-stream.stopped
+}
+
+// Connected reports whether events are currently received
+// Callers should fall back to polling when this is false
+func (stream *Stream) Connected() bool {
+	return stream.connected.Load()
+}
+
+// Changing the token reconnects, empty token disconnects
+func (stream *Stream) SetToken(token string) {
+	if stream.token.Load() == token {
+		return
+	}
+	stream.token.Store(token)
+	stream.reconnect()
+}
+
+func (stream *Stream) SetURL(streamUrl string) {
+	if stream.url.Load() == streamUrl {
+		return
+	}
+	stream.url.Store(streamUrl)
+	stream.reconnect()
+}
+
+// Subscribe joins the collection channel, handler is called from the stream goroutine
+func (stream *Stream) Subscribe(slug string, handler StreamHandler) {
+	stream.mutex.Lock()
+	stream.handlers[slug] = handler
+	stream.mutex.Unlock()
+	if err := stream.send(collectionTopic(slug), "phx_join"); err != nil {
+		// Not connected, it will be joined when connected
+		stream.reconnect()
+	}
+}
+
+func (stream *Stream) Unsubscribe(slug string) {
+	stream.mutex.Lock()
+	delete(stream.handlers, slug)
+	stream.mutex.Unlock()
+	stream.send(collectionTopic(slug), "phx_leave")
+}
+
+// Subscribed reports whether events of this collection are being received
+func (stream *Stream) Subscribed(slug string) bool {
+	stream.mutex.Lock()
+	_, ok := stream.handlers[slug]
+	stream.mutex.Unlock()
+	return ok && stream.Connected()
+}
+
+func collectionTopic(slug string) string {
+	return "collection:" + slug
+}
+
+func (stream *Stream) reconnect() {
+	stream.closeConn()
+	select {
+	case stream.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (stream *Stream) closeConn() {
+	stream.mutex.Lock()
+	stream.gen++
+	if stream.conn != nil {
+		stream.conn.Close()
+	}
+	stream.mutex.Unlock()
+}
+
+func (stream *Stream) send(topic, event string) error {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	if stream.conn == nil {
+		return errors.New("stream is not connected")
+	}
+	stream.ref++
+	ref := strconv.Itoa(stream.ref)
+	return websocket.JSON.Send(stream.conn, phxMessage{
+		Topic:   topic,
+		Event:   event,
+		Payload: json.RawMessage("{}"),
+		Ref:     &ref,
+	})
+}
+
+func (stream *Stream) hasWork() bool {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	return stream.token.Load() != "" && len(stream.handlers) > 0
+}
+
+func (stream *Stream) run() {
+	defer close(stream.stopped)
+	backoff := streamMinBackoff
+	for {
+		if stream.hasWork() {
+			connectedAt := time.Now()
+			err := stream.connectAndServe()
+			stream.connected.Store(false)
+			if err != nil {
+				log.Warn().Println("Opensea stream disconnected:", err)
+			}
+			// Reset backoff if the connection was healthy for a while
+			if time.Since(connectedAt) > streamMaxBackoff {
+				backoff = streamMinBackoff
+			}
+		} else {
+			// Nothing to do, wait until something changes
+			backoff = streamMinBackoff
+			select {
+			case <-stream.wake:
+				continue
+			case <-stream.stop:
+				return
+			}
+		}
+		select {
+		case <-time.After(backoff):
+		case <-stream.wake:
+		case <-stream.stop:
+			return
+		}
+		backoff = min(backoff*2, streamMaxBackoff)
+	}
+}
+
+func (stream *Stream) dial() (*websocket.Conn, error) {
+	u, err := url.Parse(stream.url.Load())
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("token", stream.token.Load())
+	u.RawQuery = q.Encode()
+	config, err := websocket.NewConfig(u.String(), "http://localhost/")
+	if err != nil {
+		return nil, err
+	}
+	if ua := httpclient.UserAgent(); ua != "" {
+		config.Header.Set("User-Agent", ua)
+	}
+	// Dialing through httpclient uses the same proxy and root certificates as the rest api
+	ctx, cancel := context.WithTimeout(context.Background(), httpclient.TIMEOUT)
+	defer cancel()
+	conn, err := httpclient.DialContext(ctx, config.Location)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(httpclient.TIMEOUT))
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return ws, nil
+}
+
+func (stream *Stream) connectAndServe() error {
+	stream.mutex.Lock()
+	gen := stream.gen
+	stream.mutex.Unlock()
+	conn, err := stream.dial()
+	if err != nil {
+		return err
+	}
+	stream.mutex.Lock()
+	if gen != stream.gen {
+		// Token or url changed or stream stopped while dialing
+		stream.mutex.Unlock()
+		conn.Close()
+		return nil
+	}
+	stream.conn = conn
+	slugs := make([]string, 0, len(stream.handlers))
+	for slug := range stream.handlers {
+		slugs = append(slugs, slug)
+	}
+	stream.mutex.Unlock()
+	defer func() {
+		stream.mutex.Lock()
+		stream.conn = nil
+		stream.mutex.Unlock()
+		conn.Close()
+	}()
+	// Join every subscribed collection
+	for _, slug := range slugs {
+		if err := stream.send(collectionTopic(slug), "phx_join"); err != nil {
+			return err
+		}
+	}
+	stream.connected.Store(true)
+	log.Info().Println("Opensea stream connected with", len(slugs), "subscriptions")
+	// Heartbeat keeps the connection alive
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(streamHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := stream.send("phoenix", "heartbeat"); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	for {
+		// Server must answer heartbeats, so no message in two periods means a dead connection
+		conn.SetReadDeadline(time.Now().Add(streamHeartbeat * 2))
+		var msg phxMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			return err
+		}
+		stream.handle(msg)
+	}
+}
+
+func (stream *Stream) handle(msg phxMessage) {
+	switch StreamEventType(msg.Event) {
+	case ItemListed, ItemSold, ItemCancelled:
+	case "phx_error":
+		log.Warn().Println("Opensea stream channel error:", msg.Topic)
+		return
+	default:
+		// Replies and other events
+		return
+	}
+	var payload streamPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		log.Warn().Println("Invalid opensea stream event:", err)
+		return
+	}
+	event := payload.convert()
+	if event.Type == "" {
+		event.Type = StreamEventType(msg.Event)
+	}
+	stream.mutex.Lock()
+	handler, ok := stream.handlers[event.Collection]
+	stream.mutex.Unlock()
+	if ok {
+		handler(event)
+	}
+}
@@ -0,0 +1,209 @@
+package opensea
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nftsiren/pkg/number"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// Stand-in for the stream server, records joins and lets the test push events
+type streamStandIn struct {
+	t       *testing.T
+	server  *httptest.Server
+	mutex   sync.Mutex
+	conns   []*websocket.Conn
+	tokens  []string
+	joins   chan string
+	refuse  bool
+	connect chan struct{}
+}
+
+func newStreamStandIn(t *testing.T) *streamStandIn {
+	standIn := &streamStandIn{
+		t:       t,
+		joins:   make(chan string, 16),
+		connect: make(chan struct{}, 16),
+	}
+	standIn.server = httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		standIn.mutex.Lock()
+		if standIn.refuse {
+			standIn.mutex.Unlock()
+			conn.Close()
+			return
+		}
+		standIn.conns = append(standIn.conns, conn)
+		standIn.tokens = append(standIn.tokens, conn.Request().URL.Query().Get("token"))
+		standIn.mutex.Unlock()
+		standIn.connect <- struct{}{}
+		for {
+			var msg phxMessage
+			if err := websocket.JSON.Receive(conn, &msg); err != nil {
+				return
+			}
+			if msg.Event == "phx_join" {
+				standIn.joins <- msg.Topic
+			}
+			// Reply like phoenix does
+			websocket.JSON.Send(conn, phxMessage{
+				Topic:   msg.Topic,
+				Event:   "phx_reply",
+				Payload: json.RawMessage(`{"status":"ok","response":{}}`),
+				Ref:     msg.Ref,
+			})
+		}
+	}))
+	t.Cleanup(standIn.server.Close)
+	return standIn
+}
+
+func (standIn *streamStandIn) url() string {
+	return "ws" + strings.TrimPrefix(standIn.server.URL, "http") + "/socket/websocket"
+}
+
+func (standIn *streamStandIn) last() *websocket.Conn {
+	standIn.mutex.Lock()
+	defer standIn.mutex.Unlock()
+	return standIn.conns[len(standIn.conns)-1]
+}
+
+func (standIn *streamStandIn) push(slug, event, payload string) {
+	err := websocket.JSON.Send(standIn.last(), phxMessage{
+		Topic:   collectionTopic(slug),
+		Event:   event,
+		Payload: json.RawMessage(payload),
+	})
+	require.NoError(standIn.t, err)
+}
+
+func receive[T any](t *testing.T, ch chan T) T {
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+	var zero T
+	return zero
+}
+
+const listedPayload = `{
+	"event_type": "item_listed",
+	"sent_at": "2023-01-02T10:00:00.000000+00:00",
+	"payload": {
+		"item": {"nft_id": "ethereum/0xabc/42", "permalink": "https://opensea.io/assets/ethereum/0xabc/42"},
+		"collection": {"slug": "doodles-official"},
+		"base_price": "1250000000000000000",
+		"payment_token": {"symbol": "ETH", "decimals": 18},
+		"event_timestamp": "2023-01-02T10:00:00.000000+00:00"
+	}
+}`
+
+const soldPayload = `{
+	"event_type": "item_sold",
+	"payload": {
+		"item": {"nft_id": "ethereum/0xabc/7"},
+		"collection": {"slug": "doodles-official"},
+		"sale_price": "3000000",
+		"payment_token": {"symbol": "USDC", "decimals": 6}
+	}
+}`
+
+func TestStreamEvents(t *testing.T) {
+	standIn := newStreamStandIn(t)
+	stream := NewStream(standIn.url())
+	stream.Start()
+	defer stream.Stop()
+
+	events := make(chan StreamEvent, 16)
+	stream.Subscribe("doodles-official", func(event StreamEvent) { events <- event })
+	// No token, so no connection
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, stream.Connected())
+
+	stream.SetToken("secret")
+	receive(t, standIn.connect)
+	assert.Equal(t, "collection:doodles-official", receive(t, standIn.joins))
+	standIn.mutex.Lock()
+	assert.Equal(t, []string{"secret"}, standIn.tokens)
+	standIn.mutex.Unlock()
+	assert.Eventually(t, stream.Connected, time.Second, 10*time.Millisecond)
+	assert.True(t, stream.Subscribed("doodles-official"))
+	assert.False(t, stream.Subscribed("azuki"))
+
+	standIn.push("doodles-official", "item_listed", listedPayload)
+	event := receive(t, events)
+	assert.Equal(t, ItemListed, event.Type)
+	assert.Equal(t, "doodles-official", event.Collection)
+	assert.Equal(t, "ethereum/0xabc/42", event.NftID)
+	assert.Equal(t, "ETH", event.Currency)
+	assert.True(t, event.Price.Equals(number.NewFromFloat(1.25)), event.Price.String())
+	assert.Equal(t, time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC), event.Time.UTC())
+
+	standIn.push("doodles-official", "item_sold", soldPayload)
+	event = receive(t, events)
+	assert.Equal(t, ItemSold, event.Type)
+	assert.Equal(t, "USDC", event.Currency)
+	assert.True(t, event.Price.Equals(number.NewFromInt(3)), event.Price.String())
+
+	standIn.push("doodles-official", "item_cancelled", `{"event_type":"item_cancelled","payload":{"collection":{"slug":"doodles-official"}}}`)
+	event = receive(t, events)
+	assert.Equal(t, ItemCancelled, event.Type)
+	assert.True(t, event.Price.IsNil())
+
+	// Events of other collections and unknown events are ignored
+	standIn.push("azuki", "item_listed", strings.ReplaceAll(listedPayload, "doodles-official", "azuki"))
+	standIn.push("doodles-official", "item_metadata_updated", `{}`)
+	stream.Unsubscribe("doodles-official")
+	standIn.push("doodles-official", "item_listed", listedPayload)
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event: %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestStreamReconnect(t *testing.T) {
+	standIn := newStreamStandIn(t)
+	stream := NewStream(standIn.url())
+	stream.SetToken("secret")
+	stream.Start()
+	defer stream.Stop()
+
+	stream.Subscribe("azuki", func(StreamEvent) {})
+	receive(t, standIn.connect)
+	assert.Equal(t, "collection:azuki", receive(t, standIn.joins))
+
+	// Server drops the connection, stream falls back and then rejoins after backoff
+	standIn.mutex.Lock()
+	standIn.refuse = true
+	standIn.mutex.Unlock()
+	standIn.last().Close()
+	assert.Eventually(t, func() bool { return !stream.Connected() }, time.Second, 10*time.Millisecond)
+
+	standIn.mutex.Lock()
+	standIn.refuse = false
+	standIn.mutex.Unlock()
+	receive(t, standIn.connect)
+	assert.Equal(t, "collection:azuki", receive(t, standIn.joins))
+	assert.Eventually(t, stream.Connected, time.Second, 10*time.Millisecond)
+
+	// Subscribing while connected joins immediately
+	stream.Subscribe("doodles-official", func(StreamEvent) {})
+	assert.Equal(t, "collection:doodles-official", receive(t, standIn.joins))
+
+	// Changing the token reconnects with the new token
+	stream.SetToken("other")
+	receive(t, standIn.connect)
+	standIn.mutex.Lock()
+	assert.Equal(t, "other", standIn.tokens[len(standIn.tokens)-1])
+	standIn.mutex.Unlock()
+}
@@ -0,0 +1,251 @@
+// Package cassette implements a record/replay http.RoundTripper
+// Interactions are stored in json files so tests can run without network access
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"nftsiren/pkg/httpclient"
+)
+
+// Set this environment variable to a non empty value to record new cassettes from live hosts
+const RecordEnv = "NFTSIREN_RECORD"
+
+type Mode int
+
+const (
+	// Only serves recorded interactions, unknown requests return an error
+	ModeReplay Mode = iota
+	// Sends every request to the real transport and records the response
+	ModeRecord
+)
+
+func ModeFromEnv() Mode {
+	if os.Getenv(RecordEnv) != "" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+var ErrNotRecorded = errors.New("cassette: request not recorded")
+
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   json.RawMessage   `json:"body,omitempty"` // Used when body is valid json, keeps files readable
+	Raw    string            `json:"raw,omitempty"`  // Used for everything else
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is the round tripper, create it with New
+type Recorder struct {
+	// These query parameters are removed before recording and matching, so api keys don't leak into files
+	IgnoreParams []string
+	path         string
+	mode         Mode
+	real         http.RoundTripper
+	mutex        sync.Mutex
+	cassette     Cassette
+	played       map[int]bool
+}
+
+// New loads the cassette at path in replay mode, in record mode an empty cassette is started
+// real is only used in record mode, nil means http.DefaultTransport
+func New(path string, mode Mode, real http.RoundTripper) (*Recorder, error) {
+	if real == nil {
+		real = http.DefaultTransport
+	}
+	recorder := &Recorder{
+		IgnoreParams: []string{"apikey"},
+		path:         path,
+		mode:         mode,
+		real:         real,
+		played:       make(map[int]bool),
+	}
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(data, &recorder.cassette)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: %s: %w", path, err)
+		}
+	}
+	return recorder, nil
+}
+
+func (recorder *Recorder) Mode() Mode {
+	return recorder.mode
+}
+
+func (recorder *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	key := Request{
+		Method: req.Method,
+		URL:    recorder.cleanURL(req.URL),
+		Body:   string(body),
+	}
+	if recorder.mode == ModeRecord {
+		return recorder.record(req, key)
+	}
+	return recorder.replay(req, key)
+}
+
+func (recorder *Recorder) record(req *http.Request, key Request) (*http.Response, error) {
+	resp, err := recorder.real.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	recorded := Response{
+		Status: resp.StatusCode,
+		Header: map[string]string{},
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		recorded.Header["Content-Type"] = ct
+	}
+	if json.Valid(data) {
+		recorded.Body = data
+	} else {
+		recorded.Raw = string(data)
+	}
+	recorder.mutex.Lock()
+	recorder.cassette.Interactions = append(recorder.cassette.Interactions, Interaction{Request: key, Response: recorded})
+	recorder.mutex.Unlock()
+	return recorded.toHttp(req), nil
+}
+
+// Interactions are played in order, same request may be recorded multiple times with different responses
+func (recorder *Recorder) replay(req *http.Request, key Request) (*http.Response, error) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	fallback := -1
+	for i, interaction := range recorder.cassette.Interactions {
+		if interaction.Request != key {
+			continue
+		}
+		if !recorder.played[i] {
+			recorder.played[i] = true
+			return interaction.Response.toHttp(req), nil
+		}
+		fallback = i
+	}
+	// Every matching interaction is played, repeat the last one
+	if fallback >= 0 {
+		return recorder.cassette.Interactions[fallback].Response.toHttp(req), nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, key.Method, key.URL)
+}
+
+// Save writes recorded interactions to the cassette file, does nothing in replay mode
+func (recorder *Recorder) Save() error {
+	if recorder.mode != ModeRecord {
+		return nil
+	}
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	data, err := json.MarshalIndent(recorder.cassette, "", "\t")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(recorder.path), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(recorder.path, append(data, '\n'), 0644)
+}
+
+func (recorder *Recorder) cleanURL(u *url.URL) string {
+	clean := *u
+	q := clean.Query()
+	for _, param := range recorder.IgnoreParams {
+		q.Del(param)
+	}
+	clean.RawQuery = q.Encode()
+	return clean.String()
+}
+
+func (resp Response) toHttp(req *http.Request) *http.Response {
+	body := []byte(resp.Raw)
+	if len(resp.Body) > 0 {
+		body = resp.Body
+	}
+	header := make(http.Header)
+	for k, v := range resp.Header {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// TB is the part of testing.TB which Use needs, so this package doesn't import testing
+type TB interface {
+	Helper()
+	Fatal(args ...any)
+	Error(args ...any)
+	Cleanup(f func())
+}
+
+// Use loads the cassette at path into client for the duration of the test
+// Mode is chosen by ModeFromEnv and recorded interactions are saved at cleanup
+func Use(tb TB, client *httpclient.Client, path string) *Recorder {
+	tb.Helper()
+	recorder, err := New(path, ModeFromEnv(), nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	client.SetTransport(recorder)
+	tb.Cleanup(func() {
+		client.SetTransport(nil)
+		if err := recorder.Save(); err != nil {
+			tb.Error(err)
+		}
+	})
+	return recorder
+}
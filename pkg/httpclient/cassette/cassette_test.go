@@ -0,0 +1,80 @@
+package cassette
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"nftsiren/pkg/httpclient"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path":%q,"hit":%d}`, r.URL.Path, hits)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	// Record two responses for the same request
+	recorder, err := New(path, ModeRecord, nil)
+	require.NoError(t, err)
+	client := httpclient.NewClientWithTransport(server.URL, recorder)
+	client.SetDefaultHeader("X-Secret", "key")
+	var resp struct {
+		Path string `json:"path"`
+		Hit  int    `json:"hit"`
+	}
+	for i := 1; i <= 2; i++ {
+		status, err := client.GetJson([]string{"stats"}, map[string]string{"apikey": "secret"}, &resp)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, i, resp.Hit)
+	}
+	require.NoError(t, recorder.Save())
+	assert.Equal(t, 2, hits)
+
+	// Replay without touching the server, api key param must be ignored
+	recorder, err = New(path, ModeReplay, nil)
+	require.NoError(t, err)
+	client.SetTransport(recorder)
+	for _, want := range []int{1, 2, 2} {
+		_, err := client.GetJson([]string{"stats"}, map[string]string{"apikey": "other"}, &resp)
+		require.NoError(t, err)
+		assert.Equal(t, "/stats", resp.Path)
+		assert.Equal(t, want, resp.Hit)
+	}
+	assert.Equal(t, 2, hits)
+
+	// Unknown request
+	_, err = client.GetJson([]string{"unknown"}, nil, &resp)
+	assert.ErrorIs(t, err, ErrNotRecorded)
+}
+
+func TestReplayRawBody(t *testing.T) {
+	path := filepath.Join("testdata", "raw.json")
+	recorder, err := New(path, ModeReplay, nil)
+	require.NoError(t, err)
+	client := httpclient.NewClientWithTransport("https://example.com/api", recorder)
+	resp, err := client.Get("ping")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "slow down", string(body))
+}
+
+func TestMissingCassette(t *testing.T) {
+	_, err := New(filepath.Join("testdata", "does-not-exist.json"), ModeReplay, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,148 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// UserAgent returns the configured user agent, empty means go default
+func UserAgent() string {
+	return userAgent.Load()
+}
+
+// DialContext opens a connection to the host of target like the shared transport does,
+// through the configured proxy and with the configured root certificates
+// Connection is wrapped in tls for https and wss urls, useful for protocols like websocket
+func DialContext(ctx context.Context, target *url.URL) (net.Conn, error) {
+	t := transport.Load()
+	if t == nil {
+		t = http.DefaultTransport.(*http.Transport)
+	}
+	secure := target.Scheme == "https" || target.Scheme == "wss"
+	addr := hostPort(target.Hostname(), target.Port(), secure)
+
+	var proxyURL *url.URL
+	if t.Proxy != nil {
+		// Proxy functions only look at the url, scheme decides which proxy env variable is used
+		scheme := "http"
+		if secure {
+			scheme = "https"
+		}
+		var err error
+		proxyURL, err = t.Proxy(&http.Request{URL: &url.URL{Scheme: scheme, Host: target.Host}})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: TIMEOUT}
+	var conn net.Conn
+	var err error
+	switch {
+	case proxyURL == nil:
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	case proxyURL.Scheme == "socks5":
+		var d proxy.Dialer
+		d, err = proxy.FromURL(proxyURL, dialer)
+		if err == nil {
+			conn, err = d.(proxy.ContextDialer).DialContext(ctx, "tcp", addr)
+		}
+	default:
+		conn, err = dialConnect(ctx, dialer, proxyURL, addr, t.TLSClientConfig)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !secure {
+		return conn, nil
+	}
+	tlsConn, err := tlsHandshake(ctx, conn, target.Hostname(), t.TLSClientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+func hostPort(host, port string, secure bool) string {
+	if port == "" {
+		port = "80"
+		if secure {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(host, port)
+}
+
+func tlsHandshake(ctx context.Context, conn net.Conn, serverName string, config *tls.Config) (*tls.Conn, error) {
+	if config == nil {
+		config = &tls.Config{}
+	} else {
+		config = config.Clone()
+	}
+	config.ServerName = serverName
+	tlsConn := tls.Client(conn, config)
+	err := tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// Opens a tunnel to addr through an http or https proxy with the CONNECT method
+func dialConnect(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, addr string, config *tls.Config) (net.Conn, error) {
+	secure := proxyURL.Scheme == "https"
+	conn, err := dialer.DialContext(ctx, "tcp", hostPort(proxyURL.Hostname(), proxyURL.Port(), secure))
+	if err != nil {
+		return nil, err
+	}
+	if secure {
+		tlsConn, err := tlsHandshake(ctx, conn, proxyURL.Hostname(), config)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	err = req.Write(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// Proxy doesn't send anything after the response until the client speaks
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused connection: %s", resp.Status)
+	}
+	return conn, nil
+}
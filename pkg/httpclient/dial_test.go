@@ -0,0 +1,53 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialContextProxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello")
+	}))
+	defer target.Close()
+	var tunnels []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodConnect, r.Method)
+		tunnels = append(tunnels, r.Host)
+		dest, err := net.Dial("tcp", r.Host)
+		require.NoError(t, err)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		io.WriteString(conn, "HTTP/1.1 200 OK\r\n\r\n")
+		go func() {
+			io.Copy(dest, conn)
+			dest.Close()
+		}()
+		io.Copy(conn, dest)
+		conn.Close()
+	}))
+	defer proxy.Close()
+	require.NoError(t, Configure(TransportSettings{Proxy: proxy.URL}))
+	defer Configure(TransportSettings{})
+
+	u, _ := url.Parse(target.URL)
+	conn, err := DialContext(context.Background(), u)
+	require.NoError(t, err)
+	defer conn.Close()
+	req, _ := http.NewRequest(http.MethodGet, target.URL, nil)
+	require.NoError(t, req.Write(conn))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, []string{u.Host}, tunnels)
+}
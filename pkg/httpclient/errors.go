@@ -0,0 +1,28 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+)
+
+// StatusError is returned by api packages when the server answers with an error status
+type StatusError struct {
+	Code    int
+	Message string // Shown instead of the status text if not empty
+}
+
+func (err *StatusError) Error() string {
+	if err.Message != "" {
+		return err.Message
+	}
+	return http.StatusText(err.Code)
+}
+
+// IsAuthError reports whether the server rejected the api key of the request
+func IsAuthError(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.Code == http.StatusUnauthorized || statusErr.Code == http.StatusForbidden
+}
@@ -3,6 +3,7 @@ package httpclient
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"time"
@@ -18,21 +19,28 @@ const (
 )
 
 type Client struct {
-	handle    *http.Client
-	baseUrl   *url.URL
+	handle    mutex.Value[*http.Client]
+	baseUrl   mutex.Value[*url.URL]
 	rateLimit *rate.RateLimiter
 	headers   *mutex.Map[string, string]
 	inQueue   mutex.Counter
 }
 
 func NewClient(baseUrl string) *Client {
-	return &Client{
-		handle: &http.Client{
-			Timeout: TIMEOUT,
-		},
-		baseUrl: mustParseURL(baseUrl),
+	client := &Client{
 		headers: mutex.NewMap[string, string](),
 	}
+	client.baseUrl.Store(mustParseURL(baseUrl))
+	client.SetTransport(nil)
+	return client
+}
+
+// NewClientWithTransport creates a client which sends every request through rt
+// instead of the shared transport, mostly useful for tests
+func NewClientWithTransport(baseUrl string, rt http.RoundTripper) *Client {
+	client := NewClient(baseUrl)
+	client.SetTransport(rt)
+	return client
 }
 
 func mustParseURL(raw string) *url.URL {
@@ -49,6 +57,34 @@ func NewClientWithLimit(baseUrl string, limit int, interval time.Duration) *Clie
 	return client
 }
 
+// Changes the url that all request paths are joined to
+func (client *Client) SetBaseURL(baseUrl string) error {
+	u, err := url.Parse(baseUrl)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("base url must be absolute: %s", baseUrl)
+	}
+	client.baseUrl.Store(u)
+	return nil
+}
+
+func (client *Client) BaseURL() string {
+	return client.baseUrl.Load().String()
+}
+
+// SetTransport replaces the round tripper of this client, nil restores the shared transport
+func (client *Client) SetTransport(rt http.RoundTripper) {
+	if rt == nil {
+		rt = sharedTransport
+	}
+	client.handle.Store(&http.Client{
+		Timeout:   TIMEOUT,
+		Transport: rt,
+	})
+}
+
 // Default headers will be added to every request
 func (client *Client) SetDefaultHeader(key, value string) {
 	client.headers.Store(key, value)
@@ -87,7 +123,7 @@ func (client *Client) DoRequest(req *Request) (*http.Response, error) {
 		client.inQueue.Decrement()
 	}
 	// Build url
-	reqURL := req.URL(client.baseUrl)
+	reqURL := req.URL(client.baseUrl.Load())
 	// log.Debug().Println(req.Method, reqURL)
 	// Create request
 	httpReq, err := http.NewRequest(req.Method, reqURL, req.Payload)
@@ -104,7 +140,7 @@ func (client *Client) DoRequest(req *Request) (*http.Response, error) {
 		httpReq.Header.Set(k, v)
 	}
 	// Do request
-	return client.handle.Do(httpReq)
+	return client.handle.Load().Do(httpReq)
 }
 
 // Makes a GET request and returns response
@@ -117,6 +153,12 @@ func (client *Client) Get(path ...string) (*http.Response, error) {
 func (client *Client) GetJson(path []string, params map[string]string, respObjRef any) (int, error) {
 	req := NewRequest(http.MethodGet, path).SetAcceptJSON()
 	req.Params = params
+	return client.DoJson(req, respObjRef)
+}
+
+// DoJson makes the request and decodes the response body into respObjRef
+// Status code may zero if there is a network error, also may return json error
+func (client *Client) DoJson(req *Request, respObjRef any) (int, error) {
 	resp, err := client.DoRequest(req)
 	if err != nil {
 		return 0, err
@@ -151,10 +193,5 @@ func (client *Client) PostJson(path []string, params map[string]string, bodyObj
 		return 0, err
 	}
 	req := NewRequest(http.MethodPost, path).SetPayloadBytes(payload).SetContentTypeJSON().SetAcceptJSON()
-	resp, err := client.DoRequest(req)
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
-	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(respObjRef)
+	return client.DoJson(req, respObjRef)
 }
@@ -0,0 +1,196 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+type PageStyle int
+
+const (
+	// Response contains a cursor which is sent back in CursorParam to get the next page
+	CursorPages PageStyle = iota
+	// OffsetParam is increased by the number of items received
+	OffsetPages
+	// Response contains the url of the next page, absolute or relative to base url
+	NextURLPages
+)
+
+// Pagination describes a paginated list endpoint
+// R is the decoded response of a single page and T is the item type
+type Pagination[R any, T any] struct {
+	Style  PageStyle
+	Method string // GET if empty
+	Path   []string
+	Params map[string]string
+	// Names of the request parameters, only the one for the Style is required
+	CursorParam string
+	OffsetParam string
+	LimitParam  string // Optional, sent with PageSize
+	PageSize    int    // Offset style stops when a page has less items than this
+	// Budget, zero means unlimited
+	MaxPages int
+	MaxItems int
+	// Items returns the items of a page, must be set
+	Items func(resp *R) []T
+	// Next returns the cursor or next url, empty means this is the last page
+	// Not used in offset style
+	Next func(resp *R) string
+	// Check is optional and called for every decoded page, returned error stops the pager
+	Check func(status int, resp *R) error
+}
+
+// Pager fetches one page for every Next call, so only one page is kept in memory
+// Usage is same as bufio.Scanner:
+//
+//	pager := httpclient.Paginate(client, pagination)
+//	for pager.Next() {
+//		for _, item := range pager.Page() { ... }
+//	}
+//	if err := pager.Err(); err != nil { ... }
+type Pager[R any, T any] struct {
+	client     *Client
+	pagination Pagination[R, T]
+	page       []T
+	err        error
+	done       bool
+	pages      int
+	items      int
+	offset     int
+	cursor     string // Cursor or next url depending on style
+}
+
+var ErrPaginationLoop = errors.New("pagination returned the same cursor twice")
+
+func Paginate[R any, T any](client *Client, pagination Pagination[R, T]) *Pager[R, T] {
+	if pagination.Items == nil {
+		panic("httpclient: pagination requires Items")
+	}
+	if pagination.Style != OffsetPages && pagination.Next == nil {
+		panic("httpclient: pagination requires Next")
+	}
+	if pagination.Method == "" {
+		pagination.Method = http.MethodGet
+	}
+	return &Pager[R, T]{
+		client:     client,
+		pagination: pagination,
+	}
+}
+
+// Next fetches the next page, returns false when there are no more pages,
+// budget is exhausted or an error happened
+// Requests go through the client so rate limit is respected
+func (pager *Pager[R, T]) Next() bool {
+	p := &pager.pagination
+	pager.page = nil
+	if pager.done || pager.err != nil {
+		return false
+	}
+	if (p.MaxPages > 0 && pager.pages >= p.MaxPages) || (p.MaxItems > 0 && pager.items >= p.MaxItems) {
+		pager.done = true
+		return false
+	}
+	req := pager.request()
+	var resp R
+	status, err := pager.client.DoJson(req, &resp)
+	if err != nil {
+		if status >= 400 {
+			err = &StatusError{Code: status}
+		}
+		pager.err = err
+		return false
+	}
+	if p.Check != nil {
+		if err := p.Check(status, &resp); err != nil {
+			pager.err = err
+			return false
+		}
+	} else if status >= 400 {
+		pager.err = &StatusError{Code: status}
+		return false
+	}
+	items := p.Items(&resp)
+	pager.pages++
+	// Apply item budget
+	if p.MaxItems > 0 && pager.items+len(items) > p.MaxItems {
+		items = items[:p.MaxItems-pager.items]
+	}
+	pager.items += len(items)
+	pager.page = items
+	// Prepare for the next page
+	switch p.Style {
+	case OffsetPages:
+		pager.offset += len(items)
+		if len(items) == 0 || (p.PageSize > 0 && len(items) < p.PageSize) {
+			pager.done = true
+		}
+	default:
+		next := p.Next(&resp)
+		if next == "" {
+			pager.done = true
+		} else if next == pager.cursor {
+			pager.err = ErrPaginationLoop
+			pager.done = true
+		}
+		pager.cursor = next
+	}
+	return true
+}
+
+func (pager *Pager[R, T]) request() *Request {
+	p := &pager.pagination
+	req := NewRequest(p.Method, p.Path).SetAcceptJSON()
+	for k, v := range p.Params {
+		req.SetParam(k, v)
+	}
+	if p.LimitParam != "" && p.PageSize > 0 {
+		req.SetParam(p.LimitParam, strconv.Itoa(p.PageSize))
+	}
+	switch p.Style {
+	case CursorPages:
+		if pager.cursor != "" {
+			req.SetParam(p.CursorParam, pager.cursor)
+		}
+	case OffsetPages:
+		req.SetParam(p.OffsetParam, strconv.Itoa(pager.offset))
+	case NextURLPages:
+		if pager.cursor != "" {
+			// Next url already contains every parameter
+			req.SetRawURL(pager.cursor)
+			req.Params = nil
+		}
+	}
+	return req
+}
+
+// Page returns the items of the last fetched page
+func (pager *Pager[R, T]) Page() []T {
+	return pager.page
+}
+
+// Err returns the first error, nil when pages ended normally
+// The last page is still valid when the error is ErrPaginationLoop
+func (pager *Pager[R, T]) Err() error {
+	return pager.err
+}
+
+// Number of fetched pages so far
+func (pager *Pager[R, T]) Pages() int {
+	return pager.pages
+}
+
+// Number of received items so far
+func (pager *Pager[R, T]) Items() int {
+	return pager.items
+}
+
+// Collects every page into a slice, only use it with a budget or small lists
+func (pager *Pager[R, T]) All() ([]T, error) {
+	var all []T
+	for pager.Next() {
+		all = append(all, pager.Page()...)
+	}
+	return all, pager.Err()
+}
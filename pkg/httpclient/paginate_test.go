@@ -0,0 +1,184 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type listResponse struct {
+	Items  []int  `json:"items"`
+	Cursor string `json:"cursor"`
+	Next   string `json:"next"`
+}
+
+// Serves numbers from 0 to total in all three pagination styles
+func newListServer(t *testing.T, total int) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		q := r.URL.Query()
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		if limit == 0 {
+			limit = 3
+		}
+		start := 0
+		switch r.URL.Path {
+		case "/cursor":
+			if c := q.Get("cursor"); c != "" {
+				start, _ = strconv.Atoi(c[1:])
+			}
+		case "/offset":
+			start, _ = strconv.Atoi(q.Get("offset"))
+		case "/next":
+			start, _ = strconv.Atoi(q.Get("from"))
+		case "/error":
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		resp := listResponse{Items: []int{}}
+		for i := start; i < total && i < start+limit; i++ {
+			resp.Items = append(resp.Items, i)
+		}
+		if end := start + limit; end < total {
+			resp.Cursor = fmt.Sprintf("c%d", end)
+			resp.Next = fmt.Sprintf("/next?from=%d&limit=%d", end, limit)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func items(resp *listResponse) []int   { return resp.Items }
+func cursor(resp *listResponse) string { return resp.Cursor }
+func next(resp *listResponse) string   { return resp.Next }
+
+func TestCursorPagination(t *testing.T) {
+	server, requests := newListServer(t, 10)
+	client := NewClient(server.URL)
+	pager := Paginate(client, Pagination[listResponse, int]{
+		Style:       CursorPages,
+		Path:        []string{"cursor"},
+		CursorParam: "cursor",
+		Items:       items,
+		Next:        cursor,
+	})
+	var pages [][]int
+	for pager.Next() {
+		pages = append(pages, pager.Page())
+	}
+	require.NoError(t, pager.Err())
+	assert.Equal(t, [][]int{{0, 1, 2}, {3, 4, 5}, {6, 7, 8}, {9}}, pages)
+	assert.Equal(t, 4, pager.Pages())
+	assert.Equal(t, 10, pager.Items())
+	assert.Equal(t, 4, *requests)
+	assert.False(t, pager.Next())
+}
+
+func TestOffsetPagination(t *testing.T) {
+	server, requests := newListServer(t, 8)
+	client := NewClient(server.URL)
+	all, err := Paginate(client, Pagination[listResponse, int]{
+		Style:       OffsetPages,
+		Path:        []string{"offset"},
+		OffsetParam: "offset",
+		LimitParam:  "limit",
+		PageSize:    4,
+		Items:       items,
+	}).All()
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, all)
+	// Last page is full so one more empty page is requested
+	assert.Equal(t, 3, *requests)
+}
+
+func TestNextURLPagination(t *testing.T) {
+	server, _ := newListServer(t, 7)
+	client := NewClient(server.URL)
+	all, err := Paginate(client, Pagination[listResponse, int]{
+		Style:      NextURLPages,
+		Path:       []string{"next"},
+		LimitParam: "limit",
+		PageSize:   2,
+		Items:      items,
+		Next:       next,
+	}).All()
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, all)
+}
+
+func TestPaginationBudget(t *testing.T) {
+	server, requests := newListServer(t, 100)
+	client := NewClient(server.URL)
+	pagination := Pagination[listResponse, int]{
+		Style:       CursorPages,
+		Path:        []string{"cursor"},
+		CursorParam: "cursor",
+		Items:       items,
+		Next:        cursor,
+		MaxPages:    2,
+	}
+	all, err := Paginate(client, pagination).All()
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, all)
+	assert.Equal(t, 2, *requests)
+
+	pagination.MaxPages = 0
+	pagination.MaxItems = 5
+	all, err = Paginate(client, pagination).All()
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, all)
+	assert.Equal(t, 4, *requests)
+}
+
+func TestPaginationRateLimit(t *testing.T) {
+	server, _ := newListServer(t, 9)
+	client := NewClientWithLimit(server.URL, 1, 50*time.Millisecond)
+	begin := time.Now()
+	all, err := Paginate(client, Pagination[listResponse, int]{
+		Style:       CursorPages,
+		Path:        []string{"cursor"},
+		CursorParam: "cursor",
+		Items:       items,
+		Next:        cursor,
+	}).All()
+	require.NoError(t, err)
+	assert.Len(t, all, 9)
+	// Three requests with one request per 50ms
+	assert.GreaterOrEqual(t, time.Since(begin), 100*time.Millisecond)
+}
+
+func TestPaginationErrors(t *testing.T) {
+	server, _ := newListServer(t, 10)
+	client := NewClient(server.URL)
+	pager := Paginate(client, Pagination[listResponse, int]{
+		Style:       CursorPages,
+		Path:        []string{"error"},
+		CursorParam: "cursor",
+		Items:       items,
+		Next:        cursor,
+	})
+	assert.False(t, pager.Next())
+	assert.EqualError(t, pager.Err(), "Too Many Requests")
+
+	// Server always returns the same cursor
+	pager = Paginate(client, Pagination[listResponse, int]{
+		Style:       CursorPages,
+		Path:        []string{"cursor"},
+		CursorParam: "ignored",
+		Items:       items,
+		Next:        cursor,
+	})
+	assert.True(t, pager.Next())
+	assert.True(t, pager.Next())
+	assert.ErrorIs(t, pager.Err(), ErrPaginationLoop)
+	assert.False(t, pager.Next())
+}
@@ -10,6 +10,7 @@ import (
 
 type Request struct {
 	Method  string
+	RawURL  string // Overrides base url and path when set, may be relative to base url
 	Path    []string
 	Params  map[string]string
 	Header  map[string]string
@@ -25,7 +26,17 @@ func NewRequest(method string, path []string) *Request {
 }
 
 func (r *Request) URL(base *url.URL) string {
-	u := base.JoinPath(r.Path...)
+	var u *url.URL
+	if r.RawURL != "" {
+		ref, err := url.Parse(r.RawURL)
+		if err != nil {
+			// Let http.NewRequest report it
+			return r.RawURL
+		}
+		u = base.ResolveReference(ref)
+	} else {
+		u = base.JoinPath(r.Path...)
+	}
 	q := u.Query()
 	for k, v := range r.Params {
 		q.Set(k, v)
@@ -39,6 +50,11 @@ func (r *Request) SetMethod(method string) *Request {
 	return r
 }
 
+func (r *Request) SetRawURL(rawurl string) *Request {
+	r.RawURL = rawurl
+	return r
+}
+
 func (r *Request) SetPath(path []string) *Request {
 	r.Path = path
 	return r
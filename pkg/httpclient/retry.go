@@ -0,0 +1,86 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry decides how failed requests are repeated
+type Retry struct {
+	Attempts int           // Total tries including the first one
+	Backoff  time.Duration // Wait before the second try, doubles after every try
+	MaxWait  time.Duration // Longest wait, also limits Retry-After of the server, zero is unlimited
+}
+
+var DefaultRetry = Retry{Attempts: 3, Backoff: time.Second, MaxWait: 30 * time.Second}
+
+// Network errors, rate limits and server errors are worth trying again
+func IsRetryable(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+// Wait before the try after the given number of tries
+func (retry Retry) wait(tries int, resp *http.Response) time.Duration {
+	wait := retry.Backoff << (tries - 1)
+	if resp != nil {
+		if after, ok := RetryAfter(resp); ok {
+			wait = after
+		}
+	}
+	if retry.MaxWait > 0 && wait > retry.MaxWait {
+		wait = retry.MaxWait
+	}
+	return wait
+}
+
+// RetryAfter parses the Retry-After header in seconds or as a date
+func RetryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// DoRequestRetry makes the request until it succeeds or the attempts run out
+// Response of the last try is returned, caller checks its status like DoRequest
+func (client *Client) DoRequestRetry(req *Request, retry Retry) (*http.Response, error) {
+	// Payload is read once so every try sends the same body
+	var payload []byte
+	if req.Payload != nil {
+		var err error
+		payload, err = io.ReadAll(req.Payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+	attempts := max(retry.Attempts, 1)
+	for tries := 1; ; tries++ {
+		if payload != nil {
+			req.Payload = bytes.NewReader(payload)
+		}
+		resp, err := client.DoRequest(req)
+		status := 0
+		if err == nil {
+			status = resp.StatusCode
+		}
+		if tries >= attempts || !IsRetryable(status) {
+			return resp, err
+		}
+		wait := retry.wait(tries, resp)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		time.Sleep(wait)
+	}
+}
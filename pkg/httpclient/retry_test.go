@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoRequestRetry(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		switch len(bodies) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "0.01")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+	retry := Retry{Attempts: 3, Backoff: time.Millisecond}
+
+	req := NewRequest(http.MethodPost, []string{"hook"}).SetPayloadString("payload")
+	resp, err := client.DoRequestRetry(req, retry)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, []string{"payload", "payload", "payload"}, bodies)
+
+	// Client errors are not repeated
+	requests := 0
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer rejecting.Close()
+	resp, err = NewClient(rejecting.URL).DoRequestRetry(NewRequest(http.MethodPost, nil), retry)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, 1, requests)
+}
+
+func TestRetryWait(t *testing.T) {
+	retry := Retry{Backoff: time.Second, MaxWait: 5 * time.Second}
+	assert.Equal(t, time.Second, retry.wait(1, nil))
+	assert.Equal(t, 4*time.Second, retry.wait(3, nil))
+	assert.Equal(t, 5*time.Second, retry.wait(4, nil))
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"2"}}}
+	assert.Equal(t, 2*time.Second, retry.wait(3, resp))
+	resp.Header.Set("Retry-After", "120")
+	assert.Equal(t, 5*time.Second, retry.wait(1, resp))
+	assert.False(t, IsRetryable(http.StatusBadRequest))
+	assert.True(t, IsRetryable(http.StatusServiceUnavailable))
+}
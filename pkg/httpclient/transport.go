@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"nftsiren/pkg/mutex"
+)
+
+// TransportSettings is shared by every client in this package, including the
+// standalone Get function, so changing it reroutes all outbound traffic
+type TransportSettings struct {
+	Proxy      string // http://, https:// or socks5:// url, empty means direct connection
+	RootCAFile string // Path to a PEM file, certificates are added to the system pool
+	UserAgent  string // Empty means go default
+}
+
+var transport mutex.Value[*http.Transport]
+var userAgent mutex.Value[string]
+
+// Every client created in this package uses this round tripper, it always
+// delegates to the latest configured transport
+var sharedTransport http.RoundTripper = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+	if ua := userAgent.Load(); ua != "" && req.Header.Get("User-Agent") == "" {
+		// Round trippers must not modify the request of the caller
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", ua)
+	}
+	t := transport.Load()
+	if t == nil {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	return t.RoundTrip(req)
+})
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+// Builds a new transport from settings and replaces the current one
+// Current transport stays untouched if there is an error
+func Configure(settings TransportSettings) error {
+	t, err := NewTransport(settings)
+	if err != nil {
+		return err
+	}
+	if old := transport.Load(); old != nil {
+		old.CloseIdleConnections()
+	}
+	transport.Store(t)
+	userAgent.Store(settings.UserAgent)
+	return nil
+}
+
+// NewTransport creates a transport but does not install it, can be used for validating settings
+func NewTransport(settings TransportSettings) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if settings.Proxy != "" {
+		proxyURL, err := ParseProxyURL(settings.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		t.Proxy = http.ProxyURL(proxyURL)
+	}
+	if settings.RootCAFile != "" {
+		pool, err := loadRootCAs(settings.RootCAFile)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return t, nil
+}
+
+// Parses proxy url, host without scheme is treated as an http proxy
+func ParseProxyURL(raw string) (*url.URL, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("proxy host is empty")
+	}
+	return u, nil
+}
+
+func loadRootCAs(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		// System pool is not available on some platforms
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+var stdClient = &http.Client{
+	Timeout:   TIMEOUT,
+	Transport: sharedTransport,
+}
+
+// Get makes a GET request to the absolute url using the shared transport
+func Get(rawurl string) (*http.Response, error) {
+	return stdClient.Get(rawurl)
+}
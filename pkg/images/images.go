@@ -12,6 +12,7 @@ import (
 	"net/http"
 
 	"nftsiren/pkg/bench"
+	"nftsiren/pkg/httpclient"
 	"nftsiren/pkg/images/webp"
 
 	// "github.com/tidbyt/go-libwebp/webp"
@@ -22,7 +23,7 @@ import (
 const MAX_IMAGE_SIZE = 1024 * 1024 * 32 // 32 mb
 
 func Download(url string) (*image.RGBA, error) {
-	resp, err := http.Get(url)
+	resp, err := httpclient.Get(url)
 	if err != nil {
 		return nil, err
 	}
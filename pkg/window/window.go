@@ -0,0 +1,177 @@
+package window
+
+import (
+	"sync"
+	"time"
+
+	"nftsiren/pkg/number"
+)
+
+type Sample[T any] struct {
+	Time  time.Time
+	Value T
+}
+
+// Window keeps the samples of the last length duration in memory, safe for concurrent use
+// Do not copy Window
+type Window[T any] struct {
+	mutex      sync.Mutex
+	length     time.Duration
+	resolution time.Duration
+	samples    []Sample[T] // Ordered by time
+}
+
+// Samples closer than resolution to the previous one replace it, so frequently
+// updated values don't grow the window, resolution may be zero
+func New[T any](length, resolution time.Duration) *Window[T] {
+	return &Window[T]{
+		length:     length,
+		resolution: resolution,
+	}
+}
+
+func (w *Window[T]) Length() time.Duration {
+	return w.length
+}
+
+// Add appends a sample and drops the ones older than window length
+// Samples older than the latest one are ignored
+func (w *Window[T]) Add(t time.Time, value T) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if n := len(w.samples); n > 0 {
+		last := w.samples[n-1].Time
+		if t.Before(last) {
+			return
+		}
+		// Latest sample is replaced until it is resolution apart from the previous one
+		if n > 1 && last.Sub(w.samples[n-2].Time) < w.resolution {
+			w.samples[n-1] = Sample[T]{t, value}
+			return
+		}
+	}
+	w.samples = append(w.samples, Sample[T]{t, value})
+	// Drop expired samples
+	cutoff := t.Add(-w.length)
+	i := 0
+	for i < len(w.samples) && w.samples[i].Time.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		w.samples = append(w.samples[:0], w.samples[i:]...)
+	}
+}
+
+// Since returns a copy of the samples at or after t
+func (w *Window[T]) Since(t time.Time) []Sample[T] {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return append([]Sample[T](nil), After(w.samples, t)...)
+}
+
+// Samples returns a copy of every sample
+func (w *Window[T]) Samples() []Sample[T] {
+	return w.Since(time.Time{})
+}
+
+// After returns the part of ordered samples at or after t, it doesn't copy
+func After[T any](samples []Sample[T], t time.Time) []Sample[T] {
+	i := len(samples)
+	for i > 0 && !samples[i-1].Time.Before(t) {
+		i--
+	}
+	return samples[i:]
+}
+
+// ValueAt returns the value of the latest sample at or before t
+func ValueAt[T any](samples []Sample[T], t time.Time) (T, bool) {
+	for i := len(samples) - 1; i >= 0; i-- {
+		if !samples[i].Time.After(t) {
+			return samples[i].Value, true
+		}
+	}
+	return *new(T), false
+}
+
+func (w *Window[T]) Latest() (Sample[T], bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.samples) == 0 {
+		return Sample[T]{}, false
+	}
+	return w.samples[len(w.samples)-1], true
+}
+
+func (w *Window[T]) Len() int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return len(w.samples)
+}
+
+func (w *Window[T]) Clear() {
+	w.mutex.Lock()
+	w.samples = nil
+	w.mutex.Unlock()
+}
+
+// PercentChange compares the latest value with the highest and lowest values since t
+// Drop is how much it fell from the highest and rise is how much it rose from the lowest, in percent
+// Samples with nil values are skipped, ok is false when there are less than two values
+func PercentChange[T any](w *Window[T], since time.Time, value func(T) number.Number) (drop, rise number.Number, ok bool) {
+	return PercentChangeOf(w.Since(since), value)
+}
+
+// PercentChangeOf is PercentChange over ordered samples
+func PercentChangeOf[T any](samples []Sample[T], value func(T) number.Number) (drop, rise number.Number, ok bool) {
+	var high, low, latest number.Number
+	count := 0
+	for _, sample := range samples {
+		v := value(sample.Value)
+		if v.IsNil() {
+			continue
+		}
+		if count == 0 || v.GreaterThan(high) {
+			high = v
+		}
+		if count == 0 || v.LessThan(low) {
+			low = v
+		}
+		latest = v
+		count++
+	}
+	if count < 2 || high.IsZero() || low.IsZero() {
+		return number.Number{}, number.Number{}, false
+	}
+	drop = high.Sub(latest).Div(high).MulInt64(100)
+	rise = latest.Sub(low).Div(low).MulInt64(100)
+	return drop, rise, true
+}
+
+// RatioToAverage divides the latest value by the average of the older values since t
+// At least minSamples older values are required, zero average is not ok
+func RatioToAverage[T any](w *Window[T], since time.Time, minSamples int, value func(T) number.Number) (number.Number, bool) {
+	return RatioToAverageOf(w.Since(since), minSamples, value)
+}
+
+// RatioToAverageOf is RatioToAverage over ordered samples
+func RatioToAverageOf[T any](samples []Sample[T], minSamples int, value func(T) number.Number) (number.Number, bool) {
+	var values []number.Number
+	for _, sample := range samples {
+		if v := value(sample.Value); !v.IsNil() {
+			values = append(values, v)
+		}
+	}
+	if len(values) < 2 || len(values)-1 < minSamples {
+		return number.Number{}, false
+	}
+	latest := values[len(values)-1]
+	var sum number.Number
+	for _, v := range values[:len(values)-1] {
+		sum = sum.Add(v)
+	}
+	average := sum.DivInt64(int64(len(values) - 1))
+	if average.IsZero() {
+		return number.Number{}, false
+	}
+	return latest.Div(average), true
+}
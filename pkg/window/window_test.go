@@ -0,0 +1,145 @@
+package window
+
+import (
+	"testing"
+	"time"
+
+	"nftsiren/pkg/nft"
+	"nftsiren/pkg/number"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func num(n number.Number) number.Number { return n }
+
+func TestWindowExpiry(t *testing.T) {
+	begin := time.Now()
+	w := New[int](time.Hour, 0)
+	for i := 0; i < 120; i++ {
+		w.Add(begin.Add(time.Duration(i)*time.Minute), i)
+	}
+	// Only the last hour is kept
+	assert.Equal(t, 61, w.Len())
+	latest, ok := w.Latest()
+	assert.True(t, ok)
+	assert.Equal(t, 119, latest.Value)
+
+	samples := w.Since(begin.Add(110 * time.Minute))
+	assert.Len(t, samples, 10)
+	assert.Equal(t, 110, samples[0].Value)
+
+	// Out of order samples are ignored
+	w.Add(begin, -1)
+	latest, _ = w.Latest()
+	assert.Equal(t, 119, latest.Value)
+
+	w.Clear()
+	_, ok = w.Latest()
+	assert.False(t, ok)
+}
+
+func TestWindowResolution(t *testing.T) {
+	begin := time.Now()
+	w := New[int](time.Hour, 30*time.Second)
+	// One sample every 5 seconds for 5 minutes
+	for i := 0; i <= 60; i++ {
+		w.Add(begin.Add(time.Duration(i)*5*time.Second), i)
+	}
+	assert.Equal(t, 11, w.Len())
+	samples := w.Since(begin)
+	assert.Equal(t, 0, samples[0].Value)
+	// Latest value is never lost
+	assert.Equal(t, 60, samples[len(samples)-1].Value)
+}
+
+func TestPercentChange(t *testing.T) {
+	begin := time.Now()
+	w := New[number.Number](24*time.Hour, 0)
+	_, _, ok := PercentChange(w, begin, num)
+	assert.False(t, ok)
+
+	for i, v := range []float64{2, 2.5, 1.5, 2} {
+		w.Add(begin.Add(time.Duration(i)*time.Minute), number.NewFromFloat(v))
+	}
+	w.Add(begin.Add(4*time.Minute), number.Number{}) // Missing values are skipped
+
+	drop, rise, ok := PercentChange(w, begin, num)
+	assert.True(t, ok)
+	assert.Equal(t, 20.0, drop.Float64()) // 2.5 -> 2
+	assert.InDelta(t, 33.33, rise.Float64(), 0.01)
+
+	// Only last two minutes
+	drop, rise, ok = PercentChange(w, begin.Add(2*time.Minute), num)
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, drop.Float64())
+	assert.InDelta(t, 33.33, rise.Float64(), 0.01)
+}
+
+func TestPercentChangeOfStats(t *testing.T) {
+	begin := time.Now()
+	var samples []Sample[nft.CollectionStats]
+	for i, v := range [][2]int64{{1000, 40}, {1100, -1}, {1045, 50}, {1045, 60}} {
+		stats := nft.CollectionStats{NumOwners: number.NewFromInt(v[0])}
+		if v[1] >= 0 {
+			stats.Listed = number.NewFromInt(v[1])
+		}
+		samples = append(samples, Sample[nft.CollectionStats]{begin.Add(time.Duration(i) * time.Minute), stats})
+	}
+	owners := func(stats nft.CollectionStats) number.Number { return stats.NumOwners }
+	listed := func(stats nft.CollectionStats) number.Number { return stats.Listed }
+
+	drop, rise, ok := PercentChangeOf(samples, owners)
+	assert.True(t, ok)
+	assert.Equal(t, 5.0, drop.Float64()) // 1100 -> 1045
+	assert.Equal(t, 4.5, rise.Float64()) // 1000 -> 1045
+
+	// Missing listed counts are skipped
+	drop, rise, ok = PercentChangeOf(samples, listed)
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, drop.Float64())
+	assert.Equal(t, 50.0, rise.Float64()) // 40 -> 60
+	_, _, ok = PercentChangeOf(samples[1:3], listed)
+	assert.False(t, ok)
+}
+
+func TestRatioToAverage(t *testing.T) {
+	begin := time.Now()
+	w := New[number.Number](7*24*time.Hour, 0)
+	for i, v := range []int64{10, 12, 8, 10, 40} {
+		w.Add(begin.Add(time.Duration(i)*time.Hour), number.NewFromInt(v))
+	}
+	ratio, ok := RatioToAverage(w, begin, 4, num)
+	assert.True(t, ok)
+	assert.Equal(t, 4.0, ratio.Float64())
+
+	// Not enough baseline
+	_, ok = RatioToAverage(w, begin, 5, num)
+	assert.False(t, ok)
+	_, ok = RatioToAverage(w, begin.Add(4*time.Hour), 0, num)
+	assert.False(t, ok)
+
+	// Zero baseline
+	w = New[number.Number](time.Hour, 0)
+	w.Add(begin, number.NewFromInt(0))
+	w.Add(begin.Add(time.Minute), number.NewFromInt(5))
+	_, ok = RatioToAverage(w, begin, 1, num)
+	assert.False(t, ok)
+}
+
+func TestSampleHelpers(t *testing.T) {
+	begin := time.Now()
+	w := New[int](time.Hour, 0)
+	for i := 0; i < 5; i++ {
+		w.Add(begin.Add(time.Duration(i)*time.Minute), i)
+	}
+	samples := w.Samples()
+	assert.Len(t, samples, 5)
+	assert.Len(t, After(samples, begin.Add(3*time.Minute)), 2)
+	assert.Empty(t, After(samples, begin.Add(time.Hour)))
+
+	v, ok := ValueAt(samples, begin.Add(150*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+	_, ok = ValueAt(samples, begin.Add(-time.Second))
+	assert.False(t, ok)
+}
@@ -21,9 +21,14 @@ type Settings struct {
 
 type Worker struct {
 	Settings
-	stop chan struct{}
+	stop    chan struct{}
+	trigger chan struct{}
+	jobs    chan func()
 }
 
+// Jobs waiting to run on the worker, Post fails when it is full
+const maxJobs = 64
+
 func New(settings Settings) *Worker {
 	if settings.Interval <= 0 {
 		panic("Worker interval must bigger than zero")
@@ -34,7 +39,7 @@ func New(settings Settings) *Worker {
 	if settings.EnableLogging {
 		log.Debug().Println("Worker", settings.Name, "created")
 	}
-	return &Worker{Settings: settings, stop: make(chan struct{})}
+	return &Worker{Settings: settings, stop: make(chan struct{}), trigger: make(chan struct{}, 1), jobs: make(chan func(), maxJobs)}
 }
 
 func (w *Worker) start(panicCount int) {
@@ -59,6 +64,16 @@ func (w *Worker) start(panicCount int) {
 				log.Debug().Println("Worker", w.Name, "stopped")
 			}
 			return
+		case <-w.trigger:
+			begin := time.Now()
+			w.Work()
+			if w.EnableLogging {
+				log.Debug().Println("Worker", w.Name, "triggered and worked in", time.Since(begin))
+			}
+			prev = begin
+			ticker.Reset(w.Interval)
+		case job := <-w.jobs:
+			job()
 		case <-ticker.C:
 			begin := time.Now()
 			w.Work()
@@ -91,6 +106,26 @@ func (w *Worker) Start() {
 	go w.start(0)
 }
 
+// Trigger runs the work as soon as possible without waiting for the interval
+// Multiple triggers before the work starts are merged into one
+func (w *Worker) Trigger() {
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Post runs the job on the worker goroutine, so it never runs at the same time as the work
+// Returns false without running the job when too many jobs are waiting
+func (w *Worker) Post(job func()) bool {
+	select {
+	case w.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
 func (w *Worker) Stop() {
 	close(w.stop)
 }